@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/turahe/pkg/logger"
+)
+
+// ErrNotFound is returned by loaders when the value does not exist; the result is cached for
+// Options.NegativeTTL. Get returns errors matching it for both fresh and cached not-found results.
+var ErrNotFound = errors.New("cache: not found")
+
+// Entry kinds in the stored envelope.
+const (
+	kindValue    byte = 1
+	kindNotFound byte = 2
+)
+
+// headerSize is the envelope header: kind (1 byte) and fresh-until time in Unix nanoseconds (8 bytes).
+const headerSize = 9
+
+// Cache is a typed cache-aside layer over a Store. Use GetWith (or Get for the default Cache) to read through
+// it. Cache is safe for concurrent use.
+type Cache struct {
+	store Store
+	opts  Options
+	group singleflight.Group
+	now   func() time.Time
+	rand  func() float64
+}
+
+// New returns a Cache on store. Options are applied first, then override.
+func New(store Store, opts Options, override ...Option) *Cache {
+	for _, o := range override {
+		o(&opts)
+	}
+	opts.applyDefaults()
+	return &Cache{store: store, opts: opts, now: time.Now, rand: rand.Float64}
+}
+
+// Get reads key through the default Cache (see Setup); without one it calls loader directly.
+func Get[T any](ctx context.Context, key string, ttl time.Duration, loader func(context.Context) (T, error)) (T, error) {
+	c := Default()
+	if c == nil {
+		return loader(ctx)
+	}
+	return GetWith(ctx, c, key, ttl, loader)
+}
+
+// GetWith returns the cached value of key, or calls loader on a miss and caches its result for ttl (0: no
+// expiry), shortened by jitter. Concurrent misses for a key share one loader call. Loader errors matching
+// Options.NotFound are cached for NegativeTTL and returned wrapped with ErrNotFound; other errors are not
+// cached. With StaleTTL, an expired entry is returned while a background refresh replaces it. Store failures
+// are logged and fall back to loader, so an unavailable cache degrades to direct loads.
+func GetWith[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, loader func(context.Context) (T, error)) (T, error) {
+	var zero T
+	k := c.opts.Prefix + key
+	load := func(ctx context.Context) (any, error) { return loader(ctx) }
+
+	// Entries past their TTL are served only as stale values within StaleTTL: a store may keep them longer
+	// (e.g. the local tier of a TieredStore).
+	if kind, fresh, payload, ok := c.read(ctx, k); ok && (fresh || (kind == kindValue && c.opts.StaleTTL > 0)) {
+		var v T
+		decoded := kind == kindNotFound || c.opts.Codec.Unmarshal(payload, &v) == nil
+		if decoded {
+			if !fresh {
+				c.refresh(ctx, k, ttl, load)
+			}
+			if kind == kindNotFound {
+				return zero, fmt.Errorf("%w: %s", ErrNotFound, key)
+			}
+			return v, nil
+		}
+		logger.WarnfContext(ctx, "cache: discarding undecodable entry %s", k)
+	}
+
+	ch := c.group.DoChan(k, func() (any, error) { return c.loadAndStore(ctx, k, ttl, load) })
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		v, ok := res.Val.(T)
+		if !ok {
+			return zero, fmt.Errorf("cache: key %s loaded as %T, not %T", key, res.Val, zero)
+		}
+		return v, nil
+	}
+}
+
+// Set stores value under key for ttl (jittered), replacing any cached value.
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.write(ctx, c.opts.Prefix+key, value, ttl)
+}
+
+// Delete removes keys, so the next read loads them again.
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = c.opts.Prefix + k
+	}
+	return c.store.Delete(ctx, prefixed...)
+}
+
+// read returns the entry of k; ok is false on a miss, a store error, or a corrupt envelope.
+func (c *Cache) read(ctx context.Context, k string) (kind byte, fresh bool, payload []byte, ok bool) {
+	b, err := c.store.Get(ctx, k)
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			logger.WarnfContext(ctx, "cache: get %s failed, loading directly: %v", k, err)
+		}
+		return 0, false, nil, false
+	}
+	if len(b) < headerSize || (b[0] != kindValue && b[0] != kindNotFound) {
+		return 0, false, nil, false
+	}
+	freshUntil := int64(binary.BigEndian.Uint64(b[1:headerSize]))
+	fresh = freshUntil == 0 || c.now().UnixNano() < freshUntil
+	return b[0], fresh, b[headerSize:], true
+}
+
+// refresh reloads k in the background, at most once at a time per key.
+func (c *Cache) refresh(ctx context.Context, k string, ttl time.Duration, load func(context.Context) (any, error)) {
+	c.group.DoChan(k, func() (any, error) {
+		v, err := c.loadAndStore(ctx, k, ttl, load)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			logger.WarnfContext(ctx, "cache: background refresh of %s failed: %v", k, err)
+		}
+		return v, err
+	})
+}
+
+// loadAndStore calls load, detached from ctx's cancellation and bounded by LoadTimeout, and caches its value
+// or a not-found marker.
+func (c *Cache) loadAndStore(ctx context.Context, k string, ttl time.Duration, load func(context.Context) (any, error)) (any, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.opts.LoadTimeout)
+	defer cancel()
+	v, err := load(ctx)
+	if err != nil {
+		if !c.opts.NotFound(err) {
+			return nil, err
+		}
+		if c.opts.NegativeTTL > 0 {
+			if err := c.put(ctx, k, kindNotFound, nil, c.opts.NegativeTTL, c.opts.NegativeTTL); err != nil {
+				logger.WarnfContext(ctx, "cache: set %s failed: %v", k, err)
+			}
+		}
+		if !errors.Is(err, ErrNotFound) {
+			err = fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		return nil, err
+	}
+	if err := c.write(ctx, k, v, ttl); err != nil {
+		logger.WarnfContext(ctx, "cache: set %s failed: %v", k, err)
+	}
+	return v, nil
+}
+
+// write encodes v and stores it with a jittered TTL plus the stale window.
+func (c *Cache) write(ctx context.Context, k string, v any, ttl time.Duration) error {
+	payload, err := c.opts.Codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cache: encode %s: %w", k, err)
+	}
+	if ttl <= 0 {
+		return c.put(ctx, k, kindValue, payload, 0, 0)
+	}
+	if c.opts.Jitter > 0 {
+		ttl -= time.Duration(c.rand() * c.opts.Jitter * float64(ttl))
+	}
+	return c.put(ctx, k, kindValue, payload, ttl, ttl+c.opts.StaleTTL)
+}
+
+// put stores an envelope that is fresh for freshFor (0: always) and kept for keep (0: no expiry).
+func (c *Cache) put(ctx context.Context, k string, kind byte, payload []byte, freshFor, keep time.Duration) error {
+	b := make([]byte, headerSize, headerSize+len(payload))
+	b[0] = kind
+	if freshFor > 0 {
+		binary.BigEndian.PutUint64(b[1:], uint64(c.now().Add(freshFor).UnixNano()))
+	}
+	b = append(b, payload...)
+	return c.store.Set(ctx, k, b, keep)
+}
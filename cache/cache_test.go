@@ -0,0 +1,381 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// clock is a settable time source shared by a Cache and its MemoryStore.
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestCache(opts Options, override ...Option) (*Cache, *MemoryStore, *clock) {
+	clk := &clock{t: time.Unix(1_700_000_000, 0)}
+	store := NewMemoryStore()
+	store.now = clk.now
+	c := New(store, opts, override...)
+	c.now = clk.now
+	c.rand = func() float64 { return 0 }
+	return c, store, clk
+}
+
+func TestCodecs(t *testing.T) {
+	in := user{ID: 7, Name: "Ada"}
+	for name, codec := range map[string]Codec{"json": JSON, "msgpack": Msgpack, "gob": Gob} {
+		t.Run(name, func(t *testing.T) {
+			b, err := codec.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var out user
+			if err := codec.Unmarshal(b, &out); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if out != in {
+				t.Errorf("round trip = %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestGetWith_CachesValue(t *testing.T) {
+	for name, codec := range map[string]Codec{"json": JSON, "msgpack": Msgpack, "gob": Gob} {
+		t.Run(name, func(t *testing.T) {
+			c, _, _ := newTestCache(Options{}, WithCodec(codec), WithPrefix("svc:"))
+			var calls int
+			loader := func(context.Context) (user, error) {
+				calls++
+				return user{ID: 1, Name: "Ada"}, nil
+			}
+			for range 3 {
+				got, err := GetWith(context.Background(), c, "user:1", time.Minute, loader)
+				if err != nil {
+					t.Fatalf("GetWith: %v", err)
+				}
+				if got.Name != "Ada" {
+					t.Errorf("got %+v", got)
+				}
+			}
+			if calls != 1 {
+				t.Errorf("loader calls = %d, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestGetWith_Expiry(t *testing.T) {
+	c, _, clk := newTestCache(Options{})
+	var calls int
+	loader := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	ctx := context.Background()
+	if v, _ := GetWith(ctx, c, "k", time.Minute, loader); v != 1 {
+		t.Fatalf("first = %d, want 1", v)
+	}
+	clk.advance(time.Minute)
+	if v, _ := GetWith(ctx, c, "k", time.Minute, loader); v != 2 {
+		t.Errorf("after expiry = %d, want 2", v)
+	}
+}
+
+func TestGetWith_Singleflight(t *testing.T) {
+	c, _, _ := newTestCache(Options{})
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "v", nil
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := GetWith(context.Background(), c, "hot", time.Minute, loader)
+			if err == nil && v != "v" {
+				err = errors.New("unexpected value " + v)
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("loader calls = %d, want 1", got)
+	}
+}
+
+func TestGetWith_CallerCancel(t *testing.T) {
+	c, _, _ := newTestCache(Options{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (string, error) {
+		<-release
+		return "v", ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := GetWith(ctx, c, "k", time.Minute, loader)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	close(release)
+
+	// The shared load was not cancelled with its first caller, so it completes and is cached.
+	v, err := GetWith(context.Background(), c, "k", time.Minute, loader)
+	if err != nil || v != "v" {
+		t.Errorf("GetWith = %q, %v", v, err)
+	}
+}
+
+func TestGetWith_NegativeCaching(t *testing.T) {
+	c, _, clk := newTestCache(Options{}, WithNegativeTTL(time.Second))
+	var calls int
+	loader := func(context.Context) (*user, error) {
+		calls++
+		return nil, ErrNotFound
+	}
+	ctx := context.Background()
+	for range 2 {
+		if _, err := GetWith(ctx, c, "missing", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+	clk.advance(time.Second)
+	if _, err := GetWith(ctx, c, "missing", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	if calls != 2 {
+		t.Errorf("loader calls after NegativeTTL = %d, want 2", calls)
+	}
+}
+
+func TestGetWith_NotFoundPredicate(t *testing.T) {
+	errNoRow := errors.New("record not found")
+	c, _, _ := newTestCache(Options{}, WithNotFound(func(err error) bool { return errors.Is(err, errNoRow) }))
+	var calls int
+	loader := func(context.Context) (int, error) {
+		calls++
+		return 0, errNoRow
+	}
+	for range 2 {
+		_, err := GetWith(context.Background(), c, "k", time.Minute, loader)
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+}
+
+func TestGetWith_ErrorsNotCached(t *testing.T) {
+	c, _, _ := newTestCache(Options{})
+	boom := errors.New("db down")
+	var calls int
+	loader := func(context.Context) (int, error) {
+		calls++
+		return 0, boom
+	}
+	for range 2 {
+		if _, err := GetWith(context.Background(), c, "k", time.Minute, loader); !errors.Is(err, boom) {
+			t.Fatalf("err = %v, want %v", err, boom)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("loader calls = %d, want 2", calls)
+	}
+}
+
+func TestGetWith_StaleWhileRevalidate(t *testing.T) {
+	c, _, clk := newTestCache(Options{}, WithStaleWhileRevalidate(time.Minute))
+	var calls atomic.Int32
+	refreshed := make(chan struct{}, 1)
+	loader := func(context.Context) (int32, error) {
+		n := calls.Add(1)
+		if n > 1 {
+			refreshed <- struct{}{}
+		}
+		return n, nil
+	}
+	ctx := context.Background()
+	if v, _ := GetWith(ctx, c, "k", time.Minute, loader); v != 1 {
+		t.Fatalf("first = %d, want 1", v)
+	}
+
+	clk.advance(90 * time.Second) // past the TTL, within the stale window
+	if v, err := GetWith(ctx, c, "k", time.Minute, loader); err != nil || v != 1 {
+		t.Fatalf("stale read = %d, %v; want 1 (stale)", v, err)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("background refresh did not run")
+	}
+	// The refresh stores its value after returning it; wait for the write.
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, _ := GetWith(ctx, c, "k", time.Minute, loader)
+		if v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("value after refresh = %d, want 2", v)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	clk.advance(3 * time.Minute) // past TTL and stale window
+	if v, _ := GetWith(ctx, c, "k", time.Minute, loader); v != 3 {
+		t.Errorf("after stale window = %d, want 3 (synchronous load)", v)
+	}
+}
+
+func TestWrite_Jitter(t *testing.T) {
+	c, store, clk := newTestCache(Options{Jitter: 0.2})
+	c.rand = func() float64 { return 0.5 }
+	if err := c.Set(context.Background(), "k", 1, 100*time.Second); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	want := clk.now().Add(90 * time.Second)
+	if got := store.entries["k"].expires; !got.Equal(want) {
+		t.Errorf("expires = %v, want %v", got, want)
+	}
+
+	c.opts.Jitter = -1
+	c.Set(context.Background(), "k", 1, 100*time.Second)
+	if got := store.entries["k"].expires; !got.Equal(clk.now().Add(100 * time.Second)) {
+		t.Errorf("expires without jitter = %v", got)
+	}
+}
+
+func TestGetWith_UndecodableEntry(t *testing.T) {
+	c, store, _ := newTestCache(Options{})
+	ctx := context.Background()
+	if err := c.Set(ctx, "k", "not a number", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	store.Set(ctx, "garbage", []byte("x"), 0)
+
+	for _, key := range []string{"k", "garbage"} {
+		v, err := GetWith(ctx, c, key, time.Minute, func(context.Context) (int, error) { return 42, nil })
+		if err != nil || v != 42 {
+			t.Errorf("%s: GetWith = %d, %v; want 42 from loader", key, v, err)
+		}
+	}
+}
+
+// failingStore fails every operation.
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, error) { return nil, errors.New("down") }
+func (failingStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (failingStore) Delete(context.Context, ...string) error { return errors.New("down") }
+
+func TestGetWith_StoreDown(t *testing.T) {
+	c := New(failingStore{}, Options{})
+	v, err := GetWith(context.Background(), c, "k", time.Minute, func(context.Context) (string, error) { return "v", nil })
+	if err != nil || v != "v" {
+		t.Errorf("GetWith = %q, %v; want loader result", v, err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c, _, _ := newTestCache(Options{}, WithPrefix("p:"))
+	ctx := context.Background()
+	var calls int
+	loader := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	GetWith(ctx, c, "k", time.Minute, loader)
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if v, _ := GetWith(ctx, c, "k", time.Minute, loader); v != 2 {
+		t.Errorf("after Delete = %d, want 2", v)
+	}
+}
+
+func TestGet_Default(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	SetDefault(nil)
+	var calls int
+	loader := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	ctx := context.Background()
+	Get(ctx, "k", time.Minute, loader)
+	Get(ctx, "k", time.Minute, loader)
+	if calls != 2 {
+		t.Errorf("without default: loader calls = %d, want 2", calls)
+	}
+
+	c, _, _ := newTestCache(Options{})
+	SetDefault(c)
+	Get(ctx, "k", time.Minute, loader)
+	Get(ctx, "k", time.Minute, loader)
+	if calls != 3 {
+		t.Errorf("with default: loader calls = %d, want 3", calls)
+	}
+}
+
+func TestGetWith_ExpiredEntryWithoutStaleTTL(t *testing.T) {
+	c, _, clk := newTestCache(Options{})
+	ctx := context.Background()
+	// A store may keep an entry past its TTL (e.g. the local tier of a TieredStore).
+	if err := c.put(ctx, "k", kindValue, []byte("1"), time.Second, time.Hour); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	clk.advance(2 * time.Second)
+	v, err := GetWith(ctx, c, "k", time.Minute, func(context.Context) (int, error) { return 2, nil })
+	if err != nil || v != 2 {
+		t.Errorf("GetWith = %d, %v; want 2 from loader, not the expired entry", v, err)
+	}
+}
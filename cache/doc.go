@@ -0,0 +1,33 @@
+/*
+Package cache provides a typed cache-aside layer: Get[T] returns a cached value or loads, caches, and returns
+it.
+
+Role in architecture:
+  - Infrastructure service over Redis (RedisStore on the client of package redis) or any Store; services wrap
+    repository reads in Get instead of hand-writing GET / unmarshal / SET.
+
+Responsibilities:
+  - Get and GetWith: cache-aside with singleflight, so concurrent misses for a key share one loader call;
+    negative caching of not-found results (ErrNotFound or Options.NotFound) for Options.NegativeTTL; TTL
+    jitter (Options.Jitter) against synchronized expiry; and stale-while-revalidate (Options.StaleTTL),
+    serving an expired entry while one background load refreshes it.
+  - Codecs: JSON (default), Msgpack (github.com/vmihailenco/msgpack/v5, honouring json tags), and Gob.
+  - Stores: RedisStore (standalone or cluster), MemoryStore for tests, and TieredStore, an in-process LRU
+    tier (bounded by TieredOptions.MaxEntries, MaxBytes, and LocalTTL) in front of a remote Store whose Set
+    and Delete broadcast invalidations through a Broker (RedisBroker: redis.PublishMessage and
+    redis.SubscribeToChannel) to every instance running Listen.
+  - Metrics (RegisterMetrics): cache_requests_total by tier and result, local tier size, evictions, and
+    invalidations.
+  - Setup, Default, SetDefault: the default Cache on the configured Redis client.
+
+Constraints:
+  - Store errors are logged and never fail a Get: the loader result is returned uncached.
+  - Loaders run detached from the caller's cancellation, bounded by Options.LoadTimeout (default 10s).
+  - Singleflight is per process; instances may still load the same key concurrently once each.
+  - Pub/sub is at-most-once: a lost invalidation leaves a local copy stale for at most LocalTTL, and Listen
+    purges the local tier whenever its subscription fails.
+
+This package must NOT:
+  - Know about repositories, models, or HTTP; keys and loaders come from callers.
+*/
+package cache
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Tier metrics are shared by every TieredStore and distinguished by the cache label (TieredOptions.Name).
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Cache lookups by tier (local, remote) and result (hit, miss).",
+		},
+		[]string{"cache", "tier", "result"},
+	)
+	localEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_local_entries",
+			Help: "Entries held in the in-process cache tier.",
+		},
+		[]string{"cache"},
+	)
+	localBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cache_local_bytes",
+			Help: "Bytes of keys and values held in the in-process cache tier.",
+		},
+		[]string{"cache"},
+	)
+	localEvictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_local_evictions_total",
+			Help: "Entries evicted from the in-process cache tier to stay within its budget.",
+		},
+		[]string{"cache"},
+	)
+	invalidationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_invalidations_total",
+			Help: "Invalidation messages by direction (sent, received).",
+		},
+		[]string{"cache", "direction"},
+	)
+)
+
+// RegisterMetrics registers the TieredStore metrics on reg (prometheus.DefaultRegisterer when nil):
+// cache_requests_total, cache_local_entries, cache_local_bytes, cache_local_evictions_total, and
+// cache_invalidations_total. Registering twice is a no-op.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	for _, c := range []prometheus.Collector{requestsTotal, localEntries, localBytes, localEvictionsTotal, invalidationsTotal} {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultJitter      = 0.1
+	defaultNegativeTTL = 30 * time.Second
+	defaultLoadTimeout = 10 * time.Second
+)
+
+// Options holds Cache settings. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Prefix is prepended to every key, e.g. "users-svc:"; default none.
+	Prefix string
+	// Codec serialises values; default JSON.
+	Codec Codec
+	// Jitter shortens each TTL by a random fraction up to Jitter, so entries written together do not expire
+	// together; default 0.1 (up to 10%). Negative disables jitter.
+	Jitter float64
+	// NegativeTTL is how long a not-found result from a loader is cached; default 30s. Negative disables
+	// negative caching.
+	NegativeTTL time.Duration
+	// NotFound reports whether a loader error means "does not exist" and should be cached as such; default
+	// errors.Is(err, ErrNotFound).
+	NotFound func(error) bool
+	// StaleTTL keeps entries for this long after their TTL (stale-while-revalidate): a stale hit is returned
+	// immediately and refreshed in the background. Default 0 disables it.
+	StaleTTL time.Duration
+	// LoadTimeout bounds each loader call; default 10s. Loaders run detached from the caller's context, so a
+	// caller that gives up does not fail the others sharing the call.
+	LoadTimeout time.Duration
+}
+
+func (o *Options) applyDefaults() {
+	if o.Codec == nil {
+		o.Codec = JSON
+	}
+	if o.Jitter == 0 {
+		o.Jitter = defaultJitter
+	}
+	if o.NegativeTTL == 0 {
+		o.NegativeTTL = defaultNegativeTTL
+	}
+	if o.NotFound == nil {
+		o.NotFound = func(err error) bool { return errors.Is(err, ErrNotFound) }
+	}
+	if o.LoadTimeout <= 0 {
+		o.LoadTimeout = defaultLoadTimeout
+	}
+}
+
+// Option is a functional option applied to Options.
+type Option func(*Options)
+
+// WithPrefix sets the key prefix.
+func WithPrefix(prefix string) Option {
+	return func(o *Options) { o.Prefix = prefix }
+}
+
+// WithCodec sets the value codec.
+func WithCodec(c Codec) Option {
+	return func(o *Options) { o.Codec = c }
+}
+
+// WithStaleWhileRevalidate serves entries up to d past their TTL while refreshing them in the background.
+func WithStaleWhileRevalidate(d time.Duration) Option {
+	return func(o *Options) { o.StaleTTL = d }
+}
+
+// WithNegativeTTL sets how long not-found results are cached; negative disables it.
+func WithNegativeTTL(d time.Duration) Option {
+	return func(o *Options) { o.NegativeTTL = d }
+}
+
+// WithNotFound sets the predicate for loader errors that are cached as not found (e.g. gorm.ErrRecordNotFound).
+func WithNotFound(f func(error) bool) Option {
+	return func(o *Options) { o.NotFound = f }
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"sync/atomic"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/redis"
+)
+
+var defaultCache atomic.Pointer[Cache]
+
+// Setup creates the default Cache on the Redis client of package redis (call redis.Setup first). No-op when
+// Redis is disabled: Get then calls loaders directly, so callers need no special case.
+func Setup() error {
+	if !config.GetConfig().Redis.Enabled {
+		logger.Infof("Redis is disabled, cache.Get loads without caching")
+		return nil
+	}
+	SetDefault(New(NewRedisStore(redis.GetUniversalClient()), Options{}))
+	return nil
+}
+
+// Default returns the Cache used by Get, or nil when none is configured.
+func Default() *Cache {
+	return defaultCache.Load()
+}
+
+// SetDefault replaces the Cache used by Get; nil makes Get call loaders directly.
+func SetDefault(c *Cache) {
+	defaultCache.Store(c)
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned by Store.Get when the key is not stored.
+var ErrMiss = errors.New("cache: miss")
+
+// Store holds encoded entries. Implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the value of key, or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// RedisStore is a Store on a Redis client (standalone or cluster).
+type RedisStore struct {
+	client redis.Cmdable
+}
+
+// NewRedisStore returns a RedisStore using client, e.g. redis.GetUniversalClient() from package
+// github.com/turahe/pkg/redis.
+func NewRedisStore(client redis.Cmdable) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return b, err
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete implements Store. In cluster mode keys are deleted one by one, since they may be in different slots.
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, ok := s.client.(*redis.ClusterClient); ok {
+		for _, k := range keys {
+			if err := s.client.Del(ctx, k).Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+// MemoryStore is an in-process Store for tests and single-instance tools. Expired entries are removed when
+// read.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	if !e.expires.IsZero() && !s.now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+// Set implements Store. A ttl of 0 stores without expiry.
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	e := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = s.now().Add(ttl)
+	}
+	s.mu.Lock()
+	s.entries[key] = e
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	for _, k := range keys {
+		delete(s.entries, k)
+	}
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/turahe/pkg/redis"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get missing: err = %v, want ErrMiss", err)
+	}
+	s.Set(ctx, "a", []byte("1"), time.Second)
+	s.Set(ctx, "b", []byte("2"), 0)
+	if b, err := s.Get(ctx, "a"); err != nil || string(b) != "1" {
+		t.Fatalf("Get a = %q, %v", b, err)
+	}
+	now = now.Add(time.Second)
+	if _, err := s.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get expired: err = %v, want ErrMiss", err)
+	}
+	if _, err := s.Get(ctx, "b"); err != nil {
+		t.Errorf("Get without ttl: %v", err)
+	}
+	s.Delete(ctx, "b", "missing")
+	if _, err := s.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get deleted: err = %v, want ErrMiss", err)
+	}
+}
+
+func TestRedisStore_Integration(t *testing.T) {
+	host, port := os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if port == "" {
+		port = "6379"
+	}
+	if !redis.Available(host, port, 500*time.Millisecond) {
+		t.Skip("Redis not available")
+	}
+	client := goredis.NewClient(&goredis.Options{Addr: host + ":" + port})
+	t.Cleanup(func() { client.Close() })
+	s := NewRedisStore(client)
+	ctx := context.Background()
+	key := "pkg:integration:cache:" + t.Name()
+	t.Cleanup(func() { client.Del(ctx, key) })
+
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get missing: err = %v, want ErrMiss", err)
+	}
+	if err := s.Set(ctx, key, []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if b, err := s.Get(ctx, key); err != nil || string(b) != "v" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+
+	c := New(s, Options{})
+	var calls int
+	loader := func(context.Context) (user, error) {
+		calls++
+		return user{ID: 1, Name: "Ada"}, nil
+	}
+	s.Delete(ctx, key)
+	for range 2 {
+		if u, err := GetWith(ctx, c, key, time.Minute, loader); err != nil || u.Name != "Ada" {
+			t.Fatalf("GetWith = %+v, %v", u, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after Delete: err = %v, want ErrMiss", err)
+	}
+}
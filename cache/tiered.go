@@ -0,0 +1,336 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/redis"
+)
+
+const (
+	defaultTieredName      = "default"
+	defaultLocalMaxEntries = 10_000
+	defaultLocalMaxBytes   = 64 << 20
+	defaultLocalTTL        = time.Minute
+	defaultChannel         = "cache:invalidate"
+
+	resubscribeMin = time.Second
+	resubscribeMax = 30 * time.Second
+)
+
+// Broker carries invalidation messages between instances.
+type Broker interface {
+	// Publish sends message on channel.
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe calls handler for each message on channel until ctx is cancelled or the subscription fails.
+	Subscribe(ctx context.Context, channel string, handler func(message string)) error
+}
+
+// RedisBroker is a Broker on the client of package redis (redis.PublishMessage and redis.SubscribeToChannel).
+var RedisBroker Broker = redisBroker{}
+
+type redisBroker struct{}
+
+func (redisBroker) Publish(ctx context.Context, channel, message string) error {
+	return redis.PublishMessage(ctx, channel, message)
+}
+
+func (redisBroker) Subscribe(ctx context.Context, channel string, handler func(message string)) error {
+	return redis.SubscribeToChannel(ctx, channel, handler)
+}
+
+// TieredOptions configures a TieredStore. Zero values take package defaults.
+type TieredOptions struct {
+	// Name labels the metrics of this store; default "default".
+	Name string
+	// MaxEntries bounds the number of local entries; default 10000.
+	MaxEntries int
+	// MaxBytes bounds the size of local keys and values; default 64 MiB. Larger values are not kept locally.
+	MaxBytes int64
+	// LocalTTL is the longest an entry is kept locally; default 1 minute. It bounds staleness when an
+	// invalidation message is lost.
+	LocalTTL time.Duration
+	// Broker broadcasts invalidations to other instances, e.g. RedisBroker; nil keeps them local.
+	Broker Broker
+	// Channel is the pub/sub channel for invalidations; default "cache:invalidate". Use one channel per
+	// remote keyspace.
+	Channel string
+}
+
+func (o *TieredOptions) applyDefaults() {
+	if o.Name == "" {
+		o.Name = defaultTieredName
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = defaultLocalMaxEntries
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultLocalMaxBytes
+	}
+	if o.LocalTTL <= 0 {
+		o.LocalTTL = defaultLocalTTL
+	}
+	if o.Channel == "" {
+		o.Channel = defaultChannel
+	}
+}
+
+// invalidation is the pub/sub message: the sending instance and the keys to drop.
+type invalidation struct {
+	Origin string   `json:"o"`
+	Keys   []string `json:"k"`
+}
+
+// TieredStore is a Store with an in-process LRU tier in front of a remote Store (usually RedisStore). Set
+// and Delete write through to the remote tier and broadcast the keys, so every instance running Listen drops
+// its local copy.
+type TieredStore struct {
+	remote Store
+	opts   TieredOptions
+	id     string
+	now    func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List // of *localEntry, most recently used first
+	entries map[string]*list.Element
+	bytes   int64
+	// epoch counts invalidations, so a remote read that raced with one is not cached locally.
+	epoch uint64
+
+	hits, misses, remoteHits, remoteMisses prometheus.Counter
+	entriesGauge, bytesGauge               prometheus.Gauge
+	evictions, sent, received              prometheus.Counter
+}
+
+type localEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (e *localEntry) size() int64 { return int64(len(e.key) + len(e.value)) }
+
+// NewTiered returns a TieredStore in front of remote. Call Listen to receive other instances'
+// invalidations.
+func NewTiered(remote Store, opts TieredOptions) *TieredStore {
+	opts.applyDefaults()
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &TieredStore{
+		remote:       remote,
+		opts:         opts,
+		id:           hex.EncodeToString(id),
+		now:          time.Now,
+		lru:          list.New(),
+		entries:      make(map[string]*list.Element),
+		hits:         requestsTotal.WithLabelValues(opts.Name, "local", "hit"),
+		misses:       requestsTotal.WithLabelValues(opts.Name, "local", "miss"),
+		remoteHits:   requestsTotal.WithLabelValues(opts.Name, "remote", "hit"),
+		remoteMisses: requestsTotal.WithLabelValues(opts.Name, "remote", "miss"),
+		entriesGauge: localEntries.WithLabelValues(opts.Name),
+		bytesGauge:   localBytes.WithLabelValues(opts.Name),
+		evictions:    localEvictionsTotal.WithLabelValues(opts.Name),
+		sent:         invalidationsTotal.WithLabelValues(opts.Name, "sent"),
+		received:     invalidationsTotal.WithLabelValues(opts.Name, "received"),
+	}
+}
+
+// Get implements Store: the local tier first, then the remote one, keeping remote hits locally.
+func (s *TieredStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	if v, ok := s.getLocked(key); ok {
+		s.mu.Unlock()
+		s.hits.Inc()
+		return v, nil
+	}
+	epoch := s.epoch
+	s.mu.Unlock()
+	s.misses.Inc()
+
+	v, err := s.remote.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrMiss) {
+			s.remoteMisses.Inc()
+		}
+		return nil, err
+	}
+	s.remoteHits.Inc()
+	s.mu.Lock()
+	if s.epoch == epoch {
+		s.setLocked(key, v, s.opts.LocalTTL)
+	}
+	s.mu.Unlock()
+	return v, nil
+}
+
+// Set implements Store: it writes to the remote tier, keeps value locally, and tells other instances to drop
+// key.
+func (s *TieredStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.remote.Set(ctx, key, value, ttl); err != nil {
+		s.Invalidate(key)
+		return err
+	}
+	localTTL := s.opts.LocalTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	s.mu.Lock()
+	s.epoch++
+	s.setLocked(key, value, localTTL)
+	s.mu.Unlock()
+	s.publish(ctx, []string{key})
+	return nil
+}
+
+// Delete implements Store: it deletes keys from both tiers and tells other instances to drop them.
+func (s *TieredStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	s.Invalidate(keys...)
+	err := s.remote.Delete(ctx, keys...)
+	// A Get that read the old remote value while it was being deleted must not keep it locally.
+	s.Invalidate(keys...)
+	s.publish(ctx, keys)
+	return err
+}
+
+// Invalidate drops keys from the local tier of this instance only.
+func (s *TieredStore) Invalidate(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch++
+	for _, k := range keys {
+		if el, ok := s.entries[k]; ok {
+			s.removeLocked(el)
+		}
+	}
+	s.updateGaugesLocked()
+}
+
+// Purge empties the local tier of this instance.
+func (s *TieredStore) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch++
+	s.lru.Init()
+	clear(s.entries)
+	s.bytes = 0
+	s.updateGaugesLocked()
+}
+
+// Len returns the number of local entries, including expired ones not yet evicted.
+func (s *TieredStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// Listen applies invalidations from other instances until ctx is cancelled, then returns nil. A failed
+// subscription is retried with backoff, and the local tier is purged since messages may have been missed.
+// No-op without a Broker. Run it in its own goroutine.
+func (s *TieredStore) Listen(ctx context.Context) error {
+	if s.opts.Broker == nil {
+		return nil
+	}
+	backoff := resubscribeMin
+	for {
+		start := time.Now()
+		err := s.opts.Broker.Subscribe(ctx, s.opts.Channel, s.handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.Purge()
+		if time.Since(start) > resubscribeMax {
+			backoff = resubscribeMin
+		}
+		logger.WarnfContext(ctx, "cache: invalidation subscription on %s failed, retrying in %s: %v", s.opts.Channel, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, resubscribeMax)
+	}
+}
+
+// handle applies one invalidation message, ignoring this instance's own.
+func (s *TieredStore) handle(message string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(message), &msg); err != nil {
+		logger.Warnf("cache: ignoring malformed invalidation on %s: %v", s.opts.Channel, err)
+		return
+	}
+	if msg.Origin == s.id {
+		return
+	}
+	s.received.Inc()
+	s.Invalidate(msg.Keys...)
+}
+
+// publish broadcasts keys; failures are logged, since LocalTTL bounds the resulting staleness.
+func (s *TieredStore) publish(ctx context.Context, keys []string) {
+	if s.opts.Broker == nil {
+		return
+	}
+	b, _ := json.Marshal(invalidation{Origin: s.id, Keys: keys})
+	if err := s.opts.Broker.Publish(ctx, s.opts.Channel, string(b)); err != nil {
+		logger.WarnfContext(ctx, "cache: publish invalidation on %s failed: %v", s.opts.Channel, err)
+		return
+	}
+	s.sent.Inc()
+}
+
+func (s *TieredStore) getLocked(key string) ([]byte, bool) {
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*localEntry)
+	if !s.now().Before(e.expires) {
+		s.removeLocked(el)
+		s.updateGaugesLocked()
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	return e.value, true
+}
+
+// setLocked stores a copy of value for ttl and evicts least recently used entries beyond the budget.
+func (s *TieredStore) setLocked(key string, value []byte, ttl time.Duration) {
+	if el, ok := s.entries[key]; ok {
+		s.removeLocked(el)
+	}
+	e := &localEntry{key: key, value: append([]byte(nil), value...), expires: s.now().Add(ttl)}
+	if e.size() > s.opts.MaxBytes {
+		s.updateGaugesLocked()
+		return
+	}
+	s.entries[key] = s.lru.PushFront(e)
+	s.bytes += e.size()
+	for s.lru.Len() > s.opts.MaxEntries || s.bytes > s.opts.MaxBytes {
+		s.removeLocked(s.lru.Back())
+		s.evictions.Inc()
+	}
+	s.updateGaugesLocked()
+}
+
+func (s *TieredStore) removeLocked(el *list.Element) {
+	e := s.lru.Remove(el).(*localEntry)
+	delete(s.entries, e.key)
+	s.bytes -= e.size()
+}
+
+func (s *TieredStore) updateGaugesLocked() {
+	s.entriesGauge.Set(float64(s.lru.Len()))
+	s.bytesGauge.Set(float64(s.bytes))
+}
@@ -0,0 +1,305 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// hub is an in-memory Broker delivering each message to every subscriber synchronously.
+type hub struct {
+	mu   sync.Mutex
+	subs map[int]func(string)
+	next int
+	fail error // returned by Subscribe immediately when set
+}
+
+func newHub() *hub { return &hub{subs: make(map[int]func(string))} }
+
+func (h *hub) Publish(_ context.Context, _, message string) error {
+	h.mu.Lock()
+	subs := make([]func(string), 0, len(h.subs))
+	for _, f := range h.subs {
+		subs = append(subs, f)
+	}
+	h.mu.Unlock()
+	for _, f := range subs {
+		f(message)
+	}
+	return nil
+}
+
+func (h *hub) Subscribe(ctx context.Context, _ string, handler func(string)) error {
+	h.mu.Lock()
+	if h.fail != nil {
+		h.mu.Unlock()
+		return h.fail
+	}
+	id := h.next
+	h.next++
+	h.subs[id] = handler
+	h.mu.Unlock()
+	<-ctx.Done()
+	h.mu.Lock()
+	delete(h.subs, id)
+	h.mu.Unlock()
+	return ctx.Err()
+}
+
+func (h *hub) subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// listen starts s.Listen and waits until it has subscribed.
+func listen(t *testing.T, h *hub, stores ...*TieredStore) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	want := h.subscribers() + len(stores)
+	for _, s := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Listen(ctx)
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for h.subscribers() < want {
+		if time.Now().After(deadline) {
+			t.Fatal("stores did not subscribe")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTieredStore_LocalTier(t *testing.T) {
+	remote := NewMemoryStore()
+	s := NewTiered(remote, TieredOptions{Name: t.Name()})
+	ctx := context.Background()
+	count := func(tier, result string) float64 {
+		return testutil.ToFloat64(requestsTotal.WithLabelValues(t.Name(), tier, result))
+	}
+	before := map[[2]string]float64{}
+	for _, k := range [][2]string{{"local", "hit"}, {"local", "miss"}, {"remote", "hit"}, {"remote", "miss"}} {
+		before[k] = count(k[0], k[1])
+	}
+
+	remote.Set(ctx, "k", []byte("v1"), 0)
+	if b, err := s.Get(ctx, "k"); err != nil || string(b) != "v1" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+	// Served locally now, even though the remote tier changed behind its back.
+	remote.Set(ctx, "k", []byte("v2"), 0)
+	if b, _ := s.Get(ctx, "k"); string(b) != "v1" {
+		t.Errorf("Get = %q, want local v1", b)
+	}
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get missing: err = %v, want ErrMiss", err)
+	}
+
+	for tier, want := range map[[2]string]float64{
+		{"local", "hit"}: 1, {"local", "miss"}: 2, {"remote", "hit"}: 1, {"remote", "miss"}: 1,
+	} {
+		if got := count(tier[0], tier[1]) - before[tier]; got != want {
+			t.Errorf("cache_requests_total{tier=%s,result=%s} = %v, want %v", tier[0], tier[1], got, want)
+		}
+	}
+}
+
+// deleteHookStore runs beforeDelete before deleting from a MemoryStore.
+type deleteHookStore struct {
+	*MemoryStore
+	beforeDelete func()
+}
+
+func (s *deleteHookStore) Delete(ctx context.Context, keys ...string) error {
+	s.beforeDelete()
+	return s.MemoryStore.Delete(ctx, keys...)
+}
+
+func TestTieredStore_DeleteRacingGet(t *testing.T) {
+	remote := &deleteHookStore{MemoryStore: NewMemoryStore()}
+	s := NewTiered(remote, TieredOptions{Name: t.Name()})
+	ctx := context.Background()
+	remote.Set(ctx, "k", []byte("old"), 0)
+	remote.beforeDelete = func() {
+		// A concurrent Get between the local invalidation and the remote delete.
+		if b, err := s.Get(ctx, "k"); err != nil || string(b) != "old" {
+			t.Errorf("Get during Delete = %q, %v", b, err)
+		}
+	}
+	if err := s.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if b, err := s.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after Delete = %q, %v; want ErrMiss", b, err)
+	}
+}
+
+func TestTieredStore_LocalTTL(t *testing.T) {
+	remote := NewMemoryStore()
+	s := NewTiered(remote, TieredOptions{LocalTTL: time.Minute})
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	remote.now = s.now
+	ctx := context.Background()
+
+	s.Set(ctx, "short", []byte("a"), time.Second)
+	s.Set(ctx, "long", []byte("b"), time.Hour)
+	now = now.Add(time.Second)
+	remote.Set(ctx, "long", []byte("b2"), 0)
+	if _, err := s.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("short: err = %v, want ErrMiss (local copy bounded by ttl)", err)
+	}
+	if b, _ := s.Get(ctx, "long"); string(b) != "b" {
+		t.Errorf("long = %q, want local b", b)
+	}
+	now = now.Add(time.Minute)
+	if b, _ := s.Get(ctx, "long"); string(b) != "b2" {
+		t.Errorf("long after LocalTTL = %q, want remote b2", b)
+	}
+}
+
+func TestTieredStore_Budget(t *testing.T) {
+	name := t.Name()
+	s := NewTiered(NewMemoryStore(), TieredOptions{Name: name, MaxEntries: 2, MaxBytes: 100})
+	evictions := testutil.ToFloat64(localEvictionsTotal.WithLabelValues(name))
+	ctx := context.Background()
+
+	s.Set(ctx, "a", []byte("1"), 0)
+	s.Set(ctx, "b", []byte("2"), 0)
+	s.Get(ctx, "a") // a is now most recently used
+	s.Set(ctx, "c", []byte("3"), 0)
+	if _, ok := s.entries["b"]; ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len = %d, want 2", s.Len())
+	}
+
+	s.Set(ctx, "big", make([]byte, 200), 0)
+	if _, ok := s.entries["big"]; ok {
+		t.Error("value larger than MaxBytes was kept locally")
+	}
+	if b, err := s.Get(ctx, "big"); err != nil || len(b) != 200 {
+		t.Errorf("Get big from remote = %d bytes, %v", len(b), err)
+	}
+
+	s.Set(ctx, "d", make([]byte, 90), 0)
+	if s.bytes > 100 {
+		t.Errorf("local bytes = %d, over MaxBytes", s.bytes)
+	}
+	if got := testutil.ToFloat64(localBytes.WithLabelValues(name)); got != float64(s.bytes) {
+		t.Errorf("cache_local_bytes = %v, want %d", got, s.bytes)
+	}
+	if got := testutil.ToFloat64(localEvictionsTotal.WithLabelValues(name)) - evictions; got < 2 {
+		t.Errorf("cache_local_evictions_total = %v, want >= 2", got)
+	}
+}
+
+func TestTieredStore_BroadcastInvalidation(t *testing.T) {
+	h := newHub()
+	remote := NewMemoryStore()
+	a := NewTiered(remote, TieredOptions{Broker: h})
+	b := NewTiered(remote, TieredOptions{Broker: h})
+	listen(t, h, a, b)
+	ctx := context.Background()
+
+	a.Set(ctx, "k", []byte("v1"), 0)
+	if v, _ := b.Get(ctx, "k"); string(v) != "v1" {
+		t.Fatalf("b Get = %q, want v1", v)
+	}
+	a.Set(ctx, "k", []byte("v2"), 0)
+	if v, _ := b.Get(ctx, "k"); string(v) != "v2" {
+		t.Errorf("b Get after a Set = %q, want v2", v)
+	}
+	if v, _ := a.Get(ctx, "k"); string(v) != "v2" {
+		t.Errorf("a Get = %q, want its own v2", v)
+	}
+
+	a.Delete(ctx, "k")
+	if _, err := b.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Errorf("b Get after a Delete: err = %v, want ErrMiss", err)
+	}
+}
+
+func TestTieredStore_HandleMalformed(t *testing.T) {
+	s := NewTiered(NewMemoryStore(), TieredOptions{})
+	s.setLocked("k", []byte("v"), time.Minute)
+	s.handle("not json")
+	s.handle(`{"o":"` + s.id + `","k":["k"]}`)
+	if s.Len() != 1 {
+		t.Errorf("Len = %d, want 1 (malformed and own messages ignored)", s.Len())
+	}
+	s.handle(`{"o":"other","k":["k"]}`)
+	if s.Len() != 0 {
+		t.Errorf("Len = %d, want 0", s.Len())
+	}
+}
+
+func TestTieredStore_ListenFailurePurges(t *testing.T) {
+	h := newHub()
+	h.fail = errors.New("connection reset")
+	s := NewTiered(NewMemoryStore(), TieredOptions{Broker: h})
+	s.Set(context.Background(), "k", []byte("v"), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Listen(ctx) }()
+	deadline := time.Now().Add(time.Second)
+	for s.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("local tier not purged after subscription failure")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Listen = %v, want nil after cancel", err)
+	}
+}
+
+func TestTieredStore_WithCache(t *testing.T) {
+	h := newHub()
+	remote := NewMemoryStore()
+	sa := NewTiered(remote, TieredOptions{Broker: h})
+	sb := NewTiered(remote, TieredOptions{Broker: h})
+	listen(t, h, sa, sb)
+	a, b := New(sa, Options{}), New(sb, Options{})
+	ctx := context.Background()
+
+	var calls int
+	loader := func(context.Context) (string, error) {
+		calls++
+		return "loaded", nil
+	}
+	GetWith(ctx, a, "setting", time.Minute, loader)
+	if v, _ := GetWith(ctx, b, "setting", time.Minute, loader); v != "loaded" || calls != 1 {
+		t.Fatalf("b GetWith = %q after %d loads, want the value a cached", v, calls)
+	}
+	a.Set(ctx, "setting", "updated", time.Minute)
+	if v, _ := GetWith(ctx, b, "setting", time.Minute, loader); v != "updated" {
+		t.Errorf("b GetWith after a Set = %q, want updated", v)
+	}
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+	if err := RegisterMetrics(reg); err != nil {
+		t.Errorf("second RegisterMetrics: %v", err)
+	}
+}
@@ -83,6 +83,23 @@ func buildConfigFromEnv() *Configuration {
 			BucketName:      getEnvOrDefault("GCS_BUCKET_NAME", ""),
 			CredentialsFile: getEnvOrDefault("GCS_CREDENTIALS_FILE", ""),
 		},
+		S3: S3Configuration{
+			Enabled:         parseBool("S3_ENABLED", false),
+			Endpoint:        getEnvOrDefault("S3_ENDPOINT", "s3.amazonaws.com"),
+			Region:          getEnvOrDefault("S3_REGION", "us-east-1"),
+			BucketName:      getEnvOrDefault("S3_BUCKET_NAME", ""),
+			AccessKeyID:     getEnvOrDefault("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnvOrDefault("S3_SECRET_ACCESS_KEY", ""),
+			SessionToken:    getEnvOrDefault("S3_SESSION_TOKEN", ""),
+			UseSSL:          parseBool("S3_USE_SSL", true),
+			ForcePathStyle:  parseBool("S3_FORCE_PATH_STYLE", false),
+		},
+		Storage: StorageConfiguration{
+			Driver:        getEnvOrDefault("STORAGE_DRIVER", "gcs"),
+			LocalPath:     getEnvOrDefault("STORAGE_LOCAL_PATH", "./storage"),
+			BaseURL:       getEnvOrDefault("STORAGE_BASE_URL", ""),
+			SigningSecret: getEnvOrDefault("STORAGE_SIGNING_SECRET", ""),
+		},
 		RateLimiter: RateLimiterConfiguration{
 			Enabled:   parseBool("RATE_LIMITER_ENABLED", false),
 			Requests:  parseInt("RATE_LIMITER_REQUESTS", 100),
@@ -116,7 +116,8 @@ func TestBuildConfigFromEnv_Defaults(t *testing.T) {
 	keys := []string{
 		"SERVER_PORT", "SERVER_MODE", "DATABASE_DRIVER", "DATABASE_HOST", "DATABASE_PORT",
 		"REDIS_HOST", "REDIS_PORT", "REDIS_ENABLED", "REDIS_DB",
-		"CORS_GLOBAL", "GCS_ENABLED",
+		"CORS_GLOBAL", "GCS_ENABLED", "STORAGE_DRIVER", "STORAGE_LOCAL_PATH",
+		"S3_ENABLED", "S3_ENDPOINT", "S3_REGION", "S3_USE_SSL",
 	}
 	for _, k := range keys {
 		os.Unsetenv(k)
@@ -152,6 +153,15 @@ func TestBuildConfigFromEnv_Defaults(t *testing.T) {
 	if cfg.Redis.DB != 1 {
 		t.Errorf("Redis.DB = %v, want 1", cfg.Redis.DB)
 	}
+	if cfg.S3.Enabled || cfg.S3.Endpoint != "s3.amazonaws.com" || cfg.S3.Region != "us-east-1" || !cfg.S3.UseSSL {
+		t.Errorf("S3 defaults = %+v", cfg.S3)
+	}
+	if cfg.Storage.Driver != "gcs" {
+		t.Errorf("Storage.Driver = %q, want gcs", cfg.Storage.Driver)
+	}
+	if cfg.Storage.LocalPath != "./storage" {
+		t.Errorf("Storage.LocalPath = %q, want ./storage", cfg.Storage.LocalPath)
+	}
 }
 
 func TestBuildConfigFromEnv_RespectsEnv(t *testing.T) {
@@ -291,6 +301,19 @@ func TestBuildConfigFromEnv_AllSections(t *testing.T) {
 		"GCS_ENABLED":                  "true",
 		"GCS_BUCKET_NAME":              "test-bucket",
 		"GCS_CREDENTIALS_FILE":          "/path/to/creds.json",
+		"S3_ENABLED":                   "true",
+		"S3_ENDPOINT":                  "minio:9000",
+		"S3_REGION":                    "eu-west-1",
+		"S3_BUCKET_NAME":               "s3-bucket",
+		"S3_ACCESS_KEY_ID":             "AKID",
+		"S3_SECRET_ACCESS_KEY":         "s3-secret",
+		"S3_SESSION_TOKEN":             "s3-token",
+		"S3_USE_SSL":                   "false",
+		"S3_FORCE_PATH_STYLE":          "true",
+		"STORAGE_DRIVER":               "local",
+		"STORAGE_LOCAL_PATH":           "/var/data/files",
+		"STORAGE_BASE_URL":             "http://localhost:3000/files",
+		"STORAGE_SIGNING_SECRET":       "sign-secret",
 		"RATE_LIMITER_ENABLED":         "true",
 		"RATE_LIMITER_REQUESTS":        "200",
 		"RATE_LIMITER_WINDOW":          "120",
@@ -422,6 +445,43 @@ func TestBuildConfigFromEnv_AllSections(t *testing.T) {
 		t.Errorf("GCS.CredentialsFile = %q, want /path/to/creds.json", cfg.GCS.CredentialsFile)
 	}
 
+	// Test S3 configuration
+	if cfg.S3.Enabled != true {
+		t.Errorf("S3.Enabled = %v, want true", cfg.S3.Enabled)
+	}
+	if cfg.S3.Endpoint != "minio:9000" {
+		t.Errorf("S3.Endpoint = %q, want minio:9000", cfg.S3.Endpoint)
+	}
+	if cfg.S3.Region != "eu-west-1" {
+		t.Errorf("S3.Region = %q, want eu-west-1", cfg.S3.Region)
+	}
+	if cfg.S3.BucketName != "s3-bucket" {
+		t.Errorf("S3.BucketName = %q, want s3-bucket", cfg.S3.BucketName)
+	}
+	if cfg.S3.AccessKeyID != "AKID" || cfg.S3.SecretAccessKey != "s3-secret" || cfg.S3.SessionToken != "s3-token" {
+		t.Errorf("S3 credentials = %q/%q/%q", cfg.S3.AccessKeyID, cfg.S3.SecretAccessKey, cfg.S3.SessionToken)
+	}
+	if cfg.S3.UseSSL != false {
+		t.Errorf("S3.UseSSL = %v, want false", cfg.S3.UseSSL)
+	}
+	if cfg.S3.ForcePathStyle != true {
+		t.Errorf("S3.ForcePathStyle = %v, want true", cfg.S3.ForcePathStyle)
+	}
+
+	// Test Storage configuration
+	if cfg.Storage.Driver != "local" {
+		t.Errorf("Storage.Driver = %q, want local", cfg.Storage.Driver)
+	}
+	if cfg.Storage.LocalPath != "/var/data/files" {
+		t.Errorf("Storage.LocalPath = %q, want /var/data/files", cfg.Storage.LocalPath)
+	}
+	if cfg.Storage.BaseURL != "http://localhost:3000/files" {
+		t.Errorf("Storage.BaseURL = %q, want http://localhost:3000/files", cfg.Storage.BaseURL)
+	}
+	if cfg.Storage.SigningSecret != "sign-secret" {
+		t.Errorf("Storage.SigningSecret = %q, want sign-secret", cfg.Storage.SigningSecret)
+	}
+
 	// Test RateLimiter configuration
 	if cfg.RateLimiter.Enabled != true {
 		t.Errorf("RateLimiter.Enabled = %v, want true", cfg.RateLimiter.Enabled)
@@ -9,6 +9,8 @@ type Configuration struct {
 	DatabaseSite DatabaseConfiguration // Optional second database; leave Dbname empty to disable.
 	Redis        RedisConfiguration
 	GCS          GCSConfiguration
+	S3           S3Configuration
+	Storage      StorageConfiguration
 	RateLimiter  RateLimiterConfiguration
 	Timezone     TimezoneConfiguration
 }
@@ -87,6 +89,27 @@ type GCSConfiguration struct {
 	CredentialsFile string // Optional; omit to use Application Default Credentials
 }
 
+// S3Configuration holds S3-compatible object storage settings (AWS S3, MinIO). BucketName required when Enabled is true.
+type S3Configuration struct {
+	Enabled         bool
+	Endpoint        string // host[:port] without scheme; default "s3.amazonaws.com"
+	Region          string // default "us-east-1"
+	BucketName      string
+	AccessKeyID     string // Optional; omit with SecretAccessKey to use AWS_* env vars, the shared credentials file, or the instance role
+	SecretAccessKey string
+	SessionToken    string // Optional; for temporary credentials
+	UseSSL          bool   // default true
+	ForcePathStyle  bool   // Address the bucket in the path instead of the host name (typical for MinIO)
+}
+
+// StorageConfiguration selects the object storage backend used by the storage package.
+type StorageConfiguration struct {
+	Driver        string // "gcs" (default; uses the GCS section), "s3" (uses the S3 section), "local", or "memory"
+	LocalPath     string // Root directory for the local driver; default "./storage"
+	BaseURL       string // Base URL the local driver's signed URLs point at, e.g. "http://localhost:8080/files"
+	SigningSecret string // HMAC key for the local driver's signed URLs; required to sign with the local driver
+}
+
 // RateLimiterConfiguration holds rate limiter settings. Requires Redis when Enabled is true.
 type RateLimiterConfiguration struct {
 	Enabled   bool
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/turahe/pkg/config"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -72,6 +73,20 @@ func GetDBSite() *gorm.DB {
 	return GetDB()
 }
 
+// GetDatabase returns the global primary *Database set by Setup or CreateDatabaseConnection, or nil if not initialized.
+func GetDatabase() *Database {
+	compatMu.RLock()
+	defer compatMu.RUnlock()
+	return defaultDB
+}
+
+// GetDatabaseSite returns the global site *Database, or nil if Setup did not configure one (no fallback to primary).
+func GetDatabaseSite() *Database {
+	compatMu.RLock()
+	defer compatMu.RUnlock()
+	return defaultDBSite
+}
+
 // HealthCheck pings both primary and site databases (if present). Returns ErrNotInitialized if Setup was not called.
 func HealthCheck(ctx context.Context) error {
 	compatMu.RLock()
@@ -115,6 +130,25 @@ func Cleanup() error {
 	return nil
 }
 
+// RegisterMetrics calls Database.RegisterMetrics for the global databases: "primary" and, if configured, "site".
+// Call after Setup. Returns ErrNotInitialized if Setup was not called.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	compatMu.RLock()
+	db := defaultDB
+	dbSite := defaultDBSite
+	compatMu.RUnlock()
+	if db == nil {
+		return ErrNotInitialized
+	}
+	if err := db.RegisterMetrics("primary", reg); err != nil {
+		return err
+	}
+	if dbSite != nil {
+		return dbSite.RegisterMetrics("site", reg)
+	}
+	return nil
+}
+
 // CreateDatabaseConnection creates a Database from cfg, sets it as the global defaultDB, and returns its *gorm.DB. For legacy callers that need a raw *gorm.DB.
 func CreateDatabaseConnection(cfg *config.DatabaseConfiguration) (*gorm.DB, error) {
 	opts := Options{}
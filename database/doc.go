@@ -8,7 +8,8 @@ Responsibilities:
   - Build DSN and open connection (standard drivers or Cloud SQL connector).
   - Apply connection pool limits (MaxOpenConns, MaxIdleConns, ConnMaxLifetime, ConnMaxIdle).
   - Expose Health(ctx) with PingTimeout-bounded ping and Close() for cleanup (including Cloud SQL connector).
-  - Optional GORM logger with SQL redaction (passwords, tokens, card numbers) and slow query logging.
+  - Optional GORM logger with SQL redaction (passwords, tokens, card numbers) and slow query logging; entries use logger name "db", so logger.SetLevel("db", slog.LevelDebug, ttl) logs every query.
+  - RegisterMetrics: Prometheus pool stats (go_sql_*), query duration by operation/table, and slow query count.
   - Legacy compat: Setup/GetDB/GetDBSite/HealthCheck/Cleanup/IsAlive for global singleton usage.
 
 Constraints:
@@ -13,6 +13,7 @@ type Database struct {
 	db       *gorm.DB
 	opts     *Options
 	cleanups []func() error
+	metrics  *dbMetrics
 }
 
 // DB returns the underlying *gorm.DB for queries. Safe to call after New; do not close it directly.
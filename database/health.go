@@ -23,8 +23,10 @@ func (d *Database) Health(ctx context.Context) error {
 	return nil
 }
 
-// Close runs all registered cleanup functions (e.g. Cloud SQL connector close) and clears the list. Returns the first error if any cleanup fails.
+// Close runs all registered cleanup functions (e.g. Cloud SQL connector close) and clears the list, and unregisters pool
+// metrics added by RegisterMetrics. Returns the first error if any cleanup fails.
 func (d *Database) Close() error {
+	d.unregisterMetrics()
 	var errs []error
 	for _, fn := range d.cleanups {
 		if err := fn(); err != nil {
@@ -67,19 +67,19 @@ func (l *fintechLogger) LogMode(level logger.LogLevel) logger.Interface {
 
 func (l *fintechLogger) Info(ctx context.Context, msg string, args ...interface{}) {
 	if l.level >= logger.Info {
-		pkglogger.InfofContext(ctx, "[DB] "+msg, args...)
+		pkglogger.InfofContext(dbContext(ctx), "[DB] "+msg, args...)
 	}
 }
 
 func (l *fintechLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
 	if l.level >= logger.Warn {
-		pkglogger.WarnfContext(ctx, "[DB] "+msg, args...)
+		pkglogger.WarnfContext(dbContext(ctx), "[DB] "+msg, args...)
 	}
 }
 
 func (l *fintechLogger) Error(ctx context.Context, msg string, args ...interface{}) {
 	if l.level >= logger.Error {
-		pkglogger.ErrorfContext(ctx, "[DB] "+msg, args...)
+		pkglogger.ErrorfContext(dbContext(ctx), "[DB] "+msg, args...)
 	}
 }
 
@@ -87,6 +87,7 @@ func (l *fintechLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 	if l.level <= logger.Silent {
 		return
 	}
+	ctx = dbContext(ctx)
 	elapsed := time.Since(begin)
 	sql, rows := fc()
 	redactedSQL := redactSQL(sql)
@@ -110,5 +111,22 @@ func (l *fintechLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 	}
 	if l.level >= logger.Info {
 		pkglogger.GetLogger().LogAttrs(ctx, slog.LevelInfo, "[DB] query executed", attrs...)
+		return
+	}
+	// Below GORM's Info level, queries are still logged at Debug when the "db" logger is set to Debug
+	// (pkglogger.SetLevel) or the request carries a debug override.
+	if log := pkglogger.GetLogger(); log.Enabled(ctx, slog.LevelDebug) {
+		log.LogAttrs(ctx, slog.LevelDebug, "[DB] query executed", attrs...)
+	}
+}
+
+// dbLoggerName is the logger name for GORM entries; pkglogger.SetLevel(dbLoggerName, ...) controls them.
+const dbLoggerName = "db"
+
+// dbContext names ctx's logger dbLoggerName.
+func dbContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	return pkglogger.WithName(ctx, dbLoggerName)
 }
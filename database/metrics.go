@@ -0,0 +1,161 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const (
+	metricsPluginName = "turahe:metrics"
+	metricsStartKey   = "turahe:metrics:start"
+)
+
+// Query metrics are shared by every Database and distinguished by the db_name label.
+var (
+	queryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of GORM operations in seconds.",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"db_name", "operation", "table"},
+	)
+
+	slowQueriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_slow_queries_total",
+			Help: "Total number of GORM operations slower than the SlowThreshold option.",
+		},
+		[]string{"db_name", "operation", "table"},
+	)
+)
+
+// dbMetrics is the registration state kept on a Database so Close can unregister its stats collector.
+type dbMetrics struct {
+	reg   prometheus.Registerer
+	stats prometheus.Collector
+}
+
+// RegisterMetrics exposes Prometheus metrics for d under db_name=name on reg (prometheus.DefaultRegisterer
+// when nil):
+//   - go_sql_* connection pool stats from sql.DBStats (open, in use, idle, wait count, wait duration, ...)
+//   - db_query_duration_seconds (histogram, labels: db_name, operation, table) from GORM callbacks
+//   - db_slow_queries_total (counter, same labels) for operations slower than Options.SlowThreshold, the
+//     same threshold used for slow-query log warnings
+//
+// Call once per Database; calling it again with a different name returns an error. Registering another
+// Database under the same name replaces the earlier pool stats; Close unregisters them.
+func (d *Database) RegisterMetrics(name string, reg prometheus.Registerer) error {
+	if d.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	// The query callbacks are installed once per gorm.DB and keep the name they were registered with.
+	if p, ok := d.db.Config.Plugins[metricsPluginName].(*metricsPlugin); ok && p.name != name {
+		return fmt.Errorf("register metrics: already registered as %q", p.name)
+	}
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return fmt.Errorf("get sql.DB: %w", err)
+	}
+	for _, c := range []prometheus.Collector{queryDuration, slowQueriesTotal} {
+		if err := registerOnce(reg, c); err != nil {
+			return fmt.Errorf("register metrics: %w", err)
+		}
+	}
+	stats := collectors.NewDBStatsCollector(sqlDB, name)
+	if err := reg.Register(stats); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return fmt.Errorf("register metrics: %w", err)
+		}
+		reg.Unregister(are.ExistingCollector)
+		if err := reg.Register(stats); err != nil {
+			return fmt.Errorf("register metrics: %w", err)
+		}
+	}
+	if err := d.db.Use(&metricsPlugin{name: name, slowThreshold: d.opts.SlowThreshold}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+		reg.Unregister(stats)
+		return fmt.Errorf("register metrics: %w", err)
+	}
+	d.metrics = &dbMetrics{reg: reg, stats: stats}
+	return nil
+}
+
+// unregisterMetrics removes the pool stats collector registered by RegisterMetrics, if any.
+func (d *Database) unregisterMetrics() {
+	if d.metrics != nil {
+		d.metrics.reg.Unregister(d.metrics.stats)
+		d.metrics = nil
+	}
+}
+
+// registerOnce registers c, treating "already registered" (the same shared collector) as success.
+func registerOnce(reg prometheus.Registerer, c prometheus.Collector) error {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// metricsPlugin times each GORM operation with before/after callbacks.
+type metricsPlugin struct {
+	name          string
+	slowThreshold time.Duration
+}
+
+func (p *metricsPlugin) Name() string {
+	return metricsPluginName
+}
+
+// Initialize registers the callbacks for every GORM operation.
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register(metricsPluginName+":before_create", p.before),
+		cb.Create().After("gorm:create").Register(metricsPluginName+":after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register(metricsPluginName+":before_query", p.before),
+		cb.Query().After("gorm:query").Register(metricsPluginName+":after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register(metricsPluginName+":before_update", p.before),
+		cb.Update().After("gorm:update").Register(metricsPluginName+":after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register(metricsPluginName+":before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register(metricsPluginName+":after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register(metricsPluginName+":before_row", p.before),
+		cb.Row().After("gorm:row").Register(metricsPluginName+":after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register(metricsPluginName+":before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register(metricsPluginName+":after_raw", p.after("raw")),
+	)
+}
+
+func (p *metricsPlugin) before(tx *gorm.DB) {
+	tx.Statement.Settings.Store(metricsStartKey, time.Now())
+}
+
+func (p *metricsPlugin) after(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.Statement.Settings.LoadAndDelete(metricsStartKey)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(v.(time.Time))
+		table := tx.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		queryDuration.WithLabelValues(p.name, op, table).Observe(elapsed.Seconds())
+		if elapsed > p.slowThreshold {
+			slowQueriesTotal.WithLabelValues(p.name, op, table).Inc()
+		}
+	}
+}
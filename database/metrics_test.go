@@ -0,0 +1,71 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/turahe/pkg/config"
+)
+
+func TestDatabase_RegisterMetrics(t *testing.T) {
+	cfg := &config.DatabaseConfiguration{Driver: "sqlite", Dbname: "test_metrics"}
+	// 1ns threshold: every query counts as slow.
+	db, err := New(cfg, Options{SlowThreshold: time.Nanosecond})
+	if err != nil {
+		t.Skipf("New sqlite: %v", err)
+	}
+	defer db.Close()
+
+	reg := prometheus.NewRegistry()
+	if err := db.RegisterMetrics("metrics_test", reg); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+	if err := db.RegisterMetrics("metrics_test", reg); err != nil {
+		t.Fatalf("RegisterMetrics twice: %v", err)
+	}
+	if err := db.RegisterMetrics("renamed", reg); err == nil {
+		t.Error("RegisterMetrics under another name: want error")
+	}
+
+	if err := db.DB().Exec("CREATE TABLE IF NOT EXISTS metric_rows (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	var count int64
+	if err := db.DB().Table("metric_rows").Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+
+	if n := testutil.CollectAndCount(queryDuration, "db_query_duration_seconds"); n == 0 {
+		t.Error("db_query_duration_seconds has no series")
+	}
+	if v := testutil.ToFloat64(slowQueriesTotal.WithLabelValues("metrics_test", "query", "metric_rows")); v < 1 {
+		t.Errorf("db_slow_queries_total{operation=query} = %v, want >= 1", v)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	if !hasMetricFamily(families, "go_sql_open_connections") {
+		t.Error("go_sql_open_connections not registered")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	families, _ = reg.Gather()
+	if hasMetricFamily(families, "go_sql_open_connections") {
+		t.Error("pool stats must be unregistered on Close")
+	}
+}
+
+func hasMetricFamily(families []*dto.MetricFamily, name string) bool {
+	for _, f := range families {
+		if f.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -7,7 +7,7 @@ Role in architecture:
   - Implementations of ports live in infrastructure (e.g. repositories package).
 
 Responsibilities:
-  - Define sentinel errors (ErrNotFound, ErrUnauthorized) for use-case and handler error handling.
+  - Define sentinel errors (ErrNotFound, ErrUnauthorized, ErrExternalService) for use-case and handler error handling.
   - Define port interfaces (e.g. GetByID in subpackage port) that use cases call and infrastructure implements.
 
 This package must NOT:
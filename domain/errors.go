@@ -6,9 +6,12 @@ import "errors"
 //
 // ErrNotFound: return when an entity is not found (e.g. repo.GetByID returns notFound).
 // ErrUnauthorized: return when the operation requires authentication or the credentials are invalid.
+// ErrExternalService: return when a downstream dependency (partner API, bank, notification provider) is
+// unavailable, e.g. its circuit breaker is open.
 //
 // Wrap with fmt.Errorf("context: %w", ErrNotFound) when adding context; handlers should still use errors.Is(err, domain.ErrNotFound).
 var (
-	ErrNotFound     = errors.New("not found")
-	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound        = errors.New("not found")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrExternalService = errors.New("external service unavailable")
 )
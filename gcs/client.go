@@ -0,0 +1,273 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/turahe/pkg/config"
+)
+
+var (
+	// ErrNotFound is returned (wrapped) when an object does not exist. It is storage.ErrObjectNotExist.
+	ErrNotFound = storage.ErrObjectNotExist
+	// ErrPreconditionFailed is returned (wrapped) when a generation precondition does not hold, e.g. the
+	// object changed since it was read (HTTP 412).
+	ErrPreconditionFailed = errors.New("gcs: precondition failed")
+)
+
+// Client is a GCS client bound to one bucket. Every call takes the caller's context, so uploads and
+// downloads are cancelled or time-bounded with the request. Safe for concurrent use.
+type Client struct {
+	client *storage.Client
+	bucket string
+	opts   *Options
+	owned  bool
+}
+
+// New creates a Client for cfg.BucketName, authenticating with cfg.CredentialsFile or Application Default
+// Credentials. ctx is used only for client creation. Close releases the underlying storage client.
+func New(ctx context.Context, cfg *config.GCSConfiguration, opts Options, override ...Option) (*Client, error) {
+	o := resolveOptions(opts, override)
+	clientOpts := append([]option.ClientOption{}, o.ClientOptions...)
+	if cfg.CredentialsFile != "" {
+		clientOpts = append(clientOpts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	sc, err := storage.NewClient(ctx, clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	return &Client{client: sc, bucket: cfg.BucketName, opts: o, owned: true}, nil
+}
+
+// NewWithClient returns a Client for bucket using an existing storage client (e.g. one pointed at an
+// emulator). Close does not close sc.
+func NewWithClient(sc *storage.Client, bucket string, opts Options, override ...Option) *Client {
+	return &Client{client: sc, bucket: bucket, opts: resolveOptions(opts, override)}
+}
+
+func resolveOptions(opts Options, override []Option) *Options {
+	o := &Options{}
+	*o = opts
+	for _, fn := range override {
+		fn(o)
+	}
+	o.applyDefaults()
+	return o
+}
+
+// Storage returns the underlying storage client.
+func (c *Client) Storage() *storage.Client {
+	return c.client
+}
+
+// Bucket returns the handle of the client's bucket.
+func (c *Client) Bucket() *storage.BucketHandle {
+	return c.client.Bucket(c.bucket)
+}
+
+// BucketName returns the client's bucket name.
+func (c *Client) BucketName() string {
+	return c.bucket
+}
+
+// HealthCheck reads the bucket's attributes with ctx.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	if c.bucket == "" {
+		return fmt.Errorf("GCS bucket name is not configured")
+	}
+	if _, err := c.Bucket().Attrs(ctx); err != nil {
+		return fmt.Errorf("failed to access bucket %s: %w", c.bucket, err)
+	}
+	return nil
+}
+
+// Close closes the underlying storage client when the Client created it (New).
+func (c *Client) Close() error {
+	if c.owned {
+		return c.client.Close()
+	}
+	return nil
+}
+
+// Preconditions make a write, read, or delete conditional on the object's current state, for optimistic
+// concurrency: read an object, keep its generation, and write back with IfGenerationMatch. A failed
+// precondition returns ErrPreconditionFailed.
+type Preconditions struct {
+	// IfGenerationMatch requires the object's generation to equal this value (0: no condition).
+	IfGenerationMatch int64
+	// IfMetagenerationMatch requires the object's metageneration to equal this value (0: no condition).
+	IfMetagenerationMatch int64
+	// IfNotExists requires that the object does not exist (create-only writes). Ignored for reads.
+	IfNotExists bool
+}
+
+func (p Preconditions) conditions(read bool) *storage.Conditions {
+	cond := storage.Conditions{
+		GenerationMatch:     p.IfGenerationMatch,
+		MetagenerationMatch: p.IfMetagenerationMatch,
+	}
+	if p.IfNotExists && !read && p.IfGenerationMatch == 0 {
+		cond.DoesNotExist = true
+	}
+	if cond == (storage.Conditions{}) {
+		return nil
+	}
+	return &cond
+}
+
+// UploadOptions are the object attributes and upload settings for Upload.
+type UploadOptions struct {
+	Preconditions
+	// ContentType of the object; detected from the first 512 bytes when empty.
+	ContentType string
+	// ContentEncoding of the data as given, e.g. "gzip" for pre-compressed content.
+	ContentEncoding    string
+	CacheControl       string
+	ContentDisposition string
+	// Metadata is stored as custom object metadata.
+	Metadata map[string]string
+	// ChunkSize is the resumable upload chunk size in bytes: 0 uses Options.ChunkSize, a negative value sends
+	// the object in a single request (no resume on failure; best for small objects). Each upload buffers one
+	// chunk in memory.
+	ChunkSize int
+	// Progress, when set, is called with the number of bytes sent after each chunk.
+	Progress func(bytesSent int64)
+}
+
+// Upload streams r to object name. Data is sent in resumable chunks of UploadOptions.ChunkSize, so memory
+// use is bounded by the chunk size rather than the object size; chunks are retried on transient errors
+// when the upload is idempotent (a generation precondition is set). Cancelling ctx aborts the upload and
+// leaves the object unchanged. Returns the attributes of the written object (including its generation).
+func (c *Client) Upload(ctx context.Context, name string, r io.Reader, opts UploadOptions) (*storage.ObjectAttrs, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := c.object(name, opts.Preconditions, false).NewWriter(ctx)
+	w.ContentType = opts.ContentType
+	w.ContentEncoding = opts.ContentEncoding
+	w.CacheControl = opts.CacheControl
+	w.ContentDisposition = opts.ContentDisposition
+	w.Metadata = opts.Metadata
+	switch {
+	case opts.ChunkSize < 0:
+		w.ChunkSize = 0
+	case opts.ChunkSize > 0:
+		w.ChunkSize = opts.ChunkSize
+	default:
+		w.ChunkSize = c.opts.ChunkSize
+	}
+	w.ChunkRetryDeadline = c.opts.ChunkRetryDeadline
+	w.ProgressFunc = opts.Progress
+
+	if _, err := io.Copy(w, r); err != nil {
+		// Cancelling before Close discards the partial upload.
+		cancel()
+		_ = w.Close()
+		return nil, wrapError("upload", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, wrapError("upload", name, err)
+	}
+	return w.Attrs(), nil
+}
+
+// DownloadOptions select what Download and NewReader read.
+type DownloadOptions struct {
+	Preconditions
+	// Offset is the first byte to read. A negative Offset reads the last -Offset bytes (Length is ignored).
+	Offset int64
+	// Length is the number of bytes to read from Offset; 0 reads to the end.
+	Length int64
+	// Generation reads a specific (e.g. noncurrent) generation of the object; 0 reads the live one.
+	Generation int64
+	// ReadCompressed returns gzip-encoded objects as stored instead of decompressing them.
+	ReadCompressed bool
+}
+
+// Download writes object name, or the byte range in opts, to w. It returns the object attributes seen by
+// the read; Generation is set only when requested in opts.
+func (c *Client) Download(ctx context.Context, name string, w io.Writer, opts DownloadOptions) (*storage.ReaderObjectAttrs, error) {
+	r, err := c.NewReader(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, wrapError("download", name, err)
+	}
+	return &r.Attrs, nil
+}
+
+// NewReader opens object name, or the byte range in opts, for streaming. The caller must close it.
+func (c *Client) NewReader(ctx context.Context, name string, opts DownloadOptions) (*storage.Reader, error) {
+	obj := c.object(name, opts.Preconditions, true)
+	if opts.Generation > 0 {
+		obj = obj.Generation(opts.Generation)
+	}
+	if opts.ReadCompressed {
+		obj = obj.ReadCompressed(true)
+	}
+	offset, length := opts.Offset, opts.Length
+	if offset < 0 || length <= 0 {
+		length = -1
+	}
+	r, err := obj.NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, wrapError("read", name, err)
+	}
+	return r, nil
+}
+
+// Attrs returns the attributes of object name.
+func (c *Client) Attrs(ctx context.Context, name string) (*storage.ObjectAttrs, error) {
+	attrs, err := c.Bucket().Object(name).Attrs(ctx)
+	if err != nil {
+		return nil, wrapError("stat", name, err)
+	}
+	return attrs, nil
+}
+
+// Exists reports whether object name exists.
+func (c *Client) Exists(ctx context.Context, name string) (bool, error) {
+	_, err := c.Bucket().Object(name).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check object existence %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Delete deletes object name, subject to p.
+func (c *Client) Delete(ctx context.Context, name string, p Preconditions) error {
+	if err := c.object(name, p, false).Delete(ctx); err != nil {
+		return wrapError("delete", name, err)
+	}
+	return nil
+}
+
+func (c *Client) object(name string, p Preconditions, read bool) *storage.ObjectHandle {
+	obj := c.Bucket().Object(name)
+	if cond := p.conditions(read); cond != nil {
+		obj = obj.If(*cond)
+	}
+	return obj
+}
+
+// wrapError adds the operation and object name to err and marks failed preconditions with
+// ErrPreconditionFailed. storage.ErrObjectNotExist stays matchable with errors.Is.
+func wrapError(op, name string, err error) error {
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && gerr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("failed to %s object %s: %w: %w", op, name, ErrPreconditionFailed, err)
+	}
+	return fmt.Errorf("failed to %s object %s: %w", op, name, err)
+}
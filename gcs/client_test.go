@@ -0,0 +1,171 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_UploadSingleRequest(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+
+	attrs, err := c.Upload(context.Background(), "kyc/doc.txt", strings.NewReader("hello"), UploadOptions{
+		ContentType:     "text/plain",
+		ContentEncoding: "identity",
+		CacheControl:    "private, max-age=0",
+		Metadata:        map[string]string{"owner": "u-1"},
+		ChunkSize:       -1,
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if attrs.Name != "kyc/doc.txt" || attrs.Size != 5 || attrs.Generation == 0 {
+		t.Errorf("attrs = %+v", attrs)
+	}
+	obj := f.objects["kyc/doc.txt"]
+	if string(obj.data) != "hello" {
+		t.Errorf("stored %q, want hello", obj.data)
+	}
+	for k, want := range map[string]string{"contentType": "text/plain", "contentEncoding": "identity", "cacheControl": "private, max-age=0"} {
+		if obj.meta[k] != want {
+			t.Errorf("%s = %v, want %s", k, obj.meta[k], want)
+		}
+	}
+	if md, _ := obj.meta["metadata"].(map[string]interface{}); md["owner"] != "u-1" {
+		t.Errorf("metadata = %v", obj.meta["metadata"])
+	}
+}
+
+func TestClient_UploadResumableChunks(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client(WithChunkSize(256 << 10))
+	data := bytes.Repeat([]byte("0123456789abcdef"), 40<<10) // 640 KiB: three chunks
+
+	var progress []int64
+	attrs, err := c.Upload(context.Background(), "big.bin", bytes.NewReader(data), UploadOptions{
+		Progress: func(n int64) { progress = append(progress, n) },
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if attrs.Size != int64(len(data)) || !bytes.Equal(f.objects["big.bin"].data, data) {
+		t.Fatalf("stored %d bytes, want %d", len(f.objects["big.bin"].data), len(data))
+	}
+	if f.chunks != 3 {
+		t.Errorf("chunk requests = %d, want 3", f.chunks)
+	}
+	if len(progress) == 0 || progress[len(progress)-1] != int64(len(data)) {
+		t.Errorf("progress = %v, want to end at %d", progress, len(data))
+	}
+}
+
+func TestClient_UploadCancelled(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.Upload(ctx, "cancelled.txt", strings.NewReader("data"), UploadOptions{}); err == nil {
+		t.Fatal("Upload with cancelled context succeeded")
+	}
+	if _, ok := f.objects["cancelled.txt"]; ok {
+		t.Error("object written despite cancelled context")
+	}
+}
+
+func TestClient_UploadPreconditions(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx := context.Background()
+	gen := f.put("ledger.json", []byte(`{"v":1}`), nil)
+
+	_, err := c.Upload(ctx, "ledger.json", strings.NewReader(`{"v":2}`), UploadOptions{Preconditions: Preconditions{IfNotExists: true}, ChunkSize: -1})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("IfNotExists on existing object: err = %v, want ErrPreconditionFailed", err)
+	}
+	_, err = c.Upload(ctx, "ledger.json", strings.NewReader(`{"v":2}`), UploadOptions{Preconditions: Preconditions{IfGenerationMatch: gen + 10}, ChunkSize: -1})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("stale generation: err = %v, want ErrPreconditionFailed", err)
+	}
+	attrs, err := c.Upload(ctx, "ledger.json", strings.NewReader(`{"v":2}`), UploadOptions{Preconditions: Preconditions{IfGenerationMatch: gen}, ChunkSize: -1})
+	if err != nil {
+		t.Fatalf("matching generation: %v", err)
+	}
+	if attrs.Generation <= gen {
+		t.Errorf("generation = %d, want > %d", attrs.Generation, gen)
+	}
+}
+
+func TestClient_Download(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx := context.Background()
+	gen := f.put("report.csv", []byte("0123456789"), map[string]interface{}{"contentType": "text/csv"})
+
+	tests := []struct {
+		name string
+		opts DownloadOptions
+		want string
+	}{
+		{"whole object", DownloadOptions{}, "0123456789"},
+		{"range", DownloadOptions{Offset: 2, Length: 3}, "234"},
+		{"from offset", DownloadOptions{Offset: 7}, "789"},
+		{"suffix", DownloadOptions{Offset: -4}, "6789"},
+		{"generation match", DownloadOptions{Preconditions: Preconditions{IfGenerationMatch: gen}}, "0123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			attrs, err := c.Download(ctx, "report.csv", &buf, tt.opts)
+			if err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+			if attrs.Size != 10 || attrs.ContentType != "text/csv" {
+				t.Errorf("attrs = %+v", attrs)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.Download(ctx, "report.csv", &buf, DownloadOptions{Preconditions: Preconditions{IfGenerationMatch: gen + 1}}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("stale generation: err = %v, want ErrPreconditionFailed", err)
+	}
+	if _, err := c.Download(ctx, "missing.csv", &buf, DownloadOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing object: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_AttrsExistsDelete(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	gen := f.put("a.txt", []byte("a"), nil)
+
+	if ok, err := c.Exists(ctx, "a.txt"); err != nil || !ok {
+		t.Fatalf("Exists = %v, %v; want true", ok, err)
+	}
+	attrs, err := c.Attrs(ctx, "a.txt")
+	if err != nil || attrs.Generation != gen {
+		t.Fatalf("Attrs = %+v, %v", attrs, err)
+	}
+	if err := c.Delete(ctx, "a.txt", Preconditions{IfGenerationMatch: gen + 1}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("Delete stale generation: err = %v, want ErrPreconditionFailed", err)
+	}
+	if err := c.Delete(ctx, "a.txt", Preconditions{IfGenerationMatch: gen}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ok, err := c.Exists(ctx, "a.txt"); err != nil || ok {
+		t.Errorf("Exists after delete = %v, %v; want false", ok, err)
+	}
+	if _, err := c.Attrs(ctx, "a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Attrs after delete: err = %v, want ErrNotFound", err)
+	}
+}
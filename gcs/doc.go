@@ -5,13 +5,24 @@ Role in architecture:
   - Infrastructure adapter: connects to GCS, exposes client and bucket; used by application code for object read/write.
 
 Responsibilities:
-  - Setup: create storage client from config (credentials file or ADC); verify bucket access if BucketName set.
-  - GetClient, GetBucket, GetBucketName: access the client and default bucket.
-  - ReadObject, ReadObjectAsReader, WriteObject, DeleteObject, ObjectExists, ListObjects: map to GCS API calls with context.
+  - Client (New, NewWithClient): context-first operations on one bucket. Upload streams from an io.Reader in
+    resumable chunks with content type/encoding, cache-control, and metadata; Download and NewReader read whole
+    objects or byte ranges; Attrs, Exists, Delete. Preconditions (generation match, create-only) give
+    optimistic concurrency and fail with ErrPreconditionFailed.
+  - List, ListPage: paginated listing with full object attributes, delimiter "directories", MatchGlob filters,
+    and a page size capped at 1000; List streams (iter.Seq2), ListPage returns page tokens.
+  - SignedURL, PostPolicy: V4 signed URLs (GET/PUT with expiry, content type, and size limit) and POST policies
+    for browser form uploads, signed with Options.PrivateKey/SignBytes or the client credentials.
+  - VerifyUpload: completion callback for direct uploads; checks size and declared/sniffed MIME type and
+    optionally deletes rejected objects (ErrUploadRejected).
+  - Setup: create the default Client from config (credentials file or ADC); verify bucket access if BucketName set.
+  - GetDefault, GetClient, GetBucket, GetBucketName: access the default client and bucket.
+  - ReadObject, ReadObjectAsReader, WriteObject, DeleteObject, ObjectExists, ListObjects: legacy calls on the
+    default client with context.Background. ListObjects loads every name; prefer List for large prefixes.
   - Close: close the client.
 
 Constraints:
-  - SDK: cloud.google.com/go/storage. Single client and bucket per process; no provider switching.
+  - SDK: cloud.google.com/go/storage. One bucket per Client; the package-level functions share the default Client.
   - No business logic; only mapping to GCS operations.
   - Credentials: config.GCS.CredentialsFile or Application Default Credentials.
 
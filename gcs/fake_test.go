@@ -0,0 +1,346 @@
+package gcs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// fakeGCS is a minimal in-process implementation of the GCS JSON API: multipart and resumable uploads, media
+// and metadata reads with byte ranges, deletes, and generation preconditions.
+type fakeGCS struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]*fakeObject
+	sessions map[string]*fakeSession
+	nextGen  int64
+	chunks   int // resumable chunk requests received
+	lists    int // objects.list requests received
+}
+
+type fakeObject struct {
+	meta map[string]interface{}
+	data []byte
+}
+
+type fakeSession struct {
+	meta map[string]interface{}
+	cond url.Values
+	data []byte
+}
+
+func newFakeGCS(t *testing.T) *fakeGCS {
+	f := &fakeGCS{t: t, objects: map[string]*fakeObject{}, sessions: map[string]*fakeSession{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// client returns a Client for bucket "test-bucket" on the fake server.
+func (f *fakeGCS) client(override ...Option) *Client {
+	f.t.Helper()
+	sc, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(f.srv.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+		storage.WithJSONReads())
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.t.Cleanup(func() { sc.Close() })
+	return NewWithClient(sc, "test-bucket", Options{}, override...)
+}
+
+func (f *fakeGCS) put(name string, data []byte, meta map[string]interface{}) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.store(name, data, meta)
+}
+
+// store saves an object as a new generation; f.mu must be held.
+func (f *fakeGCS) store(name string, data []byte, meta map[string]interface{}) int64 {
+	f.nextGen++
+	m := map[string]interface{}{}
+	for k, v := range meta {
+		m[k] = v
+	}
+	m["name"] = name
+	m["bucket"] = "test-bucket"
+	m["size"] = strconv.Itoa(len(data))
+	m["generation"] = strconv.FormatInt(f.nextGen, 10)
+	m["metageneration"] = "1"
+	crc := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+	m["crc32c"] = base64.StdEncoding.EncodeToString(binary.BigEndian.AppendUint32(nil, crc))
+	if _, ok := m["contentType"]; !ok {
+		m["contentType"] = "application/octet-stream"
+	}
+	f.objects[name] = &fakeObject{meta: m, data: data}
+	return f.nextGen
+}
+
+func (f *fakeGCS) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.EscapedPath()
+	switch {
+	case strings.HasPrefix(path, "/upload/session/"):
+		f.serveChunk(w, r, strings.TrimPrefix(path, "/upload/session/"))
+	case strings.Contains(path, "/upload/") && r.Method == http.MethodPost:
+		f.serveUpload(w, r)
+	case path == "/storage/v1/b/test-bucket/o" && r.Method == http.MethodGet:
+		f.serveList(w, r.URL.Query())
+	case strings.HasPrefix(path, "/storage/v1/b/test-bucket/o/"):
+		name, _ := url.PathUnescape(strings.TrimPrefix(path, "/storage/v1/b/test-bucket/o/"))
+		f.serveObject(w, r, name)
+	case path == "/storage/v1/b/test-bucket":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"name": "test-bucket"})
+	default:
+		writeJSONError(w, http.StatusNotFound, "no route "+r.Method+" "+path)
+	}
+}
+
+func (f *fakeGCS) serveUpload(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch q.Get("uploadType") {
+	case "multipart":
+		meta, data, err := readMultipart(r)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		f.finish(w, meta, q, data)
+	case "resumable":
+		var meta map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&meta); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id := strconv.Itoa(len(f.sessions) + 1)
+		f.sessions[id] = &fakeSession{meta: meta, cond: q}
+		w.Header().Set("Location", f.srv.URL+"/upload/session/"+id)
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeJSONError(w, http.StatusBadRequest, "unsupported uploadType")
+	}
+}
+
+func (f *fakeGCS) serveChunk(w http.ResponseWriter, r *http.Request, id string) {
+	s, ok := f.sessions[id]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "no session")
+		return
+	}
+	f.chunks++
+	data, _ := io.ReadAll(r.Body)
+	s.data = append(s.data, data...)
+	// Content-Range: "bytes a-b/*" for intermediate chunks, "bytes a-b/total" or "bytes */total" for the last.
+	cr := r.Header.Get("Content-Range")
+	if strings.HasSuffix(cr, "/*") {
+		// The client sends X-GUploader-No-308, asking for 200 with a status override instead of 308.
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.data)-1))
+		w.Header().Set("X-Http-Status-Code-Override", "308")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	delete(f.sessions, id)
+	f.finish(w, s.meta, s.cond, s.data)
+}
+
+// finish checks preconditions and stores an uploaded object; f.mu must be held.
+func (f *fakeGCS) finish(w http.ResponseWriter, meta map[string]interface{}, q url.Values, data []byte) {
+	name, _ := meta["name"].(string)
+	if !f.preconditionsHold(name, q) {
+		writeJSONError(w, http.StatusPreconditionFailed, "conditionNotMet")
+		return
+	}
+	f.store(name, data, meta)
+	writeJSON(w, http.StatusOK, f.objects[name].meta)
+}
+
+func (f *fakeGCS) preconditionsHold(name string, q url.Values) bool {
+	obj := f.objects[name]
+	if v := q.Get("ifGenerationMatch"); v != "" {
+		gen, _ := strconv.ParseInt(v, 10, 64)
+		if gen == 0 {
+			return obj == nil
+		}
+		return obj != nil && obj.meta["generation"] == v
+	}
+	return true
+}
+
+func (f *fakeGCS) serveObject(w http.ResponseWriter, r *http.Request, name string) {
+	obj, ok := f.objects[name]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "No such object")
+		return
+	}
+	if !f.preconditionsHold(name, r.URL.Query()) {
+		writeJSONError(w, http.StatusPreconditionFailed, "conditionNotMet")
+		return
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		if r.URL.Query().Get("alt") != "media" {
+			writeJSON(w, http.StatusOK, obj.meta)
+			return
+		}
+		w.Header().Set("Content-Type", obj.meta["contentType"].(string))
+		w.Header().Set("X-Goog-Generation", obj.meta["generation"].(string))
+		w.Header().Set("X-Goog-Metageneration", "1")
+		data, status := obj.data, http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, end := parseRange(rng, int64(len(obj.data)))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(obj.data)))
+			data, status = obj.data[start:end+1], http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		_, _ = w.Write(data)
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, r.Method)
+	}
+}
+
+// serveList implements objects.list with prefix, delimiter, matchGlob (without character classes), offsets,
+// and name-based page tokens.
+func (f *fakeGCS) serveList(w http.ResponseWriter, q url.Values) {
+	f.lists++
+	if strings.Contains(q.Get("matchGlob"), "[") {
+		writeJSONError(w, http.StatusBadRequest, "unsupported matchGlob")
+		return
+	}
+	prefix, delim := q.Get("prefix"), q.Get("delimiter")
+	glob := globRegexp(q.Get("matchGlob"))
+	limit, _ := strconv.Atoi(q.Get("maxResults"))
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	var names []string
+	for name := range f.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var items []interface{}
+	var prefixes []string
+	seen := map[string]bool{}
+	next := ""
+	for i, name := range names {
+		if !strings.HasPrefix(name, prefix) || name <= q.Get("pageToken") || name < q.Get("startOffset") ||
+			(q.Get("endOffset") != "" && name >= q.Get("endOffset")) || (glob != nil && !glob.MatchString(name)) {
+			continue
+		}
+		p := ""
+		if j := strings.Index(name[len(prefix):], delim); delim != "" && j >= 0 {
+			p = name[:len(prefix)+j+len(delim)]
+			if seen[p] {
+				continue
+			}
+		}
+		if len(items)+len(prefixes) == limit {
+			next = names[i-1] // page tokens resume after this name
+			break
+		}
+		if p != "" {
+			seen[p] = true
+			prefixes = append(prefixes, p)
+		} else {
+			items = append(items, f.objects[name].meta)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "storage#objects", "items": items, "prefixes": prefixes, "nextPageToken": next})
+}
+
+// globRegexp compiles a GCS matchGlob: "**" matches anything, "*" and "?" do not match "/".
+func globRegexp(glob string) *regexp.Regexp {
+	if glob == "" {
+		return nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return regexp.MustCompile("^" + b.String() + "$")
+}
+
+// parseRange parses "bytes=a-b", "bytes=a-" and "bytes=-n" into inclusive bounds.
+func parseRange(h string, size int64) (start, end int64) {
+	spec := strings.TrimPrefix(h, "bytes=")
+	a, b, _ := strings.Cut(spec, "-")
+	end = size - 1
+	if a == "" {
+		n, _ := strconv.ParseInt(b, 10, 64)
+		return max(size-n, 0), end
+	}
+	start, _ = strconv.ParseInt(a, 10, 64)
+	if b != "" {
+		e, _ := strconv.ParseInt(b, 10, 64)
+		end = min(e, end)
+	}
+	return start, end
+}
+
+func readMultipart(r *http.Request) (map[string]interface{}, []byte, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, err
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil {
+		return nil, nil, err
+	}
+	var meta map[string]interface{}
+	if err := json.NewDecoder(part).Decode(&meta); err != nil {
+		return nil, nil, err
+	}
+	part, err = mr.NextPart()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(part)
+	return meta, data, err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{"error": map[string]interface{}{"code": status, "message": msg}})
+}
@@ -1,24 +1,20 @@
 package gcs
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 
 	"cloud.google.com/go/storage"
-	"google.golang.org/api/option"
 
 	"github.com/turahe/pkg/config"
 	"github.com/turahe/pkg/logger"
 )
 
-var (
-	client     *storage.Client
-	bucketName string
-	ctx        = context.Background()
-)
+var defaultClient *Client
 
-// Setup initializes the GCS client from config (credentials file or ADC) and optionally verifies bucket access. No-op if GCS.Enabled is false.
+// Setup initializes the default Client from config (credentials file or ADC) and optionally verifies bucket access. No-op if GCS.Enabled is false.
 func Setup() error {
 	configuration := config.GetConfig()
 
@@ -27,31 +23,19 @@ func Setup() error {
 		return nil
 	}
 
-	bucketName = configuration.GCS.BucketName
-
-	var err error
-	var opts []option.ClientOption
-
-	// If credentials file is provided, use it
-	if configuration.GCS.CredentialsFile != "" {
-		opts = append(opts, option.WithCredentialsFile(configuration.GCS.CredentialsFile))
-	}
-	// Otherwise, use Application Default Credentials (ADC)
-	// This will work when running on GCP or when GOOGLE_APPLICATION_CREDENTIALS env var is set
-
-	client, err = storage.NewClient(ctx, opts...)
+	ctx := context.Background()
+	c, err := New(ctx, &configuration.GCS, Options{})
 	if err != nil {
-		return fmt.Errorf("failed to create GCS client: %w", err)
+		return err
 	}
+	defaultClient = c
 
 	// Verify bucket access
-	if bucketName != "" {
-		bucket := client.Bucket(bucketName)
-		_, err = bucket.Attrs(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to access bucket %s: %w", bucketName, err)
+	if c.bucket != "" {
+		if err := c.HealthCheck(ctx); err != nil {
+			return err
 		}
-		logger.Infof("GCS client initialized successfully with bucket: %s", bucketName)
+		logger.Infof("GCS client initialized successfully with bucket: %s", c.bucket)
 	} else {
 		logger.Infof("GCS client initialized successfully (no bucket specified)")
 	}
@@ -59,136 +43,98 @@ func Setup() error {
 	return nil
 }
 
-// GetClient returns the storage client. Panics if Setup was not called or client is nil.
-func GetClient() *storage.Client {
-	if client == nil {
+// GetDefault returns the Client created by Setup. Panics if Setup was not called.
+func GetDefault() *Client {
+	if defaultClient == nil {
 		panic("GCS client is not initialized. Call Setup() first.")
 	}
-	return client
+	return defaultClient
+}
+
+// GetClient returns the storage client. Panics if Setup was not called or client is nil.
+func GetClient() *storage.Client {
+	return GetDefault().Storage()
 }
 
 // GetBucket returns the default bucket handle. Panics if bucket name is not configured.
 func GetBucket() *storage.BucketHandle {
-	if bucketName == "" {
+	if GetBucketName() == "" {
 		panic("GCS bucket name is not configured")
 	}
-	return GetClient().Bucket(bucketName)
+	return GetDefault().Bucket()
 }
 
 // GetBucketName returns the configured bucket name (may be empty if not set).
 func GetBucketName() string {
-	return bucketName
-}
-
-// ReadObject reads an object from GCS bucket
-func ReadObject(objectName string) ([]byte, error) {
-	bucket := GetBucket()
-	obj := bucket.Object(objectName)
-
-	reader, err := obj.NewReader(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create reader for object %s: %w", objectName, err)
+	if defaultClient == nil {
+		return ""
 	}
-	defer reader.Close()
+	return defaultClient.bucket
+}
 
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read object %s: %w", objectName, err)
+// HealthCheck reads the configured bucket's attributes with ctx. Returns an error if Setup was not called, no bucket
+// is configured, or the bucket is not accessible.
+func HealthCheck(ctx context.Context) error {
+	if defaultClient == nil {
+		return fmt.Errorf("GCS client is not initialized")
 	}
-
-	return data, nil
+	return defaultClient.HealthCheck(ctx)
 }
 
-// ReadObjectAsReader returns a reader for an object from GCS bucket
-func ReadObjectAsReader(objectName string) (io.ReadCloser, error) {
-	bucket := GetBucket()
-	obj := bucket.Object(objectName)
+// defaultBucketClient returns the default Client, panicking like GetBucket when no bucket is configured.
+func defaultBucketClient() *Client {
+	GetBucket()
+	return defaultClient
+}
 
-	reader, err := obj.NewReader(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create reader for object %s: %w", objectName, err)
+// ReadObject reads an object from GCS bucket. Prefer Client.Download, which takes the request context.
+func ReadObject(objectName string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := defaultBucketClient().Download(context.Background(), objectName, &buf, DownloadOptions{}); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	return reader, nil
+// ReadObjectAsReader returns a reader for an object from GCS bucket. Prefer Client.NewReader.
+func ReadObjectAsReader(objectName string) (io.ReadCloser, error) {
+	return defaultBucketClient().NewReader(context.Background(), objectName, DownloadOptions{})
 }
 
-// WriteObject writes data to an object in GCS bucket
+// WriteObject writes data to an object in GCS bucket. Prefer Client.Upload, which takes the request context
+// and streams from an io.Reader.
 func WriteObject(objectName string, data []byte, contentType string) error {
-	bucket := GetBucket()
-	obj := bucket.Object(objectName)
-
-	writer := obj.NewWriter(ctx)
-	if contentType != "" {
-		writer.ContentType = contentType
-	}
-
-	if _, err := writer.Write(data); err != nil {
-		writer.Close()
-		return fmt.Errorf("failed to write object %s: %w", objectName, err)
-	}
-
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close writer for object %s: %w", objectName, err)
-	}
-
-	return nil
+	_, err := defaultBucketClient().Upload(context.Background(), objectName, bytes.NewReader(data), UploadOptions{ContentType: contentType})
+	return err
 }
 
-// DeleteObject deletes an object from GCS bucket
+// DeleteObject deletes an object from GCS bucket. Prefer Client.Delete.
 func DeleteObject(objectName string) error {
-	bucket := GetBucket()
-	obj := bucket.Object(objectName)
-
-	if err := obj.Delete(ctx); err != nil {
-		return fmt.Errorf("failed to delete object %s: %w", objectName, err)
-	}
-
-	return nil
+	return defaultBucketClient().Delete(context.Background(), objectName, Preconditions{})
 }
 
-// ObjectExists checks if an object exists in the bucket
+// ObjectExists checks if an object exists in the bucket. Prefer Client.Exists.
 func ObjectExists(objectName string) (bool, error) {
-	bucket := GetBucket()
-	obj := bucket.Object(objectName)
-
-	_, err := obj.Attrs(ctx)
-	if err == storage.ErrObjectNotExist {
-		return false, nil
-	}
-	if err != nil {
-		return false, fmt.Errorf("failed to check object existence %s: %w", objectName, err)
-	}
-
-	return true, nil
+	return defaultBucketClient().Exists(context.Background(), objectName)
 }
 
-// ListObjects lists objects in the bucket with the given prefix
+// ListObjects lists the names of the objects in the bucket with the given prefix. It loads every name into
+// memory; use Client.List or Client.ListPage for large prefixes or object attributes.
 func ListObjects(prefix string) ([]string, error) {
-	bucket := GetBucket()
-	query := &storage.Query{
-		Prefix: prefix,
-	}
-
 	var objectNames []string
-	it := bucket.Objects(ctx, query)
-	for {
-		attrs, err := it.Next()
-		if err == storage.ErrObjectNotExist || err == io.EOF {
-			break
-		}
+	for attrs, err := range defaultBucketClient().List(context.Background(), ListOptions{Prefix: prefix}) {
 		if err != nil {
-			return nil, fmt.Errorf("failed to list objects with prefix %s: %w", prefix, err)
+			return nil, err
 		}
 		objectNames = append(objectNames, attrs.Name)
 	}
-
 	return objectNames, nil
 }
 
 // Close closes the GCS client
 func Close() error {
-	if client != nil {
-		return client.Close()
+	if defaultClient != nil {
+		return defaultClient.Close()
 	}
 	return nil
 }
@@ -0,0 +1,110 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// maxPageSize is the largest page the GCS objects.list API returns; larger ListOptions.PageSize values are
+// capped to it.
+const maxPageSize = 1000
+
+// ListOptions select the objects List and ListPage return.
+type ListOptions struct {
+	// Prefix restricts results to names starting with it.
+	Prefix string
+	// Delimiter, usually "/", lists one "directory" level: names containing Delimiter after Prefix are
+	// collapsed into prefixes (Page.Prefixes, or attributes with only Prefix set from List).
+	Delimiter string
+	// MatchGlob filters names server-side with a glob ("**.png", "logs/2026-*/app-?.log"). "*" does not match
+	// "/"; "**" does.
+	MatchGlob string
+	// StartOffset and EndOffset restrict results to names in [StartOffset, EndOffset).
+	StartOffset, EndOffset string
+	// Versions includes noncurrent generations.
+	Versions bool
+	// PageSize is the number of results per page (objects plus prefixes); default and maximum 1000.
+	PageSize int
+	// PageToken resumes ListPage from Page.NextPageToken. Ignored by List.
+	PageToken string
+}
+
+// Page is one page of ListPage results.
+type Page struct {
+	// Objects have full attributes: size, content type, updated time, generation, metadata, etc.
+	Objects []*storage.ObjectAttrs
+	// Prefixes are the "directories" below ListOptions.Prefix when a Delimiter is set.
+	Prefixes []string
+	// NextPageToken fetches the next page; empty on the last page.
+	NextPageToken string
+}
+
+// List streams the objects selected by opts, fetching one page at a time, so memory stays bounded for any
+// bucket size. With a Delimiter, "directories" are yielded as attributes with only Prefix set. Iteration stops
+// at the first error, which is yielded.
+//
+//	for attrs, err := range c.List(ctx, gcs.ListOptions{Prefix: "kyc/"}) {
+//		if err != nil { return err }
+//		...
+//	}
+func (c *Client) List(ctx context.Context, opts ListOptions) iter.Seq2[*storage.ObjectAttrs, error] {
+	return func(yield func(*storage.ObjectAttrs, error) bool) {
+		it := c.objects(ctx, opts)
+		it.PageInfo().MaxSize = pageSize(opts.PageSize)
+		for {
+			attrs, err := it.Next()
+			if errors.Is(err, iterator.Done) {
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list objects with prefix %s: %w", opts.Prefix, err))
+				return
+			}
+			if !yield(attrs, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListPage returns one page of the objects selected by opts, starting at opts.PageToken. Use it when the
+// caller pages through results across requests (e.g. an API returning a page token).
+func (c *Client) ListPage(ctx context.Context, opts ListOptions) (*Page, error) {
+	var items []*storage.ObjectAttrs
+	next, err := iterator.NewPager(c.objects(ctx, opts), pageSize(opts.PageSize), opts.PageToken).NextPage(&items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects with prefix %s: %w", opts.Prefix, err)
+	}
+	page := &Page{NextPageToken: next}
+	for _, attrs := range items {
+		if attrs.Prefix != "" {
+			page.Prefixes = append(page.Prefixes, attrs.Prefix)
+		} else {
+			page.Objects = append(page.Objects, attrs)
+		}
+	}
+	return page, nil
+}
+
+func (c *Client) objects(ctx context.Context, opts ListOptions) *storage.ObjectIterator {
+	return c.Bucket().Objects(ctx, &storage.Query{
+		Prefix:      opts.Prefix,
+		Delimiter:   opts.Delimiter,
+		MatchGlob:   opts.MatchGlob,
+		StartOffset: opts.StartOffset,
+		EndOffset:   opts.EndOffset,
+		Versions:    opts.Versions,
+	})
+}
+
+func pageSize(n int) int {
+	if n <= 0 || n > maxPageSize {
+		return maxPageSize
+	}
+	return n
+}
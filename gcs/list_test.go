@@ -0,0 +1,101 @@
+package gcs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func putNames(f *fakeGCS, names ...string) {
+	for _, n := range names {
+		f.put(n, []byte(n), map[string]interface{}{"contentType": "image/png", "metadata": map[string]string{"owner": "u-1"}})
+	}
+}
+
+func TestClient_List(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	putNames(f, "img/1.png", "img/2.jpg", "img/3.png", "img/thumbs/1.png", "img/thumbs/2.png", "doc.pdf")
+
+	var names []string
+	for attrs, err := range c.List(ctx, ListOptions{Prefix: "img/", PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if attrs.Size != int64(len(attrs.Name)) || attrs.ContentType != "image/png" || attrs.Generation == 0 || attrs.Metadata["owner"] != "u-1" {
+			t.Errorf("attrs = %+v", attrs)
+		}
+		names = append(names, attrs.Name)
+	}
+	if strings.Join(names, ",") != "img/1.png,img/2.jpg,img/3.png,img/thumbs/1.png,img/thumbs/2.png" {
+		t.Errorf("List = %v", names)
+	}
+	if f.lists != 3 {
+		t.Errorf("list requests = %d, want 3 pages of 2", f.lists)
+	}
+
+	names = nil
+	for attrs, err := range c.List(ctx, ListOptions{MatchGlob: "img/*.png"}) {
+		if err != nil {
+			t.Fatalf("List glob: %v", err)
+		}
+		names = append(names, attrs.Name)
+	}
+	if strings.Join(names, ",") != "img/1.png,img/3.png" {
+		t.Errorf("List glob = %v", names)
+	}
+
+	n := 0
+	for range c.List(ctx, ListOptions{}) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("break after %d objects", n)
+	}
+}
+
+func TestClient_ListPage(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx := context.Background()
+	putNames(f, "img/1.png", "img/2.png", "img/a/1.png", "img/b/1.png", "img/b/2.png")
+
+	page, err := c.ListPage(ctx, ListOptions{Prefix: "img/", Delimiter: "/", PageSize: 3})
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	if len(page.Objects) != 2 || page.Objects[0].Name != "img/1.png" || strings.Join(page.Prefixes, ",") != "img/a/" || page.NextPageToken == "" {
+		t.Fatalf("page 1 = objects %d, prefixes %v, token %q", len(page.Objects), page.Prefixes, page.NextPageToken)
+	}
+	page, err = c.ListPage(ctx, ListOptions{Prefix: "img/", Delimiter: "/", PageSize: 3, PageToken: page.NextPageToken})
+	if err != nil {
+		t.Fatalf("ListPage 2: %v", err)
+	}
+	if len(page.Objects) != 0 || strings.Join(page.Prefixes, ",") != "img/b/" || page.NextPageToken != "" {
+		t.Errorf("page 2 = objects %d, prefixes %v, token %q", len(page.Objects), page.Prefixes, page.NextPageToken)
+	}
+}
+
+func TestClient_ListError(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	for _, err := range c.List(context.Background(), ListOptions{Prefix: "img/", MatchGlob: "img/[ab].png"}) {
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to list objects with prefix img/") {
+			t.Fatalf("err = %v, want list failure", err)
+		}
+		return
+	}
+	t.Fatal("List yielded nothing")
+}
+
+func TestPageSize(t *testing.T) {
+	for in, want := range map[int]int{0: 1000, -1: 1000, 10: 10, 1000: 1000, 5000: 1000} {
+		if got := pageSize(in); got != want {
+			t.Errorf("pageSize(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gcs
+
+import (
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+const (
+	// defaultChunkSize matches the storage SDK default (16 MiB); each in-flight upload buffers one chunk.
+	defaultChunkSize          = 16 << 20
+	defaultChunkRetryDeadline = 32 * time.Second
+)
+
+// Options holds Client settings. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// ClientOptions are passed to storage.NewClient by New (e.g. option.WithEndpoint for an emulator).
+	ClientOptions []option.ClientOption
+	// ChunkSize is the default resumable upload chunk size in bytes (rounded up to a multiple of 256 KiB by
+	// the SDK); default 16 MiB.
+	ChunkSize int
+	// ChunkRetryDeadline bounds the retries of one upload chunk; default 32s.
+	ChunkRetryDeadline time.Duration
+	// GoogleAccessID is the service account email that signs URLs and POST policies. Default: detected from
+	// the client credentials.
+	GoogleAccessID string
+	// PrivateKey is the PEM-encoded key of GoogleAccessID. When both PrivateKey and SignBytes are empty, the
+	// key in the credentials file is used, or the IAM signBlob API (roles/iam.serviceAccountTokenCreator).
+	PrivateKey []byte
+	// SignBytes signs with an external signer (e.g. a KMS key) instead of PrivateKey.
+	SignBytes func([]byte) ([]byte, error)
+}
+
+func (o *Options) applyDefaults() {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = defaultChunkSize
+	}
+	if o.ChunkRetryDeadline <= 0 {
+		o.ChunkRetryDeadline = defaultChunkRetryDeadline
+	}
+}
+
+// Option is a functional option applied to Options.
+type Option func(*Options)
+
+// WithClientOptions appends storage client options used by New.
+func WithClientOptions(opts ...option.ClientOption) Option {
+	return func(o *Options) { o.ClientOptions = append(o.ClientOptions, opts...) }
+}
+
+// WithChunkSize sets the default upload chunk size in bytes.
+func WithChunkSize(n int) Option {
+	return func(o *Options) { o.ChunkSize = n }
+}
+
+// WithChunkRetryDeadline sets the retry deadline for one upload chunk.
+func WithChunkRetryDeadline(d time.Duration) Option {
+	return func(o *Options) { o.ChunkRetryDeadline = d }
+}
+
+// WithSigner sets the service account and PEM private key used to sign URLs and POST policies.
+func WithSigner(googleAccessID string, privateKey []byte) Option {
+	return func(o *Options) {
+		o.GoogleAccessID = googleAccessID
+		o.PrivateKey = privateKey
+	}
+}
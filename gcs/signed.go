@@ -0,0 +1,272 @@
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// ErrUploadRejected is returned (wrapped) by VerifyUpload when an uploaded object violates its constraints.
+var ErrUploadRejected = errors.New("gcs: upload rejected")
+
+const (
+	defaultSignedExpiry = 15 * time.Minute
+	// maxSignedExpiry is the V4 signing limit.
+	maxSignedExpiry = 7 * 24 * time.Hour
+	// contentLengthRangeHeader limits the size of a PUT to a signed URL when it is part of the signature.
+	contentLengthRangeHeader = "X-Goog-Content-Length-Range"
+)
+
+// SignedURLOptions configure SignedURL.
+type SignedURLOptions struct {
+	// Method is http.MethodGet (default) or http.MethodPut.
+	Method string
+	// Expires is how long the URL stays valid; default 15 minutes, at most 7 days.
+	Expires time.Duration
+	// ContentType, for PUT, is the Content-Type the uploader must send.
+	ContentType string
+	// MaxSize, for PUT, is the largest accepted upload in bytes (0: no limit). The uploader must send the
+	// X-Goog-Content-Length-Range header returned in SignedURL.Headers.
+	MaxSize int64
+	// ResponseContentDisposition, for GET, overrides the Content-Disposition of the response, e.g.
+	// `attachment; filename="statement.pdf"`.
+	ResponseContentDisposition string
+}
+
+// SignedURL is a V4 signed URL and the headers the request must carry for the signature to match.
+type SignedURL struct {
+	URL     string
+	Method  string
+	Headers http.Header
+	Expires time.Time
+}
+
+// SignedURL returns a V4 signed URL that allows opts.Method on object name without credentials, so clients
+// download from or upload to GCS directly instead of through the API. Signing uses Options.GoogleAccessID,
+// PrivateKey, and SignBytes, or the client credentials.
+func (c *Client) SignedURL(name string, opts SignedURLOptions) (*SignedURL, error) {
+	if opts.Method == "" {
+		opts.Method = http.MethodGet
+	}
+	if opts.Method != http.MethodGet && opts.Method != http.MethodPut {
+		return nil, fmt.Errorf("unsupported signed URL method %s", opts.Method)
+	}
+	expires, err := signedExpiry(opts.Expires)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	so := &storage.SignedURLOptions{
+		GoogleAccessID: c.opts.GoogleAccessID,
+		PrivateKey:     c.opts.PrivateKey,
+		SignBytes:      c.opts.SignBytes,
+		Method:         opts.Method,
+		Expires:        expires,
+		Scheme:         storage.SigningSchemeV4,
+	}
+	if opts.Method == http.MethodPut {
+		if opts.ContentType != "" {
+			so.ContentType = opts.ContentType
+			headers.Set("Content-Type", opts.ContentType)
+		}
+		if opts.MaxSize > 0 {
+			rng := "0," + strconv.FormatInt(opts.MaxSize, 10)
+			so.Headers = append(so.Headers, strings.ToLower(contentLengthRangeHeader)+":"+rng)
+			headers.Set(contentLengthRangeHeader, rng)
+		}
+	}
+	if opts.Method == http.MethodGet && opts.ResponseContentDisposition != "" {
+		so.QueryParameters = url.Values{"response-content-disposition": {opts.ResponseContentDisposition}}
+	}
+
+	u, err := c.Bucket().SignedURL(name, so)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign URL for object %s: %w", name, err)
+	}
+	return &SignedURL{URL: u, Method: opts.Method, Headers: headers, Expires: expires}, nil
+}
+
+// PostPolicyOptions configure PostPolicy.
+type PostPolicyOptions struct {
+	// Expires is how long the policy stays valid; default 15 minutes, at most 7 days.
+	Expires time.Duration
+	// ContentType, when set, is the exact Content-Type of the upload; it is included in Fields.
+	ContentType string
+	// ContentTypePrefix, when ContentType is empty, requires the form's Content-Type field to start with it
+	// (e.g. "image/"); the form must then include a Content-Type field.
+	ContentTypePrefix string
+	// MinSize and MaxSize bound the upload size in bytes; MaxSize 0 means no upper limit. MinSize must not
+	// exceed a non-zero MaxSize.
+	MinSize, MaxSize int64
+	CacheControl     string
+	// Metadata is stored as custom object metadata; it is included in Fields.
+	Metadata map[string]string
+	// SuccessStatus is the HTTP status GCS returns after a successful upload; default 201 (with an XML body
+	// describing the object). Ignored when RedirectURL is set.
+	SuccessStatus int
+	// RedirectURL, when set, is where the browser is redirected after a successful upload.
+	RedirectURL string
+}
+
+// PostPolicy is a signed V4 POST policy: an HTML form posts Fields (in any order, before the "file" field) as
+// multipart/form-data to URL.
+type PostPolicy struct {
+	URL     string
+	Fields  map[string]string
+	Expires time.Time
+}
+
+// PostPolicy returns a signed POST policy that lets a browser form upload object name directly to GCS, with
+// the content type and size constraints in opts enforced by GCS. Signing is as for SignedURL.
+func (c *Client) PostPolicy(name string, opts PostPolicyOptions) (*PostPolicy, error) {
+	expires, err := signedExpiry(opts.Expires)
+	if err != nil {
+		return nil, err
+	}
+	fields := &storage.PolicyV4Fields{
+		ContentType:            opts.ContentType,
+		CacheControl:           opts.CacheControl,
+		Metadata:               opts.Metadata,
+		StatusCodeOnSuccess:    opts.SuccessStatus,
+		RedirectToURLOnSuccess: opts.RedirectURL,
+	}
+	if fields.StatusCodeOnSuccess == 0 && fields.RedirectToURLOnSuccess == "" {
+		fields.StatusCodeOnSuccess = http.StatusCreated
+	}
+	var conds []storage.PostPolicyV4Condition
+	if opts.ContentType == "" && opts.ContentTypePrefix != "" {
+		conds = append(conds, storage.ConditionStartsWith("$Content-Type", opts.ContentTypePrefix))
+	}
+	switch {
+	case opts.MaxSize > 0 && opts.MinSize > opts.MaxSize:
+		return nil, fmt.Errorf("POST policy for object %s: MinSize %d exceeds MaxSize %d", name, opts.MinSize, opts.MaxSize)
+	case opts.MaxSize > 0:
+		conds = append(conds, storage.ConditionContentLengthRange(uint64(max(opts.MinSize, 0)), uint64(opts.MaxSize)))
+	case opts.MinSize > 0:
+		conds = append(conds, storage.ConditionContentLengthRange(uint64(opts.MinSize), math.MaxInt64))
+	}
+
+	p, err := c.Bucket().GenerateSignedPostPolicyV4(name, &storage.PostPolicyV4Options{
+		GoogleAccessID: c.opts.GoogleAccessID,
+		PrivateKey:     c.opts.PrivateKey,
+		SignBytes:      c.opts.SignBytes,
+		Expires:        expires,
+		Fields:         fields,
+		Conditions:     conds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign POST policy for object %s: %w", name, err)
+	}
+	return &PostPolicy{URL: p.URL, Fields: p.Fields, Expires: expires}, nil
+}
+
+func signedExpiry(d time.Duration) (time.Time, error) {
+	if d <= 0 {
+		d = defaultSignedExpiry
+	}
+	if d > maxSignedExpiry {
+		return time.Time{}, fmt.Errorf("signed URL expiry %s exceeds %s", d, maxSignedExpiry)
+	}
+	return time.Now().Add(d), nil
+}
+
+// UploadConstraints are the checks VerifyUpload applies to an object uploaded through a signed URL or POST
+// policy.
+type UploadConstraints struct {
+	// MinSize and MaxSize bound the object size in bytes; MaxSize 0 means no limit.
+	MinSize, MaxSize int64
+	// AllowedTypes lists accepted media types, exact ("application/pdf") or by top-level type ("image/*").
+	// Empty accepts any type.
+	AllowedTypes []string
+	// Sniff also detects the type from the object's first 512 bytes (http.DetectContentType), which must be
+	// allowed too, so a renamed executable declared as "image/png" is rejected.
+	Sniff bool
+	// DeleteRejected deletes an object that fails verification (only the verified generation).
+	DeleteRejected bool
+}
+
+// VerifyUpload checks object name against cons after a client reports a direct upload as complete (the
+// completion callback), since signed URLs cannot enforce every constraint and clients can skip the checks
+// they can. It returns the verified attributes; keep Generation to read exactly the verified content. A
+// violation returns ErrUploadRejected (wrapped) with the reason; a missing object returns ErrNotFound.
+func (c *Client) VerifyUpload(ctx context.Context, name string, cons UploadConstraints) (*storage.ObjectAttrs, error) {
+	attrs, err := c.Attrs(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	reason, err := c.checkUpload(ctx, attrs, cons)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return attrs, nil
+	}
+	rejected := fmt.Errorf("%w: object %s %s", ErrUploadRejected, name, reason)
+	if cons.DeleteRejected {
+		if err := c.Delete(ctx, name, Preconditions{IfGenerationMatch: attrs.Generation}); err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, errors.Join(rejected, err)
+		}
+	}
+	return nil, rejected
+}
+
+// checkUpload returns why attrs violates cons, or "" when it does not.
+func (c *Client) checkUpload(ctx context.Context, attrs *storage.ObjectAttrs, cons UploadConstraints) (string, error) {
+	if attrs.Size < cons.MinSize {
+		return fmt.Sprintf("is %d bytes, below the minimum of %d", attrs.Size, cons.MinSize), nil
+	}
+	if cons.MaxSize > 0 && attrs.Size > cons.MaxSize {
+		return fmt.Sprintf("is %d bytes, above the maximum of %d", attrs.Size, cons.MaxSize), nil
+	}
+	if len(cons.AllowedTypes) == 0 {
+		return "", nil
+	}
+	if !typeAllowed(attrs.ContentType, cons.AllowedTypes) {
+		return fmt.Sprintf("has content type %q, not one of %v", attrs.ContentType, cons.AllowedTypes), nil
+	}
+	if !cons.Sniff {
+		return "", nil
+	}
+	r, err := c.NewReader(ctx, attrs.Name, DownloadOptions{
+		Preconditions: Preconditions{IfGenerationMatch: attrs.Generation},
+		Length:        512,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	head, err := io.ReadAll(r)
+	if err != nil {
+		return "", wrapError("read", attrs.Name, err)
+	}
+	if detected := http.DetectContentType(head); !typeAllowed(detected, cons.AllowedTypes) {
+		return fmt.Sprintf("content looks like %q, not one of %v", detected, cons.AllowedTypes), nil
+	}
+	return "", nil
+}
+
+// typeAllowed reports whether contentType (parameters ignored) matches an entry of allowed.
+func typeAllowed(contentType string, allowed []string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == mt || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
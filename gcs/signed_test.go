@@ -0,0 +1,191 @@
+package gcs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAccessID = "uploader@project.iam.gserviceaccount.com"
+
+// testKey returns a PEM-encoded RSA private key for offline signing.
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestClient_SignedURL(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client(WithSigner(testAccessID, testKey(t)))
+
+	get, err := c.SignedURL("kyc/doc.pdf", SignedURLOptions{
+		Expires:                    10 * time.Minute,
+		ResponseContentDisposition: `attachment; filename="doc.pdf"`,
+	})
+	if err != nil {
+		t.Fatalf("SignedURL GET: %v", err)
+	}
+	u, err := url.Parse(get.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if get.Method != http.MethodGet || !strings.HasSuffix(u.Path, "/test-bucket/kyc/doc.pdf") {
+		t.Errorf("GET = %s %s", get.Method, get.URL)
+	}
+	if q.Get("X-Goog-Algorithm") != "GOOG4-RSA-SHA256" || (q.Get("X-Goog-Expires") != "600" && q.Get("X-Goog-Expires") != "599") || q.Get("X-Goog-Signature") == "" {
+		t.Errorf("GET query = %v", q)
+	}
+	if !strings.HasPrefix(q.Get("X-Goog-Credential"), testAccessID+"/") {
+		t.Errorf("credential = %q", q.Get("X-Goog-Credential"))
+	}
+	if q.Get("response-content-disposition") != `attachment; filename="doc.pdf"` {
+		t.Errorf("response-content-disposition = %q", q.Get("response-content-disposition"))
+	}
+
+	put, err := c.SignedURL("kyc/upload.png", SignedURLOptions{Method: http.MethodPut, ContentType: "image/png", MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("SignedURL PUT: %v", err)
+	}
+	u, _ = url.Parse(put.URL)
+	if h := u.Query().Get("X-Goog-SignedHeaders"); h != "content-type;host;x-goog-content-length-range" {
+		t.Errorf("signed headers = %q", h)
+	}
+	if put.Headers.Get("Content-Type") != "image/png" || put.Headers.Get("X-Goog-Content-Length-Range") != "0,1048576" {
+		t.Errorf("PUT headers = %v", put.Headers)
+	}
+	if d := time.Until(put.Expires); d <= 14*time.Minute || d > 15*time.Minute {
+		t.Errorf("default expiry in %s, want 15m", d)
+	}
+
+	if _, err := c.SignedURL("a", SignedURLOptions{Method: http.MethodDelete}); err == nil {
+		t.Error("DELETE: want error")
+	}
+	if _, err := c.SignedURL("a", SignedURLOptions{Expires: 8 * 24 * time.Hour}); err == nil {
+		t.Error("8 day expiry: want error")
+	}
+}
+
+func TestClient_PostPolicy(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client(WithSigner(testAccessID, testKey(t)))
+
+	p, err := c.PostPolicy("avatars/u-1.png", PostPolicyOptions{
+		ContentTypePrefix: "image/",
+		MaxSize:           2 << 20,
+		Metadata:          map[string]string{"x-goog-meta-owner": "u-1"},
+	})
+	if err != nil {
+		t.Fatalf("PostPolicy: %v", err)
+	}
+	if !strings.HasSuffix(p.URL, "/test-bucket/") {
+		t.Errorf("URL = %s", p.URL)
+	}
+	if p.Fields["key"] != "avatars/u-1.png" || p.Fields["success_action_status"] != "201" ||
+		p.Fields["x-goog-meta-owner"] != "u-1" || p.Fields["x-goog-signature"] == "" {
+		t.Errorf("fields = %v", p.Fields)
+	}
+	policy, err := base64.StdEncoding.DecodeString(p.Fields["policy"])
+	if err != nil {
+		t.Fatalf("policy: %v", err)
+	}
+	for _, want := range []string{`["starts-with","$Content-Type","image/"]`, `["content-length-range",0,2097152]`} {
+		if !strings.Contains(string(policy), want) {
+			t.Errorf("policy %s lacks %s", policy, want)
+		}
+	}
+}
+
+func TestClient_PostPolicy_SizeBounds(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client(WithSigner(testAccessID, testKey(t)))
+	p, err := c.PostPolicy("docs/a.pdf", PostPolicyOptions{MinSize: 1})
+	if err != nil {
+		t.Fatalf("PostPolicy: %v", err)
+	}
+	policy, _ := base64.StdEncoding.DecodeString(p.Fields["policy"])
+	if want := `["content-length-range",1,9223372036854775807]`; !strings.Contains(string(policy), want) {
+		t.Errorf("policy %s lacks %s", policy, want)
+	}
+	if _, err := c.PostPolicy("docs/a.pdf", PostPolicyOptions{MinSize: 10, MaxSize: 5}); err == nil {
+		t.Error("MinSize > MaxSize: want error")
+	}
+}
+
+func TestClient_VerifyUpload(t *testing.T) {
+	f := newFakeGCS(t)
+	c := f.client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	images := UploadConstraints{MinSize: 1, MaxSize: 1 << 10, AllowedTypes: []string{"image/*"}, Sniff: true}
+
+	gen := f.put("ok.png", png, map[string]interface{}{"contentType": "image/png"})
+	attrs, err := c.VerifyUpload(ctx, "ok.png", images)
+	if err != nil || attrs.Generation != gen {
+		t.Fatalf("VerifyUpload = %+v, %v", attrs, err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{"too large", append(png, make([]byte, 1<<10)...), "image/png"},
+		{"empty", nil, "image/png"},
+		{"declared type", png, "application/pdf"},
+		{"sniffed type", []byte("#!/bin/sh\nrm -rf /\n"), "image/png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.put("bad", tt.data, map[string]interface{}{"contentType": tt.contentType})
+			if _, err := c.VerifyUpload(ctx, "bad", images); !errors.Is(err, ErrUploadRejected) {
+				t.Fatalf("err = %v, want ErrUploadRejected", err)
+			}
+			if _, ok := f.objects["bad"]; !ok {
+				t.Error("rejected object deleted without DeleteRejected")
+			}
+		})
+	}
+
+	f.put("bad", []byte("text"), map[string]interface{}{"contentType": "text/plain; charset=utf-8"})
+	images.DeleteRejected = true
+	if _, err := c.VerifyUpload(ctx, "bad", images); !errors.Is(err, ErrUploadRejected) {
+		t.Fatalf("err = %v, want ErrUploadRejected", err)
+	}
+	if _, ok := f.objects["bad"]; ok {
+		t.Error("rejected object not deleted")
+	}
+	if _, err := c.VerifyUpload(ctx, "missing", images); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing object: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTypeAllowed(t *testing.T) {
+	allowed := []string{"application/pdf", "image/*"}
+	for ct, want := range map[string]bool{
+		"application/pdf":           true,
+		"Image/JPEG":                true,
+		"image/png; charset=binary": true,
+		"application/pdfx":          false,
+		"imagery/png":               false,
+		"":                          false,
+	} {
+		if got := typeAllowed(ct, allowed); got != want {
+			t.Errorf("typeAllowed(%q) = %v, want %v", ct, got, want)
+		}
+	}
+}
@@ -86,8 +86,10 @@ func (c *BaseHandler) GetIDFromRequestOrParam(ctx *gin.Context, reqID string, pa
 }
 
 // HandleServiceError maps err to an HTTP response and writes it. Returns true if a response was written.
-// Checks errors.Is(ErrNotFound) -> 404, errors.Is(ErrUnauthorized) -> 401, then notFoundMessages exact match -> 404,
-// then legacy strings "current password is incorrect" / "Unauthorized" -> 401; otherwise 500.
+// Checks errors.Is(ErrNotFound) -> 404, errors.Is(ErrUnauthorized) -> 401,
+// errors.Is(ErrExternalService) -> 503 with CaseCodeExternalServiceError, then notFoundMessages exact match -> 404,
+// then legacy strings "current password is incorrect" / "Unauthorized" -> 401; otherwise 500. 503 and 500
+// errors are also reported to error reporting (logger.ReportError) with the caller as report location.
 func (c *BaseHandler) HandleServiceError(ctx *gin.Context, serviceCode string, err error, notFoundMessages ...string) bool {
 	if err == nil {
 		return false
@@ -105,6 +107,12 @@ func (c *BaseHandler) HandleServiceError(ctx *gin.Context, serviceCode string, e
 		response.UnauthorizedError(ctx, errMsg)
 		return true
 	}
+	if errors.Is(err, ErrExternalService) {
+		logger.Errorf("External service error: %s", errMsg)
+		logger.ReportError(ctx.Request.Context(), err, logger.ReportCallerSkip(1), logger.ReportStatus(http.StatusServiceUnavailable))
+		response.FailWithDetailed(ctx, http.StatusServiceUnavailable, serviceCode, response.CaseCodeExternalServiceError, nil, errMsg)
+		return true
+	}
 
 	for _, msg := range notFoundMessages {
 		if errMsg == msg {
@@ -121,6 +129,7 @@ func (c *BaseHandler) HandleServiceError(ctx *gin.Context, serviceCode string, e
 	}
 
 	logger.Errorf("Service error: %v", err)
+	logger.ReportError(ctx.Request.Context(), err, logger.ReportCallerSkip(1), logger.ReportStatus(http.StatusInternalServerError))
 	response.FailWithDetailed(ctx, http.StatusInternalServerError, serviceCode, response.CaseCodeInternalError, nil, errMsg)
 	return true
 }
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -356,6 +357,22 @@ func TestBaseController_HandleServiceError(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
+	// Test with sentinel ErrExternalService (e.g. httpclient.ErrCircuitOpen)
+	router.GET("/test-sentinel-external", func(c *gin.Context) {
+		err := fmt.Errorf("bank api: %w", ErrExternalService)
+		if handler.HandleServiceError(c, response.ServiceCodeBank, err) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	req = httptest.NewRequest("GET", "/test-sentinel-external", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var body response.CommonResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, response.BuildResponseCode(http.StatusServiceUnavailable, response.ServiceCodeBank, response.CaseCodeExternalServiceError), body.Code)
+
 	// Test with unauthorized error (legacy string)
 	router.GET("/test3", func(c *gin.Context) {
 		err := &testError{message: "current password is incorrect"}
@@ -372,6 +389,38 @@ func TestBaseController_HandleServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
+func TestBaseController_HandleServiceError_ReportsServerErrors(t *testing.T) {
+	var events []*logger.ErrorEvent
+	logger.Init(logger.Config{Writer: &bytes.Buffer{}, ErrorReporting: logger.ErrorReportingConfig{
+		Enabled: true,
+		Hooks: []logger.ReportHook{logger.ReportHookFunc(func(_ context.Context, ev *logger.ErrorEvent) {
+			events = append(events, ev)
+		})},
+	}})
+	defer logger.Init(logger.Config{})
+
+	router := setupRouter()
+	handler := &BaseHandler{}
+	router.GET("/:kind", func(c *gin.Context) {
+		errs := map[string]error{
+			"internal": errors.New("db down"),
+			"external": fmt.Errorf("bank api: %w", ErrExternalService),
+			"notfound": ErrNotFound,
+		}
+		handler.HandleServiceError(c, response.ServiceCodeCommon, errs[c.Param("kind")])
+	})
+
+	for _, kind := range []string{"internal", "external", "notfound"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/"+kind, nil))
+	}
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, http.StatusInternalServerError, events[0].Status)
+		assert.Equal(t, http.StatusServiceUnavailable, events[1].Status)
+		assert.Contains(t, events[0].Location.Function, "TestBaseController_HandleServiceError_ReportsServerErrors")
+	}
+}
+
 type testError struct {
 	message string
 }
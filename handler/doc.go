@@ -6,7 +6,7 @@ Role in architecture:
 
 Responsibilities:
   - Bind request body, query, and URI parameters by content-type and method.
-  - Map domain errors (ErrNotFound, ErrUnauthorized) and optional notFoundMessages to standardized JSON responses (response package).
+  - Map domain errors (ErrNotFound, ErrUnauthorized) and optional notFoundMessages to standardized JSON responses (response package); report 500 and 503 errors with logger.ReportError.
   - Normalize pagination parameters and build pagination responses.
   - Extract user ID and roles from context set by auth middleware.
 
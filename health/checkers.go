@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+
+	"cloud.google.com/go/storage"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/database"
+	"github.com/turahe/pkg/gcs"
+	"github.com/turahe/pkg/redis"
+	"github.com/turahe/pkg/s3"
+)
+
+// Database checks db with Database.Health (ping bounded by Options.PingTimeout and the check timeout).
+func Database(db *database.Database) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.Health(ctx)
+	}
+}
+
+// PrimaryDatabase checks the global primary database from database.Setup.
+func PrimaryDatabase() CheckFunc {
+	return func(ctx context.Context) error {
+		db := database.GetDatabase()
+		if db == nil {
+			return database.ErrNotInitialized
+		}
+		return db.Health(ctx)
+	}
+}
+
+// SiteDatabase checks the global site database from database.Setup.
+func SiteDatabase() CheckFunc {
+	return func(ctx context.Context) error {
+		db := database.GetDatabaseSite()
+		if db == nil {
+			return database.ErrNotInitialized
+		}
+		return db.Health(ctx)
+	}
+}
+
+// Redis pings the global client from redis.Setup (standalone or cluster).
+func Redis() CheckFunc {
+	return redis.HealthCheck
+}
+
+// RedisClient pings client (*redis.Client, *redis.ClusterClient, or any UniversalClient).
+func RedisClient(client goredis.UniversalClient) CheckFunc {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// GCS reads the attributes of the bucket configured for gcs.Setup.
+func GCS() CheckFunc {
+	return gcs.HealthCheck
+}
+
+// GCSBucket reads the attributes of bucket.
+func GCSBucket(bucket *storage.BucketHandle) CheckFunc {
+	return func(ctx context.Context) error {
+		_, err := bucket.Attrs(ctx)
+		return err
+	}
+}
+
+// S3 checks the bucket configured for s3.Setup.
+func S3() CheckFunc {
+	return s3.HealthCheck
+}
+
+// RegisterDefaults registers the built-in checks for the dependencies enabled in cfg: "database" (critical),
+// "database_site" when DatabaseSite.Dbname is set (critical), "redis" when Redis.Enabled (critical), and
+// "gcs" when GCS.Enabled (non-critical), and "s3" when S3.Enabled (non-critical).
+func RegisterDefaults(r *Registry, cfg *config.Configuration) error {
+	checks := []Check{{Name: "database", Func: PrimaryDatabase(), Critical: true}}
+	if cfg.DatabaseSite.Dbname != "" {
+		checks = append(checks, Check{Name: "database_site", Func: SiteDatabase(), Critical: true})
+	}
+	if cfg.Redis.Enabled {
+		checks = append(checks, Check{Name: "redis", Func: Redis(), Critical: true})
+	}
+	if cfg.GCS.Enabled {
+		checks = append(checks, Check{Name: "gcs", Func: GCS()})
+	}
+	if cfg.S3.Enabled {
+		checks = append(checks, Check{Name: "s3", Func: S3()})
+	}
+	for _, c := range checks {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
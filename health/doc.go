@@ -0,0 +1,28 @@
+/*
+Package health provides a registry of named dependency checks and Gin handlers for Kubernetes liveness
+(/livez) and readiness (/readyz) probes.
+
+Role in architecture:
+  - Adapter: aggregates infrastructure health (database, Redis, GCS, S3, partner APIs) for probes and load
+    balancers. No business logic.
+
+Responsibilities:
+  - Registry: named checks with per-check timeout and critical/non-critical classification.
+  - Run: execute checks concurrently; reuse results younger than CacheTTL so frequent probes don't hammer
+    dependencies; concurrent probes share one in-flight run per check.
+  - Aggregation: down when a critical check fails, degraded when only non-critical checks fail, otherwise up.
+  - Handlers: LivenessHandler (no dependency checks), ReadinessHandler (JSON Report; 503 when down or
+    shutting down; check errors are logged and answered as "check failed" unless Options.ExposeErrors),
+    Mount for GET /livez and /readyz.
+  - Built-in checkers: PrimaryDatabase, SiteDatabase, Database, Redis, RedisClient, GCS, GCSBucket, S3;
+    RegisterDefaults wires them from config.
+
+Constraints:
+  - A check that ignores ctx is abandoned at its timeout (reported as down); its goroutine finishes in the
+    background.
+  - SetShuttingDown(true) makes readiness fail immediately; use it at the start of graceful shutdown.
+
+This package must NOT:
+  - Restart or reconnect dependencies; it only reports.
+*/
+package health
@@ -0,0 +1,46 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LivenessHandler answers 200 {"status":"up"} while the process can serve HTTP. It runs no dependency checks:
+// restarting a pod because the database is down does not fix the database.
+func (r *Registry) LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, Report{Status: StatusUp})
+	}
+}
+
+// genericCheckError replaces check error messages in readiness responses unless Options.ExposeErrors is set.
+const genericCheckError = "check failed"
+
+// ReadinessHandler runs the checks and answers with the Report as JSON: 200 when up or degraded, 503 when a
+// critical check failed or the service is shutting down. Check errors are reported as "check failed" unless
+// Options.ExposeErrors is set.
+func (r *Registry) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Run(c.Request.Context())
+		status := http.StatusOK
+		if report.Status == StatusDown || report.Status == StatusShuttingDown {
+			status = http.StatusServiceUnavailable
+		}
+		if !r.opts.ExposeErrors {
+			for name, res := range report.Checks {
+				if res.Error != "" {
+					res.Error = genericCheckError
+					report.Checks[name] = res
+				}
+			}
+		}
+		c.JSON(status, report)
+	}
+}
+
+// Mount registers GET /livez and GET /readyz on routes.
+func (r *Registry) Mount(routes gin.IRoutes) {
+	routes.GET("/livez", r.LivenessHandler())
+	routes.GET("/readyz", r.ReadinessHandler())
+}
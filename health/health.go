@@ -0,0 +1,254 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/turahe/pkg/logger"
+)
+
+const (
+	defaultCacheTTL = time.Second
+	defaultTimeout  = 2 * time.Second
+)
+
+// Status is the outcome of a check or of a whole report.
+type Status string
+
+const (
+	// StatusUp means the check passed (report: every check passed).
+	StatusUp Status = "up"
+	// StatusDegraded means only non-critical checks failed (report only); readiness still answers 200.
+	StatusDegraded Status = "degraded"
+	// StatusDown means the check failed (report: at least one critical check failed).
+	StatusDown Status = "down"
+	// StatusShuttingDown means SetShuttingDown(true) was called (report only).
+	StatusShuttingDown Status = "shutting_down"
+)
+
+// CheckFunc checks one dependency; return nil when healthy. It must respect ctx cancellation.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency check.
+type Check struct {
+	// Name identifies the check in reports (e.g. "database", "redis"); must be unique.
+	Name string
+	// Func runs the check.
+	Func CheckFunc
+	// Timeout bounds one run; default Options.Timeout.
+	Timeout time.Duration
+	// Critical checks make readiness fail; non-critical failures only mark the report degraded.
+	Critical bool
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"` // e.g. "1.2ms"
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the aggregated outcome of all checks.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Options configures a Registry. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// CacheTTL is how long a check result is reused, so frequent probes don't hammer dependencies.
+	// Default 1s; negative disables caching.
+	CacheTTL time.Duration
+	// Timeout is the default per-check timeout; default 2s.
+	Timeout time.Duration
+	// ExposeErrors makes ReadinessHandler answer with each check's error message. Default false answers
+	// "check failed" instead, since driver errors can reveal hosts, ports, and user names to anyone who can
+	// reach the probe; failures are always logged with the full error.
+	ExposeErrors bool
+}
+
+func (o *Options) applyDefaults() {
+	if o.CacheTTL == 0 {
+		o.CacheTTL = defaultCacheTTL
+	} else if o.CacheTTL < 0 {
+		o.CacheTTL = 0
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+}
+
+// Option is a functional option applied to Options (e.g. WithCacheTTL).
+type Option func(*Options)
+
+// WithCacheTTL sets how long check results are cached; negative disables caching.
+func WithCacheTTL(d time.Duration) Option {
+	return func(o *Options) { o.CacheTTL = d }
+}
+
+// WithExposeErrors makes ReadinessHandler include check error messages in its response.
+func WithExposeErrors() Option {
+	return func(o *Options) { o.ExposeErrors = true }
+}
+
+// WithTimeout sets the default per-check timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) { o.Timeout = d }
+}
+
+// entry is a registered check with its cached result. mu serialises runs, so concurrent probes share one
+// in-flight run.
+type entry struct {
+	check  Check
+	mu     sync.Mutex
+	result Result
+	at     time.Time
+}
+
+// Registry holds named checks. Safe for concurrent use.
+type Registry struct {
+	opts         Options
+	mu           sync.RWMutex
+	entries      []*entry
+	shuttingDown atomic.Bool
+
+	now func() time.Time
+}
+
+// New creates a Registry from opts (zero values use defaults), then applies override options.
+func New(opts Options, override ...Option) *Registry {
+	for _, o := range override {
+		o(&opts)
+	}
+	opts.applyDefaults()
+	return &Registry{opts: opts, now: time.Now}
+}
+
+// Register adds a check. Returns an error if the name is empty or already registered, or Func is nil.
+func (r *Registry) Register(c Check) error {
+	if c.Name == "" || c.Func == nil {
+		return errors.New("health: check requires Name and Func")
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = r.opts.Timeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.check.Name == c.Name {
+			return fmt.Errorf("health: check %q already registered", c.Name)
+		}
+	}
+	r.entries = append(r.entries, &entry{check: c})
+	return nil
+}
+
+// SetShuttingDown marks the service as draining: readiness answers 503 without running checks.
+// Call at the start of graceful shutdown so load balancers stop routing new traffic.
+func (r *Registry) SetShuttingDown(v bool) {
+	r.shuttingDown.Store(v)
+}
+
+// Run executes all checks concurrently (reusing results younger than CacheTTL) and aggregates them:
+// down if any critical check failed, degraded if only non-critical checks failed, otherwise up.
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+	r.mu.RLock()
+	entries := make([]*entry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.runEntry(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(entries))}
+	for i, e := range entries {
+		res := results[i]
+		report.Checks[e.check.Name] = res
+		if res.Status == StatusDown {
+			if e.check.Critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+	return report
+}
+
+// Names returns the registered check names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		names = append(names, e.check.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) runEntry(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.at.IsZero() && r.now().Sub(e.at) < r.opts.CacheTTL {
+		return e.result
+	}
+	start := r.now()
+	err := runWithTimeout(ctx, e.check.Func, e.check.Timeout)
+	res := Result{
+		Status:    StatusUp,
+		Critical:  e.check.Critical,
+		Duration:  r.now().Sub(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+		logger.WarnfContext(ctx, "health: check %s failed: %v", e.check.Name, err)
+	}
+	// Don't cache a result caused by the caller giving up; the next probe should re-run the check.
+	if ctx.Err() == nil {
+		e.result, e.at = res, start
+	}
+	return res
+}
+
+// runWithTimeout runs fn with a deadline and returns when either fn finishes or the deadline passes, so a check
+// that ignores ctx cannot block the probe. Panics are reported as errors.
+func runWithTimeout(ctx context.Context, fn CheckFunc, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timeout after %s: %w", timeout, ctx.Err())
+	}
+}
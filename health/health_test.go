@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turahe/pkg/config"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestRegistry_Register(t *testing.T) {
+	r := New(Options{})
+	require.NoError(t, r.Register(Check{Name: "a", Func: ok}))
+	assert.Error(t, r.Register(Check{Name: "a", Func: ok}), "duplicate name")
+	assert.Error(t, r.Register(Check{Name: "", Func: ok}))
+	assert.Error(t, r.Register(Check{Name: "b"}))
+	assert.Equal(t, []string{"a"}, r.Names())
+}
+
+func TestRegistry_Run_Aggregation(t *testing.T) {
+	failing := func(context.Context) error { return errors.New("boom") }
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{"all up", []Check{{Name: "db", Func: ok, Critical: true}, {Name: "gcs", Func: ok}}, StatusUp},
+		{"non-critical down", []Check{{Name: "db", Func: ok, Critical: true}, {Name: "gcs", Func: failing}}, StatusDegraded},
+		{"critical down", []Check{{Name: "db", Func: failing, Critical: true}, {Name: "gcs", Func: failing}}, StatusDown},
+		{"no checks", nil, StatusUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(Options{})
+			for _, c := range tt.checks {
+				require.NoError(t, r.Register(c))
+			}
+			report := r.Run(context.Background())
+			assert.Equal(t, tt.want, report.Status)
+			assert.Len(t, report.Checks, len(tt.checks))
+		})
+	}
+}
+
+func TestRegistry_Run_TimeoutAndPanic(t *testing.T) {
+	r := New(Options{}, WithTimeout(20*time.Millisecond))
+	require.NoError(t, r.Register(Check{Name: "stuck", Func: func(context.Context) error {
+		time.Sleep(time.Second) // ignores ctx
+		return nil
+	}}))
+	require.NoError(t, r.Register(Check{Name: "panics", Func: func(context.Context) error { panic("bad") }}))
+
+	start := time.Now()
+	report := r.Run(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusDown, report.Checks["stuck"].Status)
+	assert.Contains(t, report.Checks["stuck"].Error, "timeout")
+	assert.Contains(t, report.Checks["panics"].Error, "panic: bad")
+}
+
+func TestRegistry_Run_Cache(t *testing.T) {
+	var calls atomic.Int32
+	now := time.Unix(1000, 0)
+	r := New(Options{CacheTTL: time.Second})
+	r.now = func() time.Time { return now }
+	require.NoError(t, r.Register(Check{Name: "db", Func: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}}))
+
+	r.Run(context.Background())
+	r.Run(context.Background())
+	assert.Equal(t, int32(1), calls.Load(), "second run within TTL is cached")
+
+	now = now.Add(time.Second)
+	r.Run(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := New(Options{CacheTTL: -1})
+	healthy := true
+	require.NoError(t, r.Register(Check{Name: "db", Critical: true, Func: func(context.Context) error {
+		if !healthy {
+			return errors.New("connection refused")
+		}
+		return nil
+	}}))
+	router := gin.New()
+	r.Mount(router)
+
+	get := func(path string) (int, Report) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		var rep Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep))
+		return w.Code, rep
+	}
+
+	code, rep := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusUp, rep.Checks["db"].Status)
+
+	healthy = false
+	code, rep = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "check failed", rep.Checks["db"].Error, "driver errors are not exposed by default")
+
+	code, rep = get("/livez")
+	assert.Equal(t, http.StatusOK, code, "liveness ignores dependencies")
+	assert.Equal(t, StatusUp, rep.Status)
+
+	healthy = true
+	r.SetShuttingDown(true)
+	code, rep = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusShuttingDown, rep.Status)
+}
+
+func TestReadinessHandler_ExposeErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := New(Options{CacheTTL: -1}, WithExposeErrors())
+	require.NoError(t, r.Register(Check{Name: "db", Critical: true, Func: func(context.Context) error {
+		return errors.New("connection refused")
+	}}))
+	router := gin.New()
+	r.Mount(router)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+	router.ServeHTTP(w, req)
+	var rep Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rep))
+	assert.Equal(t, "connection refused", rep.Checks["db"].Error)
+	assert.Equal(t, "connection refused", r.Run(context.Background()).Checks["db"].Error, "Run keeps the error")
+}
+
+func TestRegisterDefaults(t *testing.T) {
+	r := New(Options{})
+	cfg := &config.Configuration{
+		DatabaseSite: config.DatabaseConfiguration{Dbname: "site"},
+		Redis:        config.RedisConfiguration{Enabled: true},
+		S3:           config.S3Configuration{Enabled: true},
+	}
+	require.NoError(t, RegisterDefaults(r, cfg))
+	assert.Equal(t, []string{"database", "database_site", "redis", "s3"}, r.Names())
+
+	report := r.Run(context.Background())
+	assert.Equal(t, StatusDown, report.Status, "nothing is initialized")
+}
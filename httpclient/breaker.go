@@ -0,0 +1,126 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/turahe/pkg/domain"
+)
+
+// ErrCircuitOpen is returned (wrapped with the host) when a host's circuit is open. It wraps
+// domain.ErrExternalService, so errors.Is(err, domain.ErrExternalService) is true.
+var ErrCircuitOpen = fmt.Errorf("circuit open: %w", domain.ErrExternalService)
+
+// IsCircuitOpen reports whether err was caused by an open circuit.
+func IsCircuitOpen(err error) bool {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateHalfOpen
+	stateOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a consecutive-failure circuit breaker for one host.
+// closed -> open after threshold failures; open -> half-open after cooldown (one probe allowed);
+// half-open -> closed on probe success, -> open on probe failure.
+type breaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+}
+
+// allow reports whether a request may proceed and returns the state it was admitted (or rejected) in.
+func (b *breaker) allow(now time.Time) (bool, breakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case stateOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false, stateOpen
+		}
+		b.state = stateHalfOpen
+		b.probing = true
+		return true, stateHalfOpen
+	case stateHalfOpen:
+		if b.probing {
+			return false, stateHalfOpen
+		}
+		b.probing = true
+		return true, stateHalfOpen
+	default:
+		return true, stateClosed
+	}
+}
+
+// record updates the breaker with the outcome of an admitted request and returns the new state.
+func (b *breaker) record(success bool, now time.Time) breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if success {
+		b.state = stateClosed
+		b.failures = 0
+		return b.state
+	}
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openedAt = now
+	}
+	return b.state
+}
+
+// release frees a half-open probe slot without recording an outcome (e.g. the caller cancelled).
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// breakerSet holds one breaker per host.
+type breakerSet struct {
+	mu        sync.Mutex
+	breakers  map[string]*breaker
+	threshold int
+	cooldown  time.Duration
+}
+
+func newBreakerSet(threshold int, cooldown time.Duration) *breakerSet {
+	return &breakerSet{breakers: make(map[string]*breaker), threshold: threshold, cooldown: cooldown}
+}
+
+// get returns the breaker for host, or nil when the breaker is disabled (threshold < 0).
+func (s *breakerSet) get(host string) *breaker {
+	if s.threshold < 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[host]
+	if !ok {
+		b = &breaker{threshold: s.threshold, cooldown: s.cooldown}
+		s.breakers[host] = b
+	}
+	return b
+}
@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Transitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := &breaker{threshold: 2, cooldown: time.Minute}
+
+	ok, _ := b.allow(now)
+	assert.True(t, ok)
+	assert.Equal(t, stateClosed, b.record(false, now))
+	assert.Equal(t, stateOpen, b.record(false, now))
+
+	ok, state := b.allow(now.Add(30 * time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, stateOpen, state)
+
+	ok, state = b.allow(now.Add(time.Minute))
+	assert.True(t, ok, "probe allowed after cooldown")
+	assert.Equal(t, stateHalfOpen, state)
+	ok, _ = b.allow(now.Add(time.Minute))
+	assert.False(t, ok, "only one probe while half-open")
+
+	assert.Equal(t, stateOpen, b.record(false, now.Add(time.Minute)), "failed probe reopens")
+	ok, _ = b.allow(now.Add(90 * time.Second))
+	assert.False(t, ok, "cooldown restarts from the failed probe")
+
+	ok, _ = b.allow(now.Add(2 * time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, stateClosed, b.record(true, now.Add(2*time.Minute)))
+	assert.Equal(t, 0, b.failures)
+}
+
+func TestBreaker_ReleaseFreesProbe(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := &breaker{threshold: 1, cooldown: time.Second}
+	b.record(false, now)
+
+	ok, _ := b.allow(now.Add(time.Second))
+	assert.True(t, ok)
+	b.release()
+	ok, state := b.allow(now.Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, stateHalfOpen, state)
+}
+
+func TestBreakerSet_Disabled(t *testing.T) {
+	assert.Nil(t, newBreakerSet(-1, time.Second).get("example.com"))
+	s := newBreakerSet(3, time.Second)
+	assert.Same(t, s.get("a"), s.get("a"))
+	assert.NotSame(t, s.get("a"), s.get("b"))
+}
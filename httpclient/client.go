@@ -0,0 +1,312 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/tracing"
+)
+
+// Request headers set by the client. The trace and correlation headers match those read by package
+// middlewares.
+const (
+	// headerIdempotencyKey marks a non-idempotent request (e.g. POST) as safe to retry.
+	headerIdempotencyKey = "Idempotency-Key"
+	headerTraceID        = "X-Trace-Id"
+	headerCorrelationID  = "X-Correlation-Id"
+)
+
+// maxDrainBytes bounds how much of a discarded response body is read so the connection can be reused.
+const maxDrainBytes = 64 << 10
+
+// Client is an instrumented HTTP client. Safe for concurrent use; create one per partner and reuse it.
+type Client struct {
+	opts     Options
+	base     http.RoundTripper
+	breakers *breakerSet
+	metrics  *clientMetrics
+	http     *http.Client
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New builds a Client from opts (zero values use defaults), then applies override options. Its metrics are
+// registered on Options.Registerer; if that fails (a conflicting collector), the error is logged and the
+// client works without exporting them.
+func New(opts Options, override ...Option) *Client {
+	for _, o := range override {
+		o(&opts)
+	}
+	opts.applyDefaults()
+	metrics, err := newClientMetrics(opts.Registerer)
+	if err != nil {
+		logger.Errorf("httpclient %s: failed to register metrics: %v", opts.Name, err)
+	}
+	c := &Client{
+		opts:     opts,
+		base:     tracing.NewTransport(opts.Transport),
+		breakers: newBreakerSet(opts.BreakerThreshold, opts.BreakerCooldown),
+		metrics:  metrics,
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+	c.http = &http.Client{Transport: (*roundTripper)(c)}
+	return c
+}
+
+// HTTPClient returns an *http.Client backed by this Client, for SDKs that accept one. It has no
+// client-level Timeout; Options.Timeout is applied per attempt.
+func (c *Client) HTTPClient() *http.Client {
+	return c.http
+}
+
+// Do sends req. Use http.NewRequestWithContext so the call carries the request's trace and deadline.
+// Non-2xx responses are not errors; the caller must close resp.Body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.http.Do(req)
+}
+
+// Get issues a GET to url with ctx.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// roundTripper is the http.RoundTripper view of Client; it runs breaker, retries, metrics, and logging
+// for each request (each redirect hop is a separate call).
+type roundTripper Client
+
+// RoundTrip implements http.RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := (*Client)(rt)
+	ctx := req.Context()
+	host := req.URL.Host
+	start := c.now()
+
+	b := c.breakers.get(host)
+	if b != nil {
+		ok, state := b.allow(start)
+		c.setCircuitState(host, state)
+		if !ok {
+			if req.Body != nil {
+				_ = req.Body.Close()
+			}
+			err := fmt.Errorf("httpclient %s: %s: %w", c.opts.Name, host, ErrCircuitOpen)
+			c.metrics.requestsTotal.WithLabelValues(c.opts.Name, host, req.Method, statusCircuitOpen).Inc()
+			c.logCall(req, nil, err, 0, 0)
+			return nil, err
+		}
+	}
+
+	retryable := c.opts.MaxRetries > 0 && isRetryableRequest(req)
+	var (
+		resp     *http.Response
+		err      error
+		attempts int
+	)
+	for {
+		attempts++
+		resp, err = c.attempt(req, attempts)
+		if !retryable || attempts > c.opts.MaxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			break
+		}
+		delay := c.backoff(attempts, resp)
+		if resp != nil {
+			drainAndClose(resp.Body)
+			resp = nil
+		}
+		c.metrics.retriesTotal.WithLabelValues(c.opts.Name, host).Inc()
+		if err = c.sleep(ctx, delay); err != nil {
+			break
+		}
+	}
+
+	if b != nil {
+		if err != nil && ctx.Err() != nil {
+			// Cancelled by the caller: says nothing about the host.
+			b.release()
+		} else {
+			c.setCircuitState(host, b.record(err == nil && resp.StatusCode < http.StatusInternalServerError, c.now()))
+		}
+	}
+
+	elapsed := c.now().Sub(start)
+	status := statusTransportError
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	c.metrics.requestsTotal.WithLabelValues(c.opts.Name, host, req.Method, status).Inc()
+	c.metrics.requestDuration.WithLabelValues(c.opts.Name, host, req.Method).Observe(elapsed.Seconds())
+	c.logCall(req, resp, err, attempts, elapsed)
+	return resp, err
+}
+
+// attempt sends one try of req with the per-attempt timeout. The timeout's cancel runs when the returned
+// body is closed, so it also bounds reading the body.
+func (c *Client) attempt(req *http.Request, n int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.opts.Timeout)
+	out := req.Clone(ctx)
+	if n > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		out.Body = body
+	}
+	if out.Header.Get(headerTraceID) == "" {
+		if id := logger.GetTraceID(ctx); id != "" {
+			out.Header.Set(headerTraceID, id)
+		}
+	}
+	if out.Header.Get(headerCorrelationID) == "" {
+		if id := logger.GetCorrelationID(ctx); id != "" {
+			out.Header.Set(headerCorrelationID, id)
+		}
+	}
+
+	resp, err := c.base.RoundTrip(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns the delay before retry n: full-jitter exponential backoff, or Retry-After when the
+// server sent one, both capped at RetryMaxDelay.
+func (c *Client) backoff(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.now()); ok {
+			return min(d, c.opts.RetryMaxDelay)
+		}
+	}
+	d := c.opts.RetryBaseDelay << (n - 1)
+	if d <= 0 || d > c.opts.RetryMaxDelay {
+		d = c.opts.RetryMaxDelay
+	}
+	return rand.N(d) + 1
+}
+
+func (c *Client) setCircuitState(host string, s breakerState) {
+	c.metrics.circuitState.WithLabelValues(c.opts.Name, host).Set(float64(s))
+}
+
+// logCall writes one entry per logical call: Error for transport failures and 5xx, Warn for 4xx,
+// otherwise Info.
+func (c *Client) logCall(req *http.Request, resp *http.Response, err error, attempts int, elapsed time.Duration) {
+	if c.opts.DisableLogging {
+		return
+	}
+	hr := &logger.HTTPRequest{
+		RequestMethod: req.Method,
+		// Query strings are omitted: they often carry tokens or signatures.
+		RequestURL: req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		Latency:    strconv.FormatFloat(elapsed.Seconds(), 'f', -1, 64) + "s",
+	}
+	if req.ContentLength > 0 {
+		hr.RequestSize = req.ContentLength
+	}
+	fields := logger.Fields{"client": c.opts.Name, "attempts": attempts}
+	log := logger.WithContext(logger.WithHTTPRequest(req.Context(), hr))
+	msg := "outbound " + req.Method + " " + req.URL.Host + req.URL.Path
+	if err != nil {
+		fields["error"] = err.Error()
+		log.Error(msg, fields)
+		return
+	}
+	hr.Status = resp.StatusCode
+	if resp.ContentLength > 0 {
+		hr.ResponseSize = resp.ContentLength
+	}
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		log.Error(msg, fields)
+	case resp.StatusCode >= http.StatusBadRequest:
+		log.Warn(msg, fields)
+	default:
+		log.Info(msg, fields)
+	}
+}
+
+// isRetryableRequest reports whether req may be sent more than once: an idempotent method or an
+// Idempotency-Key header, and a body that is absent or replayable.
+func isRetryableRequest(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(headerIdempotencyKey) != ""
+}
+
+// shouldRetry reports whether an attempt's outcome is transient.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After value in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	_ = body.Close()
+}
+
+// cancelOnClose releases the per-attempt timeout when the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
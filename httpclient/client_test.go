@@ -0,0 +1,208 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turahe/pkg/domain"
+	"github.com/turahe/pkg/logger"
+)
+
+// newTestClient returns a Client whose backoff sleeps are recorded instead of waited.
+func newTestClient(t *testing.T, opts Options, override ...Option) (*Client, *[]time.Duration) {
+	t.Helper()
+	opts.DisableLogging = true
+	c := New(opts, override...)
+	var slept []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+	return c, &slept
+}
+
+func TestClient_PropagatesTraceHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, Options{Name: "propagation"})
+	ctx := logger.WithTraceID(context.Background(), "trace-123")
+	ctx = logger.WithCorrelationID(ctx, "corr-456")
+	resp, err := c.Get(ctx, srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "trace-123", got.Get("X-Trace-Id"))
+	assert.Equal(t, "corr-456", got.Get("X-Correlation-Id"))
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, slept := newTestClient(t, Options{Name: "retry-get", RetryMaxDelay: 500 * time.Millisecond})
+	resp, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, *slept, "Retry-After capped at RetryMaxDelay")
+	host := strings.TrimPrefix(srv.URL, "http://")
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.metrics.retriesTotal.WithLabelValues("retry-get", host)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.metrics.requestsTotal.WithLabelValues("retry-get", host, "GET", "200")))
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	t.Run("POST is not retried", func(t *testing.T) {
+		calls.Store(0)
+		c, _ := newTestClient(t, Options{Name: "retry-post"})
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("POST with Idempotency-Key replays body", func(t *testing.T) {
+		calls.Store(0)
+		bodies = nil
+		c, _ := newTestClient(t, Options{Name: "retry-key"}, WithMaxRetries(1))
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+		req.Header.Set("Idempotency-Key", "k1")
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, []string{"payload", "payload"}, bodies)
+	})
+
+	t.Run("negative MaxRetries disables retries", func(t *testing.T) {
+		calls.Store(0)
+		c, _ := newTestClient(t, Options{Name: "retry-off", MaxRetries: -1})
+		resp, err := c.Get(context.Background(), srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestClient_PerAttemptTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, Options{Name: "timeout", Timeout: 20 * time.Millisecond, MaxRetries: -1})
+	_, err := c.Get(context.Background(), srv.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1000, 0)
+	c, _ := newTestClient(t, Options{Name: "breaker", MaxRetries: -1}, WithBreaker(2, time.Minute))
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Get(context.Background(), srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	_, err := c.Get(context.Background(), srv.URL)
+	require.Error(t, err)
+	assert.True(t, IsCircuitOpen(err))
+	assert.ErrorIs(t, err, domain.ErrExternalService)
+	assert.Equal(t, int32(2), calls.Load(), "open circuit must not reach the server")
+
+	now = now.Add(time.Minute)
+	resp, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err, "half-open probe is sent")
+	resp.Body.Close()
+	assert.Equal(t, int32(3), calls.Load())
+	_, err = c.Get(context.Background(), srv.URL)
+	assert.True(t, IsCircuitOpen(err), "failed probe reopens the circuit")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(5*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+
+	for _, v := range []string{"", "-1", "soon"} {
+		_, ok = parseRetryAfter(v, now)
+		assert.False(t, ok, v)
+	}
+}
+
+func TestClient_Registerer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	reg := prometheus.NewRegistry()
+	a, _ := newTestClient(t, Options{Name: "a", Registerer: reg})
+	b, _ := newTestClient(t, Options{Name: "b"}, WithRegisterer(reg))
+	for _, c := range []*Client{a, b} {
+		resp, err := c.Get(context.Background(), srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	n, err := testutil.GatherAndCount(reg, "http_client_requests_total")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "clients on one registry share the collectors")
+
+	conflicting := prometheus.NewRegistry()
+	conflicting.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "http_client_requests_total", Help: "other"}))
+	c, _ := newTestClient(t, Options{Registerer: conflicting})
+	resp, err := c.Get(context.Background(), srv.URL)
+	require.NoError(t, err, "a registration conflict does not break the client")
+	resp.Body.Close()
+}
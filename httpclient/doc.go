@@ -0,0 +1,34 @@
+/*
+Package httpclient provides an instrumented outbound HTTP client for partner APIs (banks, notification
+providers): trace/correlation propagation, structured call logs, Prometheus metrics, retries with jittered
+backoff, and a per-host circuit breaker.
+
+Role in architecture:
+  - Infrastructure adapter: use-case adapters call partner APIs through Client (or Client.HTTPClient() for
+    third-party SDKs that accept an *http.Client). No business logic.
+
+Responsibilities:
+  - Propagate X-Trace-Id and X-Correlation-Id from the logger context, plus W3C traceparent/tracestate
+    (via tracing.NewTransport), unless the caller already set them.
+  - Log one entry per call with a GCP httpRequest (logger.HTTPRequest): 5xx/transport error Error, 4xx Warn,
+    otherwise Info. Query strings are never logged.
+  - Record http_client_* metrics per client name, host, method, and status, on Options.Registerer.
+  - Retry transport errors and 429/502/503/504 for idempotent methods (GET, HEAD, OPTIONS, TRACE, PUT,
+    DELETE) or requests carrying an Idempotency-Key header, with full-jitter exponential backoff that
+    honours Retry-After. Requests with a body are retried only when it can be replayed (req.GetBody).
+  - Per-host circuit breaker: opens after consecutive failures (transport error or 5xx), rejects calls with
+    ErrCircuitOpen while open, and lets a single probe through after the cooldown (half-open).
+
+Error mapping: ErrCircuitOpen wraps domain.ErrExternalService, so handler.BaseHandler.HandleServiceError
+answers 503 with response.CaseCodeExternalServiceError.
+
+Constraints:
+  - Non-2xx responses are returned as responses, not errors (net/http semantics); only transport failures,
+    timeouts, and an open circuit return an error.
+  - Timeout applies per attempt; the request context bounds the whole call including backoff.
+  - httpRequest is included in the log entry only when logger.Config.EnableHTTPLogging is true.
+
+This package must NOT:
+  - Contain partner-specific request building or response parsing; that belongs in the calling adapter.
+*/
+package httpclient
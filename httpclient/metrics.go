@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Status label values used instead of a response code.
+const (
+	statusTransportError = "error"
+	statusCircuitOpen    = "circuit_open"
+)
+
+// clientMetrics holds the collectors of one registry; clients registering on the same registry share them.
+type clientMetrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	retriesTotal    *prometheus.CounterVec
+	circuitState    *prometheus.GaugeVec
+}
+
+// newClientMetrics creates the http_client_* collectors and registers them on reg, reusing collectors
+// already registered there. On error the returned collectors still work but are not exported.
+func newClientMetrics(reg prometheus.Registerer) (*clientMetrics, error) {
+	m := &clientMetrics{
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_requests_total",
+			Help: "Total number of outbound HTTP calls (after retries).",
+		}, []string{"client", "host", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_client_request_duration_seconds",
+			Help:    "Duration of outbound HTTP calls in seconds, including retries and backoff.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"client", "host", "method"}),
+		retriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_retries_total",
+			Help: "Total number of outbound HTTP retry attempts.",
+		}, []string{"client", "host"}),
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_client_circuit_state",
+			Help: "Circuit breaker state per host: 0 closed, 1 half-open, 2 open.",
+		}, []string{"client", "host"}),
+	}
+	var errs []error
+	var err error
+	m.requestsTotal, err = registerCollector(reg, m.requestsTotal)
+	errs = append(errs, err)
+	m.requestDuration, err = registerCollector(reg, m.requestDuration)
+	errs = append(errs, err)
+	m.retriesTotal, err = registerCollector(reg, m.retriesTotal)
+	errs = append(errs, err)
+	m.circuitState, err = registerCollector(reg, m.circuitState)
+	errs = append(errs, err)
+	return m, errors.Join(errs...)
+}
+
+// registerCollector registers c, or returns the already registered collector with the same descriptor.
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
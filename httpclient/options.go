@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxRetries       = 2
+	defaultRetryBaseDelay   = 100 * time.Millisecond
+	defaultRetryMaxDelay    = 2 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// Options configures a Client. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Name labels metrics and logs (e.g. "bank-bca", "sendgrid"); default "default".
+	Name string
+	// Timeout bounds each attempt (connect, headers, and body read); default 10s.
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt for retryable requests; 0 = default 2,
+	// negative disables retries.
+	MaxRetries int
+	// RetryBaseDelay is the initial backoff; doubled per retry with full jitter. Default 100ms.
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps each backoff, including a server-supplied Retry-After. Default 2s.
+	RetryMaxDelay time.Duration
+	// BreakerThreshold is the number of consecutive failures that opens a host's circuit; 0 = default 5,
+	// negative disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long a circuit stays open before a half-open probe; default 30s.
+	BreakerCooldown time.Duration
+	// Transport is the underlying round tripper; default http.DefaultTransport.
+	Transport http.RoundTripper
+	// DisableLogging turns off the per-call log entry (metrics are still recorded).
+	DisableLogging bool
+	// Registerer receives the http_client_* collectors; default prometheus.DefaultRegisterer. Clients on the
+	// same registry share them, distinguished by the client label.
+	Registerer prometheus.Registerer
+}
+
+func (o *Options) applyDefaults() {
+	if o.Name == "" {
+		o.Name = "default"
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = defaultMaxRetries
+	} else if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	}
+	if o.RetryBaseDelay <= 0 {
+		o.RetryBaseDelay = defaultRetryBaseDelay
+	}
+	if o.RetryMaxDelay <= 0 {
+		o.RetryMaxDelay = defaultRetryMaxDelay
+	}
+	if o.BreakerThreshold == 0 {
+		o.BreakerThreshold = defaultBreakerThreshold
+	}
+	if o.BreakerCooldown <= 0 {
+		o.BreakerCooldown = defaultBreakerCooldown
+	}
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+	if o.Registerer == nil {
+		o.Registerer = prometheus.DefaultRegisterer
+	}
+}
+
+// Option is a functional option applied to Options (e.g. WithName, WithMaxRetries).
+type Option func(*Options)
+
+// WithName sets the client name used in metric labels and logs.
+func WithName(name string) Option {
+	return func(o *Options) { o.Name = name }
+}
+
+// WithTimeout sets the per-attempt timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *Options) { o.Timeout = d }
+}
+
+// WithMaxRetries sets the number of retries after the first attempt; negative disables retries.
+func WithMaxRetries(n int) Option {
+	return func(o *Options) { o.MaxRetries = n }
+}
+
+// WithBreaker sets the consecutive-failure threshold and open-state cooldown of the per-host circuit breaker.
+func WithBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *Options) {
+		o.BreakerThreshold = threshold
+		o.BreakerCooldown = cooldown
+	}
+}
+
+// WithRegisterer sets the registry receiving the client's metrics.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(o *Options) { o.Registerer = reg }
+}
+
+// WithTransport sets the underlying round tripper (e.g. one with custom TLS or proxy settings).
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *Options) { o.Transport = rt }
+}
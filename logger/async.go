@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy is what the async writer does when its buffer is full (AsyncConfig.Policy).
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the logging goroutine wait for space (default; no entries are lost).
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropLowest evicts the lowest-severity queued entry when it is below the new entry's level;
+	// otherwise the new entry is dropped. Errors survive bursts of debug and info output.
+	OverflowDropLowest OverflowPolicy = "drop_lowest"
+	// OverflowDrop drops the new entry.
+	OverflowDrop OverflowPolicy = "drop"
+)
+
+const (
+	defaultAsyncBufferSize = 1024
+	// fatalFlushTimeout bounds the flush in Fatalf before the process exits.
+	fatalFlushTimeout = 5 * time.Second
+)
+
+// AsyncConfig configures asynchronous writing (Config.Async). Entries are encoded on the logging goroutine
+// and written by a background goroutine, so a slow destination does not add request latency.
+type AsyncConfig struct {
+	// Enabled turns on the async writer.
+	Enabled bool
+	// BufferSize is the queue capacity in entries; default 1024.
+	BufferSize int
+	// Policy when the queue is full; default OverflowBlock.
+	Policy OverflowPolicy
+}
+
+// levelWriter is implemented by writers that need the entry level (the async writer's overflow policy).
+type levelWriter interface {
+	writeLevel(level slog.Level, p []byte) error
+}
+
+type asyncEntry struct {
+	level slog.Level
+	data  []byte
+}
+
+// asyncWriter queues encoded entries in a bounded ring buffer drained by one background goroutine.
+type asyncWriter struct {
+	out    io.Writer
+	policy OverflowPolicy
+
+	mu      sync.Mutex
+	space   *sync.Cond // signalled when the flusher frees space
+	ring    []asyncEntry
+	head    int
+	n       int
+	writing bool
+	closed  bool
+	idle    chan struct{} // closed when the queue drains; created by Flush
+	notify  chan struct{}
+	done    chan struct{}
+}
+
+func newAsyncWriter(out io.Writer, cfg AsyncConfig) *asyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultAsyncBufferSize
+	}
+	if cfg.Policy == "" {
+		cfg.Policy = OverflowBlock
+	}
+	w := &asyncWriter{
+		out:    out,
+		policy: cfg.Policy,
+		ring:   make([]asyncEntry, cfg.BufferSize),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	w.space = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write queues p at Info level (for callers such as the GORM logger that write through GetWriter).
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	if err := w.writeLevel(slog.LevelInfo, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *asyncWriter) writeLevel(level slog.Level, p []byte) error {
+	data := append([]byte(nil), p...)
+	w.mu.Lock()
+	if w.closed {
+		// Loggers created before Init replaced this writer keep working, synchronously.
+		w.mu.Unlock()
+		_, err := w.out.Write(data)
+		return err
+	}
+	for w.n == len(w.ring) {
+		switch w.policy {
+		case OverflowDrop:
+			w.mu.Unlock()
+			recordDropped(level, dropReasonFull)
+			return nil
+		case OverflowDropLowest:
+			if !w.evictBelow(level) {
+				w.mu.Unlock()
+				recordDropped(level, dropReasonFull)
+				return nil
+			}
+		default:
+			w.space.Wait()
+			if w.closed {
+				w.mu.Unlock()
+				_, err := w.out.Write(data)
+				return err
+			}
+		}
+	}
+	w.ring[(w.head+w.n)%len(w.ring)] = asyncEntry{level: level, data: data}
+	w.n++
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evictBelow removes the oldest queued entry with the lowest level if that level is below level, keeping
+// the order of the rest. Caller holds mu.
+func (w *asyncWriter) evictBelow(level slog.Level) bool {
+	victim := -1
+	for i := 0; i < w.n; i++ {
+		e := w.ring[(w.head+i)%len(w.ring)]
+		if e.level < level && (victim < 0 || e.level < w.ring[(w.head+victim)%len(w.ring)].level) {
+			victim = i
+		}
+	}
+	if victim < 0 {
+		return false
+	}
+	recordDropped(w.ring[(w.head+victim)%len(w.ring)].level, dropReasonEvicted)
+	for i := victim; i < w.n-1; i++ {
+		w.ring[(w.head+i)%len(w.ring)] = w.ring[(w.head+i+1)%len(w.ring)]
+	}
+	w.n--
+	w.ring[(w.head+w.n)%len(w.ring)] = asyncEntry{}
+	return true
+}
+
+// run writes queued entries in batches until Close.
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	var batch bytes.Buffer
+	for {
+		w.mu.Lock()
+		for w.n > 0 {
+			e := w.ring[w.head]
+			w.ring[w.head] = asyncEntry{}
+			w.head = (w.head + 1) % len(w.ring)
+			w.n--
+			batch.Write(e.data)
+		}
+		closed := w.closed
+		w.writing = batch.Len() > 0
+		w.space.Broadcast()
+		w.mu.Unlock()
+
+		if batch.Len() > 0 {
+			_, _ = w.out.Write(batch.Bytes())
+			batch.Reset()
+			w.mu.Lock()
+			w.writing = false
+			w.mu.Unlock()
+			continue // pick up entries queued during the write
+		}
+		w.mu.Lock()
+		if w.idle != nil {
+			close(w.idle)
+			w.idle = nil
+		}
+		w.mu.Unlock()
+		if closed {
+			return
+		}
+		<-w.notify
+	}
+}
+
+// Flush waits until every entry queued so far has been written, or ctx is done.
+func (w *asyncWriter) Flush(ctx context.Context) error {
+	for {
+		w.mu.Lock()
+		if w.n == 0 && !w.writing {
+			w.mu.Unlock()
+			return nil
+		}
+		if w.idle == nil {
+			w.idle = make(chan struct{})
+		}
+		idle := w.idle
+		w.mu.Unlock()
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close drains the queue and stops the background goroutine; later writes go straight to the destination.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.space.Broadcast()
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+	<-w.done
+	return nil
+}
+
+// Flush waits until entries queued by the async writer (Config.Async) have been written, or ctx is done.
+// Call it during shutdown after the last log line; it returns immediately when logging is synchronous.
+func Flush(ctx context.Context) error {
+	if w, ok := cachedWriter.(*asyncWriter); ok {
+		return w.Flush(ctx)
+	}
+	return nil
+}
+
+// flushBeforeExit flushes queued entries with a bounded wait (Fatalf).
+func flushBeforeExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	defer cancel()
+	_ = Flush(ctx)
+}
@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gatedWriter blocks writes until release is closed and records what was written.
+type gatedWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func newGatedWriter() *gatedWriter { return &gatedWriter{release: make(chan struct{})} }
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// fill queues lines until the flusher is blocked in Write and the buffer is full.
+func fill(t *testing.T, w *asyncWriter, level slog.Level, lines ...string) {
+	t.Helper()
+	for _, l := range lines {
+		if err := w.writeLevel(level, []byte(l)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func waitWriting(t *testing.T, w *asyncWriter) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		w.mu.Lock()
+		writing := w.writing
+		w.mu.Unlock()
+		if writing {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("flusher did not start writing")
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	out := newGatedWriter()
+	w := newAsyncWriter(out, AsyncConfig{BufferSize: 2})
+	fill(t, w, slog.LevelInfo, "a\n")
+	waitWriting(t, w) // "a" is in flight; the queue is empty
+	fill(t, w, slog.LevelInfo, "b\n", "c\n")
+
+	queued := make(chan struct{})
+	go func() {
+		fill(t, w, slog.LevelInfo, "d\n") // blocks until the flusher frees space
+		close(queued)
+	}()
+	select {
+	case <-queued:
+		t.Fatal("write must block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(out.release)
+	<-queued
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "a\nb\nc\nd\n" {
+		t.Errorf("written = %q, want all entries in order", got)
+	}
+	w.Close()
+}
+
+func TestAsyncWriter_Drop(t *testing.T) {
+	out := newGatedWriter()
+	w := newAsyncWriter(out, AsyncConfig{BufferSize: 2, Policy: OverflowDrop})
+	dropped := droppedEntriesTotal.WithLabelValues("INFO", dropReasonFull)
+	before := testutil.ToFloat64(dropped)
+
+	fill(t, w, slog.LevelInfo, "a\n")
+	waitWriting(t, w)
+	fill(t, w, slog.LevelInfo, "b\n", "c\n", "d\n", "e\n")
+	if got := testutil.ToFloat64(dropped) - before; got != 2 {
+		t.Errorf("dropped = %v, want 2", got)
+	}
+	close(out.release)
+	w.Close()
+	if got := out.String(); got != "a\nb\nc\n" {
+		t.Errorf("written = %q", got)
+	}
+}
+
+func TestAsyncWriter_DropLowest(t *testing.T) {
+	out := newGatedWriter()
+	w := newAsyncWriter(out, AsyncConfig{BufferSize: 3, Policy: OverflowDropLowest})
+	evicted := droppedEntriesTotal.WithLabelValues("DEBUG", dropReasonEvicted)
+	before := testutil.ToFloat64(evicted)
+
+	fill(t, w, slog.LevelInfo, "first\n")
+	waitWriting(t, w)
+	fill(t, w, slog.LevelInfo, "info1\n")
+	fill(t, w, slog.LevelDebug, "debug1\n")
+	fill(t, w, slog.LevelWarn, "warn1\n")
+	fill(t, w, slog.LevelError, "error1\n") // evicts debug1
+	fill(t, w, slog.LevelError, "error2\n") // evicts info1
+	fill(t, w, slog.LevelDebug, "debug2\n") // nothing lower queued: dropped
+
+	if got := testutil.ToFloat64(evicted) - before; got != 1 {
+		t.Errorf("evicted DEBUG = %v, want 1", got)
+	}
+	close(out.release)
+	w.Close()
+	if got := out.String(); got != "first\nwarn1\nerror1\nerror2\n" {
+		t.Errorf("written = %q", got)
+	}
+}
+
+func TestAsyncWriter_FlushTimeoutAndClose(t *testing.T) {
+	out := newGatedWriter()
+	w := newAsyncWriter(out, AsyncConfig{})
+	fill(t, w, slog.LevelInfo, "a\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Flush = %v, want deadline exceeded while the destination is stuck", err)
+	}
+	close(out.release)
+	w.Close()
+	if _, err := w.Write([]byte("after close\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.HasSuffix(got, "after close\n") {
+		t.Errorf("write after Close must go straight through: %q", got)
+	}
+}
+
+func TestInit_AsyncFlush(t *testing.T) {
+	prev := globalCfg.get()
+	defer Init(prev)
+
+	var buf bytes.Buffer
+	Init(Config{LogLevel: slog.LevelInfo, Writer: &buf, Async: AsyncConfig{Enabled: true}})
+	Info("queued", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"message":"queued"`) {
+		t.Errorf("entry not written after Flush: %q", buf.String())
+	}
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+	if err := RegisterMetrics(reg); err != nil {
+		t.Errorf("second RegisterMetrics: %v", err)
+	}
+	recordDropped(slog.LevelWarn, dropReasonFull)
+	if n, err := testutil.GatherAndCount(reg, "log_dropped_entries_total"); err != nil || n == 0 {
+		t.Errorf("gathered %d series, %v", n, err)
+	}
+}
@@ -1,6 +1,9 @@
 package logger
 
-import "log/slog"
+import (
+	"io"
+	"log/slog"
+)
 
 // Config configures the enterprise observability logger.
 // Use Init(cfg) once at startup (e.g. in main or wire).
@@ -23,6 +26,21 @@ type Config struct {
 	Redact RedactFunc
 	// ErrorStacktrace enables stack traces in structured error logging. Optional.
 	ErrorStacktrace bool
+
+	// Format selects the output format: FormatGCP (default), FormatConsole, FormatText, FormatECS, FormatOTel.
+	Format Format
+	// Formatter is a custom formatter; overrides Format. Optional.
+	Formatter Formatter
+	// Output selects the destination: OutputStderr (default), OutputStdout, or OutputFile (see File).
+	Output Output
+	// File configures OutputFile (path and rotation).
+	File FileConfig
+	// Writer is a custom destination; overrides Output. Optional.
+	Writer io.Writer
+	// Async queues entries for a background writer (bounded buffer, overflow policy). Call Flush on shutdown.
+	Async AsyncConfig
+	// ErrorReporting enables ReportError/ReportPanic: grouped, rate-limited Cloud Error Reporting events.
+	ErrorReporting ErrorReportingConfig
 }
 
 // RedactFunc redacts sensitive values. Return the redacted string or the original.
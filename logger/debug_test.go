@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugToken(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Unix(1_700_000_000, 0)
+	token := SignDebugToken(secret, "cust-42", now.Add(10*time.Minute))
+
+	subject, err := VerifyDebugToken(secret, token, now)
+	if err != nil || subject != "cust-42" {
+		t.Fatalf("VerifyDebugToken = %q, %v; want cust-42, nil", subject, err)
+	}
+	dotted := SignDebugToken(secret, "ops.oncall@example.com", now.Add(time.Minute))
+	if subject, err := VerifyDebugToken(secret, dotted, now); err != nil || subject != "ops.oncall@example.com" {
+		t.Errorf("VerifyDebugToken(dotted subject) = %q, %v", subject, err)
+	}
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte("cust-43")) + "." + parts[2]
+
+	tests := []struct {
+		name   string
+		secret []byte
+		token  string
+		now    time.Time
+		want   error
+	}{
+		{"wrong secret", []byte("other"), token, now, ErrInvalidDebugToken},
+		{"no secret", nil, token, now, ErrInvalidDebugToken},
+		{"tampered subject", secret, tampered, now, ErrInvalidDebugToken},
+		{"malformed", secret, "garbage", now, ErrInvalidDebugToken},
+		{"expired", secret, token, now.Add(10 * time.Minute), ErrExpiredDebugToken},
+	}
+	for _, tt := range tests {
+		if _, err := VerifyDebugToken(tt.secret, tt.token, tt.now); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestWithDebug_OverridesLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(newHandler(&buf, slog.LevelError, &GCPFormatter{}))
+
+	ctx := context.Background()
+	if DebugEnabled(ctx) {
+		t.Fatal("DebugEnabled(background) = true")
+	}
+	l.DebugContext(ctx, "hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("debug written without override: %s", buf.String())
+	}
+
+	ctx = WithDebug(ctx, "cust-42")
+	if !DebugEnabled(ctx) {
+		t.Fatal("DebugEnabled = false after WithDebug")
+	}
+	l.DebugContext(ctx, "traced")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if entry["message"] != "traced" || entry["debug_override"] != "cust-42" {
+		t.Errorf("entry = %v, want message traced with debug_override cust-42", entry)
+	}
+}
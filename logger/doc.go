@@ -11,6 +11,7 @@ Logs follow the official Google Cloud structured logging format:
   - logging.googleapis.com/spanId, logging.googleapis.com/sourceLocation
   - httpRequest (GCP HttpRequest shape when in HTTP context)
   - correlation_id (business transaction ID; never overwritten by middleware)
+  - logging.googleapis.com/labels (service, version, environment from Config, when set)
 
 Trace is only injected when a valid trace ID and project ID exist (env GOOGLE_CLOUD_PROJECT or Config.ProjectID).
 
@@ -31,6 +32,32 @@ Call Init once at startup (e.g. in main or wire):
   }
   logger.Init(cfg)
 
+## Formats and Outputs
+
+Config.Format selects the encoding: FormatGCP (default, described above), FormatConsole/FormatText
+(human-readable lines, coloured on a terminal for FormatConsole), FormatECS (Elastic Common Schema), or
+FormatOTel (OpenTelemetry log data model). Config.Formatter plugs in a custom Formatter.
+
+Config.Output selects the destination: OutputStderr (default), OutputStdout, or OutputFile with size-based
+rotation (Config.File). Config.Writer overrides it, e.g. with a buffer in tests.
+
+  logger.Init(logger.Config{Format: logger.FormatConsole, LogLevel: slog.LevelDebug})
+
+Config.Async moves writes to a background goroutine with a bounded buffer; AsyncConfig.Policy decides
+what happens when it is full (block, evict the lowest-severity entry, or drop). Dropped entries are counted
+in log_dropped_entries_total, exported by RegisterMetrics(reg). Call Flush(ctx) on shutdown; Fatalf flushes before exiting.
+
+## Levels
+
+SetLogLevel sets the global level. SetLevel overrides it for one named logger and its dotted children
+("db" covers "db.gorm"), optionally reverting after a TTL; names come from Named or WithName(ctx). GORM
+entries (package database) use "db" and the rate limiter "ratelimit":
+
+  logger.SetLevel("db", slog.LevelDebug, 15*time.Minute)
+
+WithDebug(ctx, subject) enables Debug for every entry logged with ctx, e.g. to trace one customer's
+request; middlewares.DebugOverride sets it from a signed X-Debug-Token (SignDebugToken).
+
 ## Usage in Handlers
 
 After trace and HTTP instrumentation middleware have run:
@@ -39,6 +66,15 @@ After trace and HTTP instrumentation middleware have run:
   log.Infof("payment processed: %s", paymentID)
   log.Info("payment", logger.Fields{"payment_id": paymentID, "amount": 100})
 
+Request-scoped fields and child loggers (fields are stored in the context, so every later entry logged with
+it carries them):
+
+  ctx = logger.WithFields(ctx, logger.Fields{"order_id": orderID})
+  log := logger.WithContext(ctx).With(logger.Fields{"step": "capture"})
+
+slog attributes and groups are honoured: GetLogger().With("order_id", id) adds the attribute to every entry,
+and WithGroup("payment") nests later attributes under a "payment" JSON object (empty groups are omitted).
+
 Structured errors (type, cause chain, optional stack):
 
   log := logger.WithContext(c.Request.Context())
@@ -46,23 +82,36 @@ Structured errors (type, cause chain, optional stack):
       log.ErrorStructured(err)
   }
 
+## Error Reporting
+
+With Config.ErrorReporting.Enabled, ReportError and ReportPanic write entries that Cloud Error Reporting
+ingests (@type ReportedErrorEvent, serviceContext from ServiceName/ServiceVersion, context.reportLocation,
+context.httpRequest, and the Go stack in the message). Events are grouped by error type and a fingerprint of
+the stack's functions, rate-limited per group, and passed to ErrorReportingConfig.Hooks for other sinks:
+
+  logger.ReportError(ctx, err)
+
+middlewares.RecoveryHandler reports panics and handler.HandleServiceError reports 500 and 503 errors.
+
 ## Correlation Strategy
 
-  - traceID: distributed tracing (X-Cloud-Trace-Context, X-Trace-Id, X-Request-ID)
-  - spanID: request span (from X-Cloud-Trace-Context)
+  - traceID: distributed tracing (traceparent, X-Cloud-Trace-Context, X-Trace-Id, X-Request-ID)
+  - spanID: request span (from traceparent, X-Cloud-Trace-Context, or the active OpenTelemetry span)
   - correlationID: business transaction (order_id, payment_id, etc.); never overwritten when already set
 
-## OpenTelemetry Integration Point
+## OpenTelemetry Integration
 
-This package does not implement OpenTelemetry. To bridge with OTel:
+When ctx carries a valid OpenTelemetry span context (an active span, or the remote parent stored by
+TraceMiddleware/CloudTraceMiddleware from a traceparent header), its trace and span IDs are used for
+logging.googleapis.com/trace and logging.googleapis.com/spanId, and its sampled flag for
+logging.googleapis.com/trace_sampled. IDs set with WithTraceID/WithSpanID are the fallback.
 
-  - Extract trace/span from otel span context and set them via WithTraceID/WithSpanID in context.
-  - Or use go.opentelemetry.io/contrib/instrumentation to populate X-Cloud-Trace-Context
-    and let CloudTraceMiddleware parse it; logs will then link to the same trace in GCP.
+Logs written inside child spans (GORM queries, Redis commands, outbound HTTP; see package tracing)
+therefore link to the exact span in Cloud Trace.
 
 ## Production Safety
 
-  - No os.Exit in request context; Fatalf is for startup failures only.
+  - No os.Exit in request context; Fatalf is for startup failures only (it flushes queued entries first).
   - Recovery middleware logs CRITICAL with trace/correlation preserved.
   - Redact hook prevents sensitive data in logs (audit-grade for fintech).
 */
@@ -0,0 +1,242 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Format selects a built-in Formatter (Config.Format).
+type Format string
+
+const (
+	// FormatGCP is Google Cloud Logging structured JSON (default).
+	FormatGCP Format = "gcp"
+	// FormatConsole is human-readable single-line output, coloured when writing to a terminal.
+	FormatConsole Format = "console"
+	// FormatText is FormatConsole without colour.
+	FormatText Format = "text"
+	// FormatECS is Elastic Common Schema JSON.
+	FormatECS Format = "ecs"
+	// FormatOTel is JSON following the OpenTelemetry log data model.
+	FormatOTel Format = "otel"
+)
+
+// SourceLocation is the file, line, and function that emitted an entry.
+type SourceLocation struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// Entry is a resolved log record passed to a Formatter: context IDs, service information from Config, and
+// fields (context fields, handler attributes, and call attributes, already grouped and redacted).
+// An Entry and its Fields are reused after Format returns; formatters must not retain them.
+type Entry struct {
+	Time          time.Time
+	Level         slog.Level
+	Message       string
+	TraceID       string
+	SpanID        string
+	TraceSampled  bool
+	CorrelationID string
+	LoggerName    string
+	Source        *SourceLocation
+	HTTPRequest   *HTTPRequest
+
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+
+	Fields map[string]interface{}
+}
+
+// Formatter encodes one Entry, including the trailing newline, into buf.
+type Formatter interface {
+	Format(buf *bytes.Buffer, e *Entry) error
+}
+
+// newFormatter returns cfg.Formatter, or the built-in formatter for cfg.Format writing to w.
+func newFormatter(cfg Config, w io.Writer) Formatter {
+	if cfg.Formatter != nil {
+		return cfg.Formatter
+	}
+	switch cfg.Format {
+	case FormatConsole:
+		return &ConsoleFormatter{Color: isTerminal(w)}
+	case FormatText:
+		return &ConsoleFormatter{}
+	case FormatECS:
+		return &ECSFormatter{}
+	case FormatOTel:
+		return &OTelFormatter{}
+	default:
+		return &GCPFormatter{ProjectID: cfg.ProjectID}
+	}
+}
+
+// encodeJSON writes v as one JSON line without HTML escaping.
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// GCPFormatter writes Google Cloud Logging structured JSON: severity, message, time,
+// logging.googleapis.com/trace (when a trace ID and project ID exist), spanId, trace_sampled, sourceLocation,
+// labels (service, version, environment), httpRequest, correlation_id, logger, and fields at the top level.
+type GCPFormatter struct {
+	// ProjectID for the trace resource name; GOOGLE_CLOUD_PROJECT when empty.
+	ProjectID string
+}
+
+// Format implements Formatter.
+func (f *GCPFormatter) Format(buf *bytes.Buffer, e *Entry) error {
+	entry := getEntryFromPool()
+	defer putEntryToPool(entry)
+
+	entry.Severity = gcpSeverity(e.Level)
+	entry.Message = e.Message
+	entry.Time = e.Time.Format(time.RFC3339Nano)
+	entry.CorrelationID = e.CorrelationID
+	projectID := f.ProjectID
+	if projectID == "" {
+		projectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if e.TraceID != "" && projectID != "" {
+		entry.Trace = "projects/" + projectID + "/traces/" + e.TraceID
+		entry.TraceSampled = e.TraceSampled
+	}
+	entry.SpanID = e.SpanID
+	entry.SourceLocation = e.Source
+	entry.HTTPRequest = e.HTTPRequest
+	entry.Labels = serviceLabels(e)
+	if e.LoggerName != "" {
+		entry.Fields[LoggerNameKey] = e.LoggerName
+	}
+	for k, v := range e.Fields {
+		entry.Fields[k] = v
+	}
+	return encodeJSON(buf, entry)
+}
+
+// serviceLabels returns logging.googleapis.com/labels from the service fields of e, or nil when none is set.
+func serviceLabels(e *Entry) map[string]string {
+	if e.ServiceName == "" && e.ServiceVersion == "" && e.Environment == "" {
+		return nil
+	}
+	labels := make(map[string]string, 3)
+	if e.ServiceName != "" {
+		labels["service"] = e.ServiceName
+	}
+	if e.ServiceVersion != "" {
+		labels["version"] = e.ServiceVersion
+	}
+	if e.Environment != "" {
+		labels["environment"] = e.Environment
+	}
+	return labels
+}
+
+func gcpSeverity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARNING"
+	case level < slog.LevelError+1:
+		return "ERROR"
+	default:
+		return "CRITICAL"
+	}
+}
+
+// gcpLogEntry is the JSON shape written to stdout for Cloud Logging.
+type gcpLogEntry struct {
+	Severity       string                 `json:"severity"`
+	Message        string                 `json:"message"`
+	Time           string                 `json:"time"`
+	Trace          string                 `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string                 `json:"logging.googleapis.com/spanId,omitempty"`
+	TraceSampled   bool                   `json:"logging.googleapis.com/trace_sampled,omitempty"`
+	SourceLocation *SourceLocation        `json:"logging.googleapis.com/sourceLocation,omitempty"`
+	HTTPRequest    *HTTPRequest           `json:"httpRequest,omitempty"`
+	CorrelationID  string                 `json:"correlation_id,omitempty"`
+	Labels         map[string]string      `json:"logging.googleapis.com/labels,omitempty"`
+	Fields         map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON merges Fields into the same JSON object.
+func (e gcpLogEntry) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, 12)
+	m["severity"] = e.Severity
+	m["message"] = e.Message
+	m["time"] = e.Time
+	if e.Trace != "" {
+		m["logging.googleapis.com/trace"] = e.Trace
+	}
+	if e.SpanID != "" {
+		m["logging.googleapis.com/spanId"] = e.SpanID
+	}
+	if e.TraceSampled {
+		m["logging.googleapis.com/trace_sampled"] = true
+	}
+	if e.SourceLocation != nil {
+		m["logging.googleapis.com/sourceLocation"] = e.SourceLocation
+	}
+	if e.HTTPRequest != nil {
+		m["httpRequest"] = e.HTTPRequest
+	}
+	if e.CorrelationID != "" {
+		m["correlation_id"] = e.CorrelationID
+	}
+	if len(e.Labels) > 0 {
+		m["logging.googleapis.com/labels"] = e.Labels
+	}
+	for k, v := range e.Fields {
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
+
+var (
+	entryPool = sync.Pool{
+		New: func() interface{} {
+			return &gcpLogEntry{Fields: make(map[string]interface{}, 8)}
+		},
+	}
+	bufPool = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+)
+
+func getEntryFromPool() *gcpLogEntry {
+	e := entryPool.Get().(*gcpLogEntry)
+	if e.Fields == nil {
+		e.Fields = make(map[string]interface{}, 8)
+	}
+	return e
+}
+
+func putEntryToPool(e *gcpLogEntry) {
+	for k := range e.Fields {
+		delete(e.Fields, k)
+	}
+	e.Severity = ""
+	e.Message = ""
+	e.Time = ""
+	e.Trace = ""
+	e.SpanID = ""
+	e.TraceSampled = false
+	e.SourceLocation = nil
+	e.HTTPRequest = nil
+	e.CorrelationID = ""
+	e.Labels = nil
+	entryPool.Put(e)
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiFaint   = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiCyan    = "\x1b[36m"
+	ansiGray    = "\x1b[90m"
+	ansiMagenta = "\x1b[1;35m"
+)
+
+// ConsoleFormatter writes one human-readable line per entry for local development:
+//
+//	2026-01-02T15:04:05.000Z INFO  payment processed order_id=ord-1 amount=100 trace_id=4bf9...
+//
+// Fields are sorted by key; strings with spaces or quotes are quoted and objects are written as JSON.
+type ConsoleFormatter struct {
+	// Color enables ANSI colours for the level and keys.
+	Color bool
+}
+
+// Format implements Formatter.
+func (f *ConsoleFormatter) Format(buf *bytes.Buffer, e *Entry) error {
+	buf.WriteString(e.Time.UTC().Format("2006-01-02T15:04:05.000Z"))
+	buf.WriteByte(' ')
+	level := consoleLevel(e.Level)
+	if f.Color {
+		buf.WriteString(consoleLevelColor(e.Level))
+		buf.WriteString(level)
+		buf.WriteString(ansiReset)
+	} else {
+		buf.WriteString(level)
+	}
+	buf.WriteString(strings.Repeat(" ", 6-len(level)))
+	if e.LoggerName != "" {
+		buf.WriteString("[" + e.LoggerName + "] ")
+	}
+	buf.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f.writePair(buf, k, e.Fields[k])
+	}
+	if hr := e.HTTPRequest; hr != nil {
+		f.writePair(buf, "http.method", hr.RequestMethod)
+		f.writePair(buf, "http.url", hr.RequestURL)
+		if hr.Status != 0 {
+			f.writePair(buf, "http.status", hr.Status)
+		}
+		if hr.Latency != "" {
+			f.writePair(buf, "http.latency", hr.Latency)
+		}
+	}
+	if e.CorrelationID != "" {
+		f.writePair(buf, "correlation_id", e.CorrelationID)
+	}
+	if e.TraceID != "" {
+		f.writePair(buf, "trace_id", e.TraceID)
+	}
+	if e.SpanID != "" {
+		f.writePair(buf, "span_id", e.SpanID)
+	}
+	if e.Source != nil {
+		f.writePair(buf, "source", e.Source.File+":"+strconv.Itoa(e.Source.Line))
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func (f *ConsoleFormatter) writePair(buf *bytes.Buffer, key string, v interface{}) {
+	buf.WriteByte(' ')
+	if f.Color {
+		buf.WriteString(ansiFaint)
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(ansiReset)
+	} else {
+		buf.WriteString(key)
+		buf.WriteByte('=')
+	}
+	buf.WriteString(consoleValue(v))
+}
+
+// consoleValue renders v for key=value output.
+func consoleValue(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		s = t
+	case error:
+		s = t.Error()
+	case time.Duration:
+		return t.String()
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		s = t.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return fmt.Sprint(t)
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprintf("%+v", t)
+		}
+		return string(b)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func consoleLevel(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARN"
+	case level < LevelCritical:
+		return "ERROR"
+	default:
+		return "CRIT"
+	}
+}
+
+func consoleLevelColor(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return ansiGray
+	case level < slog.LevelWarn:
+		return ansiCyan
+	case level < slog.LevelError:
+		return ansiYellow
+	case level < LevelCritical:
+		return ansiRed
+	default:
+		return ansiMagenta
+	}
+}
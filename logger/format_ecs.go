@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ecsVersion is the Elastic Common Schema version the ECS format follows.
+const ecsVersion = "8.11.0"
+
+// ECSFormatter writes Elastic Common Schema JSON with dotted field names: @timestamp, log.level, message,
+// ecs.version, trace.id, span.id, log.logger, service.name/version/environment, log.origin.*, http.*, url.original,
+// user_agent.original, client.ip, event.duration (ns), and labels.correlation_id. Fields are written at the
+// top level under their own names.
+type ECSFormatter struct{}
+
+// Format implements Formatter.
+func (f *ECSFormatter) Format(buf *bytes.Buffer, e *Entry) error {
+	m := make(map[string]interface{}, len(e.Fields)+16)
+	for k, v := range e.Fields {
+		m[k] = v
+	}
+	m["@timestamp"] = e.Time.UTC().Format(time.RFC3339Nano)
+	m["log.level"] = ecsLevel(e.Level)
+	m["message"] = e.Message
+	m["ecs.version"] = ecsVersion
+	setIf(m, "trace.id", e.TraceID)
+	setIf(m, "span.id", e.SpanID)
+	setIf(m, "log.logger", e.LoggerName)
+	setIf(m, "service.name", e.ServiceName)
+	setIf(m, "service.version", e.ServiceVersion)
+	setIf(m, "service.environment", e.Environment)
+	if e.CorrelationID != "" {
+		m["labels"] = map[string]string{"correlation_id": e.CorrelationID}
+	}
+	if s := e.Source; s != nil {
+		setIf(m, "log.origin.file.name", s.File)
+		if s.Line != 0 {
+			m["log.origin.file.line"] = s.Line
+		}
+		setIf(m, "log.origin.function", s.Function)
+	}
+	if hr := e.HTTPRequest; hr != nil {
+		setIf(m, "http.request.method", hr.RequestMethod)
+		setIf(m, "url.original", hr.RequestURL)
+		setIf(m, "user_agent.original", hr.UserAgent)
+		setIf(m, "client.ip", hr.RemoteIP)
+		if hr.Status != 0 {
+			m["http.response.status_code"] = hr.Status
+		}
+		if hr.RequestSize != 0 {
+			m["http.request.body.bytes"] = hr.RequestSize
+		}
+		if hr.ResponseSize != 0 {
+			m["http.response.body.bytes"] = hr.ResponseSize
+		}
+		if d, err := time.ParseDuration(hr.Latency); err == nil {
+			m["event.duration"] = d.Nanoseconds()
+		}
+	}
+	return encodeJSON(buf, m)
+}
+
+func ecsLevel(level slog.Level) string {
+	if level >= LevelCritical {
+		return "critical"
+	}
+	return strings.ToLower(level.String())
+}
+
+// setIf sets m[key] when v is non-empty.
+func setIf(m map[string]interface{}, key, v string) {
+	if v != "" {
+		m[key] = v
+	}
+}
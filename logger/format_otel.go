@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"time"
+)
+
+// OTelFormatter writes JSON following the OpenTelemetry log data model: timestamp, severity_text,
+// severity_number, body, trace_id, span_id, trace_flags, instrumentation_scope.name (logger name), resource
+// (service.name, service.version, deployment.environment.name), and attributes (fields plus correlation_id
+// and code.* / http.* semantic convention attributes).
+type OTelFormatter struct{}
+
+// Format implements Formatter.
+func (f *OTelFormatter) Format(buf *bytes.Buffer, e *Entry) error {
+	text, number := otelSeverity(e.Level)
+	attrs := make(map[string]interface{}, len(e.Fields)+8)
+	for k, v := range e.Fields {
+		attrs[k] = v
+	}
+	setIf(attrs, "correlation_id", e.CorrelationID)
+	if s := e.Source; s != nil {
+		setIf(attrs, "code.file.path", s.File)
+		if s.Line != 0 {
+			attrs["code.line.number"] = s.Line
+		}
+		setIf(attrs, "code.function.name", s.Function)
+	}
+	if hr := e.HTTPRequest; hr != nil {
+		setIf(attrs, "http.request.method", hr.RequestMethod)
+		setIf(attrs, "url.full", hr.RequestURL)
+		setIf(attrs, "user_agent.original", hr.UserAgent)
+		setIf(attrs, "client.address", hr.RemoteIP)
+		if hr.Status != 0 {
+			attrs["http.response.status_code"] = hr.Status
+		}
+		if hr.RequestSize != 0 {
+			attrs["http.request.body.size"] = hr.RequestSize
+		}
+		if hr.ResponseSize != 0 {
+			attrs["http.response.body.size"] = hr.ResponseSize
+		}
+	}
+
+	m := map[string]interface{}{
+		"timestamp":       e.Time.UTC().Format(time.RFC3339Nano),
+		"severity_text":   text,
+		"severity_number": number,
+		"body":            e.Message,
+	}
+	if len(attrs) > 0 {
+		m["attributes"] = attrs
+	}
+	if e.TraceID != "" {
+		m["trace_id"] = e.TraceID
+		m["trace_flags"] = "00"
+		if e.TraceSampled {
+			m["trace_flags"] = "01"
+		}
+	}
+	setIf(m, "span_id", e.SpanID)
+	if e.LoggerName != "" {
+		m["instrumentation_scope"] = map[string]string{"name": e.LoggerName}
+	}
+	resource := make(map[string]string, 3)
+	if e.ServiceName != "" {
+		resource["service.name"] = e.ServiceName
+	}
+	if e.ServiceVersion != "" {
+		resource["service.version"] = e.ServiceVersion
+	}
+	if e.Environment != "" {
+		resource["deployment.environment.name"] = e.Environment
+	}
+	if len(resource) > 0 {
+		m["resource"] = resource
+	}
+	return encodeJSON(buf, m)
+}
+
+// otelSeverity maps a level to the data model's SeverityText and SeverityNumber (DEBUG=5, INFO=9, WARN=13,
+// ERROR=17, FATAL=21).
+func otelSeverity(level slog.Level) (string, int) {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG", 5
+	case level < slog.LevelWarn:
+		return "INFO", 9
+	case level < slog.LevelError:
+		return "WARN", 13
+	case level < LevelCritical:
+		return "ERROR", 17
+	default:
+		return "FATAL", 21
+	}
+}
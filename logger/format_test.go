@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry() *Entry {
+	return &Entry{
+		Time:           time.Date(2026, 1, 2, 15, 4, 5, 123e6, time.UTC),
+		Level:          slog.LevelWarn,
+		Message:        "payment declined",
+		TraceID:        "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:         "00f067aa0ba902b7",
+		TraceSampled:   true,
+		CorrelationID:  "corr-1",
+		Source:         &SourceLocation{File: "pay.go", Line: 42, Function: "pay.Charge"},
+		HTTPRequest:    &HTTPRequest{RequestMethod: "POST", RequestURL: "/charges", Status: 402, Latency: "0.250s"},
+		ServiceName:    "payments-api",
+		ServiceVersion: "1.2.3",
+		Environment:    "staging",
+		Fields:         map[string]interface{}{"order_id": "ord-1", "amount": 100, "reason": "insufficient funds"},
+	}
+}
+
+func formatJSON(t *testing.T, f Formatter) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := f.Format(&buf, testEntry()); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	return m
+}
+
+func assertFields(t *testing.T, got map[string]interface{}, want map[string]interface{}) {
+	t.Helper()
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v (%T), want %v", k, got[k], got[k], v)
+		}
+	}
+}
+
+func TestGCPFormatter(t *testing.T) {
+	m := formatJSON(t, &GCPFormatter{ProjectID: "proj"})
+	assertFields(t, m, map[string]interface{}{
+		"severity":                             "WARNING",
+		"message":                              "payment declined",
+		"time":                                 "2026-01-02T15:04:05.123Z",
+		"logging.googleapis.com/trace":         "projects/proj/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+		"logging.googleapis.com/trace_sampled": true,
+		"correlation_id":                       "corr-1",
+		"order_id":                             "ord-1",
+		"amount":                               float64(100),
+	})
+}
+
+func TestECSFormatter(t *testing.T) {
+	m := formatJSON(t, &ECSFormatter{})
+	assertFields(t, m, map[string]interface{}{
+		"@timestamp":                "2026-01-02T15:04:05.123Z",
+		"log.level":                 "warn",
+		"message":                   "payment declined",
+		"ecs.version":               ecsVersion,
+		"trace.id":                  "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span.id":                   "00f067aa0ba902b7",
+		"service.name":              "payments-api",
+		"service.version":           "1.2.3",
+		"service.environment":       "staging",
+		"log.origin.file.name":      "pay.go",
+		"log.origin.file.line":      float64(42),
+		"http.request.method":       "POST",
+		"http.response.status_code": float64(402),
+		"event.duration":            float64(250 * time.Millisecond),
+		"order_id":                  "ord-1",
+	})
+	if labels, _ := m["labels"].(map[string]interface{}); labels["correlation_id"] != "corr-1" {
+		t.Errorf("labels = %v, want correlation_id", m["labels"])
+	}
+}
+
+func TestOTelFormatter(t *testing.T) {
+	m := formatJSON(t, &OTelFormatter{})
+	assertFields(t, m, map[string]interface{}{
+		"timestamp":       "2026-01-02T15:04:05.123Z",
+		"severity_text":   "WARN",
+		"severity_number": float64(13),
+		"body":            "payment declined",
+		"trace_id":        "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":         "00f067aa0ba902b7",
+		"trace_flags":     "01",
+	})
+	attrs, _ := m["attributes"].(map[string]interface{})
+	assertFields(t, attrs, map[string]interface{}{
+		"order_id":                  "ord-1",
+		"correlation_id":            "corr-1",
+		"code.file.path":            "pay.go",
+		"http.response.status_code": float64(402),
+	})
+	resource, _ := m["resource"].(map[string]interface{})
+	assertFields(t, resource, map[string]interface{}{
+		"service.name":                "payments-api",
+		"deployment.environment.name": "staging",
+	})
+}
+
+func TestConsoleFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&ConsoleFormatter{}).Format(&buf, testEntry()); err != nil {
+		t.Fatal(err)
+	}
+	want := `2026-01-02T15:04:05.123Z WARN  payment declined amount=100 order_id=ord-1 reason="insufficient funds" ` +
+		`http.method=POST http.url=/charges http.status=402 http.latency=0.250s correlation_id=corr-1 ` +
+		`trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 source=pay.go:42` + "\n"
+	if buf.String() != want {
+		t.Errorf("Format =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := (&ConsoleFormatter{Color: true}).Format(&buf, testEntry()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ansiYellow+"WARN"+ansiReset) {
+		t.Errorf("coloured output missing level colour: %q", buf.String())
+	}
+}
+
+func TestInit_FormatAndWriter(t *testing.T) {
+	prev := globalCfg.get()
+	defer Init(prev)
+
+	var buf bytes.Buffer
+	Init(Config{LogLevel: slog.LevelInfo, Format: FormatECS, Writer: &buf, ServiceName: "svc"})
+	Info("hello", Fields{"k": "v"})
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	assertFields(t, m, map[string]interface{}{"message": "hello", "log.level": "info", "service.name": "svc", "k": "v"})
+	if GetWriter() != &buf {
+		t.Error("GetWriter must return the configured writer")
+	}
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoggerNameKey is the field under which a named logger's name is written.
+const LoggerNameKey = "logger"
+
+// levelRegistry holds the global level and per-logger-name overrides with optional expiry.
+type levelRegistry struct {
+	base      slog.LevelVar
+	count     atomic.Int32 // len(overrides), read without the lock on the hot path
+	mu        sync.RWMutex
+	overrides map[string]levelOverride
+	now       func() time.Time
+}
+
+type levelOverride struct {
+	level   slog.Level
+	expires time.Time // zero: permanent
+}
+
+var levels = &levelRegistry{overrides: make(map[string]levelOverride), now: time.Now}
+
+// levelFor returns the level for name: the override of name or of its nearest dotted parent ("db" for
+// "db.gorm"), or fallback when none applies. Expired overrides are ignored.
+func (r *levelRegistry) levelFor(name string, fallback slog.Leveler) slog.Level {
+	if name == "" || r.count.Load() == 0 {
+		return fallback.Level()
+	}
+	now := r.now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for n := name; ; {
+		if o, ok := r.overrides[n]; ok && (o.expires.IsZero() || now.Before(o.expires)) {
+			return o.level
+		}
+		i := strings.LastIndexByte(n, '.')
+		if i < 0 {
+			return fallback.Level()
+		}
+		n = n[:i]
+	}
+}
+
+func (r *levelRegistry) set(name string, level slog.Level, ttl time.Duration) {
+	o := levelOverride{level: level}
+	if ttl > 0 {
+		o.expires = r.now().Add(ttl)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[name] = o
+	r.count.Store(int32(len(r.overrides)))
+}
+
+func (r *levelRegistry) reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.overrides, name)
+	r.count.Store(int32(len(r.overrides)))
+}
+
+// LevelOverride is a per-logger level set with SetLevel.
+type LevelOverride struct {
+	Logger    string     `json:"logger"`
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// LevelState is the global level and the active per-logger overrides.
+type LevelState struct {
+	Level     string          `json:"level"`
+	Overrides []LevelOverride `json:"overrides"`
+}
+
+// SetLevel sets the minimum level for the logger called name and its dotted children ("db" covers
+// "db.gorm"). With ttl > 0 the override reverts to the global level after ttl. Overrides apply to loggers
+// from Named and to contexts from WithName, including those created before the call.
+func SetLevel(name string, level slog.Level, ttl time.Duration) {
+	levels.set(name, level, ttl)
+}
+
+// ResetLevel removes the override for name.
+func ResetLevel(name string) {
+	levels.reset(name)
+}
+
+// Levels returns the global level and the active overrides sorted by logger name; expired overrides are
+// removed.
+func Levels() LevelState {
+	now := levels.now()
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	state := LevelState{Level: levels.base.Level().String(), Overrides: []LevelOverride{}}
+	for name, o := range levels.overrides {
+		if !o.expires.IsZero() && !now.Before(o.expires) {
+			delete(levels.overrides, name)
+			continue
+		}
+		lo := LevelOverride{Logger: name, Level: o.level.String()}
+		if !o.expires.IsZero() {
+			expires := o.expires
+			lo.ExpiresAt = &expires
+		}
+		state.Overrides = append(state.Overrides, lo)
+	}
+	levels.count.Store(int32(len(levels.overrides)))
+	sort.Slice(state.Overrides, func(i, j int) bool { return state.Overrides[i].Logger < state.Overrides[j].Logger })
+	return state
+}
+
+// Named returns a logger whose entries carry name (field "logger") and whose level follows SetLevel(name).
+func Named(name string) *slog.Logger {
+	l := GetLogger()
+	if h, ok := l.Handler().(*handler); ok {
+		h2 := *h
+		h2.name = name
+		return slog.New(&h2)
+	}
+	return l.With(LoggerNameKey, name)
+}
+
+// WithName returns a copy of ctx that names the logger for entries logged with it (context-bound and
+// *Context functions), so SetLevel(name) applies to them.
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKeyLoggerName, name)
+}
+
+// GetName returns the logger name stored in ctx by WithName.
+func GetName(ctx context.Context) string {
+	if v, ok := ctx.Value(contextKeyLoggerName).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Named returns a child logger named name (see WithName).
+func (c *Ctx) Named(name string) *Ctx {
+	return &Ctx{ctx: WithName(c.ctx, name)}
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLevelRegistry_LevelFor(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	r := &levelRegistry{overrides: make(map[string]levelOverride), now: func() time.Time { return now }}
+	fallback := slog.LevelWarn
+
+	if got := r.levelFor("db", fallback); got != slog.LevelWarn {
+		t.Errorf("no overrides: level = %v, want WARN", got)
+	}
+	r.set("db", slog.LevelDebug, 0)
+	r.set("db.gorm.migrate", slog.LevelError, time.Minute)
+
+	tests := []struct {
+		name string
+		want slog.Level
+	}{
+		{"db", slog.LevelDebug},
+		{"db.gorm", slog.LevelDebug},
+		{"db.gorm.migrate", slog.LevelError},
+		{"dbx", slog.LevelWarn},
+		{"ratelimit", slog.LevelWarn},
+		{"", slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		if got := r.levelFor(tt.name, fallback); got != tt.want {
+			t.Errorf("levelFor(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+	if got := r.levelFor("db.gorm.migrate", fallback); got != slog.LevelDebug {
+		t.Errorf("after expiry: level = %v, want DEBUG (parent override)", got)
+	}
+	r.reset("db")
+	if got := r.levelFor("db.gorm", fallback); got != slog.LevelWarn {
+		t.Errorf("after reset: level = %v, want WARN", got)
+	}
+}
+
+func TestLevels_PrunesExpired(t *testing.T) {
+	prevNow := levels.now
+	now := time.Unix(1_700_000_000, 0)
+	levels.now = func() time.Time { return now }
+	defer func() { levels.now = prevNow }()
+
+	SetLevel("test.permanent", slog.LevelDebug, 0)
+	SetLevel("test.temporary", slog.LevelError, time.Minute)
+	defer ResetLevel("test.permanent")
+	defer ResetLevel("test.temporary")
+
+	state := Levels()
+	if len(state.Overrides) != 2 {
+		t.Fatalf("overrides = %+v, want 2", state.Overrides)
+	}
+	if o := state.Overrides[1]; o.Logger != "test.temporary" || o.Level != "ERROR" || o.ExpiresAt == nil {
+		t.Errorf("override = %+v, want test.temporary ERROR with expiry", o)
+	}
+
+	now = now.Add(time.Minute)
+	state = Levels()
+	if len(state.Overrides) != 1 || state.Overrides[0].Logger != "test.permanent" {
+		t.Errorf("overrides after expiry = %+v, want only test.permanent", state.Overrides)
+	}
+}
+
+func TestHandler_NamedLevels(t *testing.T) {
+	var buf bytes.Buffer
+	h := newHandler(&buf, slog.LevelInfo, &GCPFormatter{})
+	named := *h
+	named.name = "test.db"
+	db := slog.New(&named)
+	plain := slog.New(h)
+
+	db.Debug("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("debug written without override: %s", buf.String())
+	}
+
+	SetLevel("test.db", slog.LevelDebug, 0)
+	defer ResetLevel("test.db")
+	db.Debug("query")
+	plain.Debug("other")
+	ctx := WithName(context.Background(), "test.db.gorm")
+	plain.DebugContext(ctx, "via context")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(lines[0], &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["message"] != "query" || entry[LoggerNameKey] != "test.db" {
+		t.Errorf("entry = %v, want message query from logger test.db", entry)
+	}
+	if err := json.Unmarshal(lines[1], &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["message"] != "via context" || entry[LoggerNameKey] != "test.db.gorm" {
+		t.Errorf("entry = %v, want message via context from logger test.db.gorm", entry)
+	}
+}
+
+func TestWithName(t *testing.T) {
+	ctx := context.Background()
+	if got := GetName(ctx); got != "" {
+		t.Errorf("GetName(empty) = %q, want empty", got)
+	}
+	ctx = WithContext(ctx).Named("ratelimit").Context()
+	if got := GetName(ctx); got != "ratelimit" {
+		t.Errorf("GetName = %q, want ratelimit", got)
+	}
+}
@@ -3,24 +3,26 @@ package logger
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sync"
-	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // LevelCritical is the slog level for CRITICAL severity (e.g. panic). Use with GetLogger().LogAttrs.
 const LevelCritical = slog.LevelError + 1
 
 var (
-	globalLogger *slog.Logger
-	globalCfg    configHolder
-	cachedWriter io.Writer
+	globalLogger    *slog.Logger
+	globalCfg       configHolder
+	cachedWriter    io.Writer
+	cachedFormatter Formatter
 )
 
 type configHolder struct {
@@ -42,21 +44,6 @@ func (h *configHolder) set(cfg Config) {
 	h.init = true
 }
 
-// lazyWriter defers resolution of the writer until first Write.
-type lazyWriter struct {
-	once sync.Once
-	real io.Writer
-}
-
-func (w *lazyWriter) Write(p []byte) (n int, err error) {
-	w.once.Do(func() { w.real = getWriter() })
-	return w.real.Write(p)
-}
-
-func getWriter() io.Writer {
-	return os.Stderr
-}
-
 // contextKey is an unexported type for context keys.
 type contextKey string
 
@@ -65,6 +52,8 @@ const (
 	contextKeySpanID        contextKey = "span_id"
 	contextKeyCorrelationID contextKey = "correlation_id"
 	contextKeyHTTPRequest   contextKey = "http_request"
+	contextKeyFields        contextKey = "fields"
+	contextKeyLoggerName    contextKey = "logger_name"
 )
 
 // WithTraceID returns a copy of ctx with the given trace ID.
@@ -87,20 +76,38 @@ func WithHTTPRequest(ctx context.Context, req *HTTPRequest) context.Context {
 	return context.WithValue(ctx, contextKeyHTTPRequest, req)
 }
 
-// GetTraceID returns the trace ID from ctx if set.
-func GetTraceID(ctx context.Context) string {
-	if v, ok := ctx.Value(contextKeyTraceID).(string); ok {
-		return v
+// WithFields returns a copy of ctx carrying fields; every entry logged with the returned context (or a
+// context derived from it) includes them. Fields already in ctx are kept unless overridden by fields.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	existing := GetFields(ctx)
+	merged := make(Fields, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
 	}
-	return ""
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, contextKeyFields, merged)
 }
 
-// GetSpanID returns the span ID from ctx if set.
-func GetSpanID(ctx context.Context) string {
-	if v, ok := ctx.Value(contextKeySpanID).(string); ok {
+// GetFields returns the fields stored in ctx by WithFields, or nil. The map must not be modified.
+func GetFields(ctx context.Context) Fields {
+	if v, ok := ctx.Value(contextKeyFields).(Fields); ok {
 		return v
 	}
-	return ""
+	return nil
+}
+
+// GetTraceID returns the trace ID of the active OpenTelemetry span in ctx, or the one set by WithTraceID.
+func GetTraceID(ctx context.Context) string {
+	traceID, _, _ := getTraceSpanCorrelation(ctx)
+	return traceID
+}
+
+// GetSpanID returns the span ID of the active OpenTelemetry span in ctx, or the one set by WithSpanID.
+func GetSpanID(ctx context.Context) string {
+	_, spanID, _ := getTraceSpanCorrelation(ctx)
+	return spanID
 }
 
 // GetCorrelationID returns the correlation ID from ctx if set.
@@ -132,12 +139,20 @@ type HTTPRequest struct {
 	Latency       string `json:"latency,omitempty"` // Duration in seconds, e.g. "0.123s"
 }
 
+// getTraceSpanCorrelation returns trace, span, and correlation IDs from ctx. A valid OpenTelemetry span context
+// (active span or remote parent) takes precedence over IDs stored with WithTraceID/WithSpanID, so logs
+// written inside child spans (DB, Redis, outbound HTTP) carry the child's span ID.
 func getTraceSpanCorrelation(ctx context.Context) (traceID, spanID, correlationID string) {
-	if v, ok := ctx.Value(contextKeyTraceID).(string); ok {
-		traceID = v
-	}
-	if v, ok := ctx.Value(contextKeySpanID).(string); ok {
-		spanID = v
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		traceID = sc.TraceID().String()
+		spanID = sc.SpanID().String()
+	} else {
+		if v, ok := ctx.Value(contextKeyTraceID).(string); ok {
+			traceID = v
+		}
+		if v, ok := ctx.Value(contextKeySpanID).(string); ok {
+			spanID = v
+		}
 	}
 	if v, ok := ctx.Value(contextKeyCorrelationID).(string); ok {
 		correlationID = v
@@ -145,181 +160,134 @@ func getTraceSpanCorrelation(ctx context.Context) (traceID, spanID, correlationI
 	return traceID, spanID, correlationID
 }
 
-func gcpSeverity(level slog.Level) string {
-	switch {
-	case level < slog.LevelInfo:
-		return "DEBUG"
-	case level < slog.LevelWarn:
-		return "INFO"
-	case level < slog.LevelError:
-		return "WARNING"
-	case level < slog.LevelError+1:
-		return "ERROR"
-	default:
-		return "CRITICAL"
-	}
-}
-
-// sourceLocation for logging.googleapis.com/sourceLocation
-type sourceLocation struct {
-	File     string `json:"file,omitempty"`
-	Line     int    `json:"line,omitempty"`
-	Function string `json:"function,omitempty"`
+// handler implements slog.Handler: it resolves context IDs, fields, and attributes into an Entry and writes
+// it with the configured Formatter (Google Cloud structured JSON by default).
+type handler struct {
+	writer    io.Writer
+	level     slog.Leveler
+	formatter Formatter
+	// name is set by Named; per-logger levels (SetLevel) apply to it.
+	name string
+	// attrs are the WithAttrs calls so far, each with the groups open at the time.
+	attrs []groupedAttrs
+	// groups are the WithGroup names open for attributes added later (including record attributes).
+	groups []string
 }
 
-// gcpLogEntry is the JSON shape written to stdout for Cloud Logging.
-type gcpLogEntry struct {
-	Severity       string                 `json:"severity"`
-	Message        string                 `json:"message"`
-	Time           string                 `json:"time"`
-	Trace          string                 `json:"logging.googleapis.com/trace,omitempty"`
-	SpanID         string                 `json:"logging.googleapis.com/spanId,omitempty"`
-	SourceLocation *sourceLocation        `json:"logging.googleapis.com/sourceLocation,omitempty"`
-	HTTPRequest    *HTTPRequest           `json:"httpRequest,omitempty"`
-	CorrelationID  string                 `json:"correlation_id,omitempty"`
-	Fields         map[string]interface{} `json:"-"`
+type groupedAttrs struct {
+	groups []string
+	attrs  []slog.Attr
 }
 
-// MarshalJSON merges Fields into the same JSON object.
-func (e gcpLogEntry) MarshalJSON() ([]byte, error) {
-	m := make(map[string]interface{}, 12)
-	m["severity"] = e.Severity
-	m["message"] = e.Message
-	m["time"] = e.Time
-	if e.Trace != "" {
-		m["logging.googleapis.com/trace"] = e.Trace
-	}
-	if e.SpanID != "" {
-		m["logging.googleapis.com/spanId"] = e.SpanID
-	}
-	if e.SourceLocation != nil {
-		m["logging.googleapis.com/sourceLocation"] = e.SourceLocation
-	}
-	if e.HTTPRequest != nil {
-		m["httpRequest"] = e.HTTPRequest
-	}
-	if e.CorrelationID != "" {
-		m["correlation_id"] = e.CorrelationID
-	}
-	for k, v := range e.Fields {
-		m[k] = v
+func newHandler(writer io.Writer, level slog.Leveler, formatter Formatter) *handler {
+	if formatter == nil {
+		formatter = &GCPFormatter{}
 	}
-	return json.Marshal(m)
+	return &handler{writer: writer, level: level, formatter: formatter}
 }
 
-var (
-	entryPool = sync.Pool{
-		New: func() interface{} {
-			return &gcpLogEntry{Fields: make(map[string]interface{}, 8)}
-		},
-	}
-	bufPool = sync.Pool{
-		New: func() interface{} { return new(bytes.Buffer) },
-	}
-)
-
-func getEntryFromPool() *gcpLogEntry {
-	e := entryPool.Get().(*gcpLogEntry)
-	if e.Fields == nil {
-		e.Fields = make(map[string]interface{}, 8)
+// Enabled applies, in order: a per-request debug override (WithDebug), the level set for the logger name
+// (Named, or WithName on ctx), and the handler level.
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if DebugEnabled(ctx) {
+		return level >= slog.LevelDebug
 	}
-	return e
+	return level >= levels.levelFor(h.loggerName(ctx), h.level)
 }
 
-func putEntryToPool(e *gcpLogEntry) {
-	for k := range e.Fields {
-		delete(e.Fields, k)
+func (h *handler) loggerName(ctx context.Context) string {
+	if h.name != "" {
+		return h.name
 	}
-	e.Severity = ""
-	e.Message = ""
-	e.Time = ""
-	e.Trace = ""
-	e.SpanID = ""
-	e.SourceLocation = nil
-	e.HTTPRequest = nil
-	e.CorrelationID = ""
-	entryPool.Put(e)
+	return GetName(ctx)
 }
 
-// gcpHandler implements slog.Handler for Google Cloud structured logging.
-type gcpHandler struct {
-	writer io.Writer
-	level  slog.Level
+var entryFieldsPool = sync.Pool{
+	New: func() interface{} { return make(map[string]interface{}, 8) },
 }
 
-func newGCPHandler(writer io.Writer, level slog.Level) *gcpHandler {
-	return &gcpHandler{writer: writer, level: level}
-}
-
-func (h *gcpHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.level
-}
-
-func (h *gcpHandler) Handle(ctx context.Context, record slog.Record) error {
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
 	cfg := globalCfg.get()
 	traceID, spanID, correlationID := getTraceSpanCorrelation(ctx)
 
-	entry := getEntryFromPool()
-	defer putEntryToPool(entry)
-
-	entry.Severity = gcpSeverity(record.Level)
-	entry.Message = record.Message
-	entry.Time = record.Time.Format(time.RFC3339Nano)
-	entry.CorrelationID = correlationID
-
-	projectID := cfg.ProjectID
-	if projectID == "" {
-		projectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
-	}
-	if traceID != "" && projectID != "" {
-		entry.Trace = "projects/" + projectID + "/traces/" + traceID
+	fields := entryFieldsPool.Get().(map[string]interface{})
+	defer func() {
+		clear(fields)
+		entryFieldsPool.Put(fields)
+	}()
+	entry := Entry{
+		Time:           record.Time,
+		Level:          record.Level,
+		Message:        record.Message,
+		TraceID:        traceID,
+		SpanID:         spanID,
+		CorrelationID:  correlationID,
+		LoggerName:     h.loggerName(ctx),
+		ServiceName:    cfg.ServiceName,
+		ServiceVersion: cfg.ServiceVersion,
+		Environment:    cfg.Environment,
+		Fields:         fields,
+	}
+	if traceID != "" {
+		entry.TraceSampled = trace.SpanContextFromContext(ctx).IsSampled()
 	}
-	if spanID != "" {
-		entry.SpanID = spanID
+	if cfg.EnableHTTPLogging {
+		entry.HTTPRequest = GetHTTPRequest(ctx)
 	}
 
-	if cfg.EnableHTTPLogging {
-		if hr := GetHTTPRequest(ctx); hr != nil {
-			entry.HTTPRequest = hr
+	// Precedence, lowest first: context fields, handler attributes, record attributes.
+	for k, v := range GetFields(ctx) {
+		if cfg.Redact != nil {
+			v = cfg.Redact(k, v)
 		}
+		fields[k] = v
+	}
+	for _, ga := range h.attrs {
+		mergeAt(fields, ga.groups, attrsToMap(ga.attrs, cfg.Redact))
 	}
 
-	var locFromAttrs sourceLocation
-	record.Attrs(func(a slog.Attr) bool {
-		switch a.Key {
-		case "file":
-			if s, ok := a.Value.Any().(string); ok {
-				locFromAttrs.File = s
-			}
-		case "line":
-			switch v := a.Value.Any().(type) {
-			case int:
-				locFromAttrs.Line = v
-			case int64:
-				locFromAttrs.Line = int(v)
-			}
-		case "function":
-			if s, ok := a.Value.Any().(string); ok {
-				locFromAttrs.Function = s
-			}
-		case "trace_id", "correlation_id", "span_id":
-		default:
-			v := a.Value.Any()
-			if cfg.Redact != nil {
-				v = cfg.Redact(a.Key, v)
+	var locFromAttrs SourceLocation
+	if len(h.groups) > 0 {
+		// Record attributes belong to the open group; source keys are only recognised at the top level.
+		var attrs []slog.Attr
+		record.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		mergeAt(fields, h.groups, attrsToMap(attrs, cfg.Redact))
+	} else {
+		record.Attrs(func(a slog.Attr) bool {
+			switch a.Key {
+			case "file":
+				if s, ok := a.Value.Any().(string); ok {
+					locFromAttrs.File = s
+				}
+			case "line":
+				switch v := a.Value.Any().(type) {
+				case int:
+					locFromAttrs.Line = v
+				case int64:
+					locFromAttrs.Line = int(v)
+				}
+			case "function":
+				if s, ok := a.Value.Any().(string); ok {
+					locFromAttrs.Function = s
+				}
+			case "trace_id", "correlation_id", "span_id":
+			default:
+				mergeAt(fields, nil, attrsToMap([]slog.Attr{a}, cfg.Redact))
 			}
-			entry.Fields[a.Key] = v
-		}
-		return true
-	})
+			return true
+		})
+	}
 
 	if locFromAttrs.File != "" || locFromAttrs.Line != 0 || locFromAttrs.Function != "" {
-		entry.SourceLocation = &locFromAttrs
+		entry.Source = &locFromAttrs
 	} else if cfg.EnableCaller && record.PC != 0 {
 		fs := runtime.CallersFrames([]uintptr{record.PC})
 		f, _ := fs.Next()
 		if f.File != "" {
-			entry.SourceLocation = &sourceLocation{
+			entry.Source = &SourceLocation{
 				File:     filepath.Base(f.File),
 				Line:     f.Line,
 				Function: f.Function,
@@ -329,53 +297,157 @@ func (h *gcpHandler) Handle(ctx context.Context, record slog.Record) error {
 
 	buf := bufPool.Get().(*bytes.Buffer)
 	defer func() { buf.Reset(); bufPool.Put(buf) }()
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	if err := enc.Encode(entry); err != nil {
+	if err := h.formatter.Format(buf, &entry); err != nil {
 		return err
 	}
+	if lw, ok := h.writer.(levelWriter); ok {
+		return lw.writeLevel(record.Level, buf.Bytes())
+	}
 	_, err := h.writer.Write(buf.Bytes())
 	return err
 }
 
-func (h *gcpHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
-func (h *gcpHandler) WithGroup(name string) slog.Handler       { return h }
+// WithAttrs returns a handler that adds attrs to every entry, nested under the currently open groups.
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], groupedAttrs{groups: h.groups, attrs: attrs})
+	return &h2
+}
+
+// WithGroup returns a handler that nests attributes added later (WithAttrs and record attributes) under name
+// as a JSON object. Groups with no attributes are omitted.
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+// attrsToMap converts attrs to a JSON object: LogValuers are resolved, groups become nested objects (inlined
+// when the key is empty, omitted when empty), and leaf values pass through redact.
+func attrsToMap(attrs []slog.Attr, redact RedactFunc) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			g := attrsToMap(a.Value.Group(), redact)
+			if len(g) == 0 {
+				continue
+			}
+			if a.Key == "" {
+				mergeAt(m, nil, g)
+			} else {
+				mergeAt(m, []string{a.Key}, g)
+			}
+			continue
+		}
+		v := a.Value.Any()
+		if redact != nil {
+			v = redact(a.Key, v)
+		}
+		m[a.Key] = v
+	}
+	return m
+}
+
+// mergeAt merges src into the object at path under dst, creating (or replacing non-object values with)
+// nested objects as needed. Nothing is created when src is empty. Nested objects are copied before they are
+// written, since they may be shared with a context (GetFields) or a caller's attribute value.
+func mergeAt(dst map[string]interface{}, path []string, src map[string]interface{}) {
+	if len(src) == 0 {
+		return
+	}
+	for _, name := range path {
+		next, ok := dst[name].(map[string]interface{})
+		if ok {
+			next = maps.Clone(next)
+		} else {
+			next = make(map[string]interface{}, len(src))
+		}
+		dst[name] = next
+		dst = next
+	}
+	for k, v := range src {
+		if sub, ok := v.(map[string]interface{}); ok {
+			if _, isMap := dst[k].(map[string]interface{}); isMap {
+				mergeAt(dst, []string{k}, sub)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
 
 // Init initializes the global logger with the given config. Call once at startup.
+// The output (Config.Output or Writer), format (Config.Format or Formatter), and async writer (Config.Async)
+// are set up here; an async writer or file from a previous Init is drained and closed. Error groups
+// (Config.ErrorReporting) start empty.
 func Init(cfg Config) {
 	globalCfg.set(cfg)
-	if cachedWriter == nil {
-		cachedWriter = &lazyWriter{}
+	closeWriter(cachedWriter)
+	out := newWriter(cfg)
+	cachedFormatter = newFormatter(cfg, out)
+	cachedWriter = out
+	if cfg.Async.Enabled {
+		cachedWriter = newAsyncWriter(out, cfg.Async)
+	}
+	levels.base.Set(cfg.LogLevel)
+	globalLogger = slog.New(newHandler(cachedWriter, &levels.base, cachedFormatter))
+	if cfg.ErrorReporting.Enabled {
+		reporter.Store(newErrorReporter(cfg.ErrorReporting))
+	} else {
+		reporter.Store(nil)
+	}
+}
+
+// closeWriter drains and closes writers owned by the logger (async queue, rotating file).
+func closeWriter(w io.Writer) {
+	if aw, ok := w.(*asyncWriter); ok {
+		_ = aw.Close()
+		w = aw.out
+	}
+	if rf, ok := w.(*rotatingFile); ok {
+		_ = rf.Close()
 	}
-	globalLogger = slog.New(newGCPHandler(cachedWriter, cfg.LogLevel))
 }
 
 func init() {
-	cachedWriter = &lazyWriter{}
+	cachedWriter = &lazyWriter{open: getWriter}
+	cachedFormatter = &GCPFormatter{}
 	globalCfg.set(Config{LogLevel: slog.LevelInfo})
-	globalLogger = slog.New(newGCPHandler(cachedWriter, slog.LevelInfo))
+	levels.base.Set(slog.LevelInfo)
+	globalLogger = slog.New(newHandler(cachedWriter, &levels.base, cachedFormatter))
 }
 
-// SetLogLevel updates the log level. Kept for backward compatibility.
+// SetLogLevel updates the global log level; per-logger overrides (SetLevel) still take precedence.
 func SetLogLevel(level slog.Level) {
 	if cachedWriter == nil {
-		cachedWriter = &lazyWriter{}
+		cachedWriter = &lazyWriter{open: getWriter}
 	}
 	cfg := globalCfg.get()
 	cfg.LogLevel = level
 	globalCfg.set(cfg)
-	globalLogger = slog.New(newGCPHandler(cachedWriter, level))
+	levels.base.Set(level)
+	globalLogger = slog.New(newHandler(cachedWriter, &levels.base, cachedFormatter))
 }
 
 // GetLogger returns the global slog.Logger.
 func GetLogger() *slog.Logger {
 	if globalLogger == nil {
-		globalLogger = slog.New(newGCPHandler(getWriter(), slog.LevelInfo))
+		globalLogger = slog.New(newHandler(getWriter(), &levels.base, nil))
 	}
 	return globalLogger
 }
 
-// GetWriter returns the io.Writer used for logging (e.g. for GORM).
+// GetWriter returns the io.Writer used for logging (e.g. for GORM): the configured output, stderr by default.
 func GetWriter() io.Writer {
 	if cachedWriter == nil {
 		cachedWriter = getWriter()
@@ -451,9 +523,11 @@ func Errorf(format string, args ...interface{}) {
 	logf(context.Background(), slog.LevelError, callerSkip, format, args...)
 }
 
-// Fatalf logs at level Error and exits with code 1. Use only for startup failures; in request handlers use Errorf + abort.
+// Fatalf logs at level Error, flushes queued entries, and exits with code 1. Use only for startup failures;
+// in request handlers use Errorf + abort.
 func Fatalf(format string, args ...interface{}) {
 	logf(context.Background(), slog.LevelError, callerSkip, format, args...)
+	flushBeforeExit()
 	os.Exit(1)
 }
 
@@ -507,6 +581,14 @@ func WithContext(ctx context.Context) *Ctx {
 	return &Ctx{ctx: ctx}
 }
 
+// With returns a child logger whose entries also carry fields (see WithFields).
+func (c *Ctx) With(fields Fields) *Ctx {
+	return &Ctx{ctx: WithFields(c.ctx, fields)}
+}
+
+// Context returns the context the logger is bound to, including fields added with With.
+func (c *Ctx) Context() context.Context { return c.ctx }
+
 func (c *Ctx) logf(level slog.Level, format string, args ...interface{}) {
 	logf(c.ctx, level, callerSkip, format, args...)
 }
@@ -527,9 +609,10 @@ func (c *Ctx) Warnf(format string, args ...interface{}) { c.logf(slog.LevelWarn,
 // Errorf logs at level Error with context.
 func (c *Ctx) Errorf(format string, args ...interface{}) { c.logf(slog.LevelError, format, args...) }
 
-// Fatalf logs at Error and exits. Do not use in request context; use Errorf + abort.
+// Fatalf logs at Error, flushes queued entries, and exits. Do not use in request context; use Errorf + abort.
 func (c *Ctx) Fatalf(format string, args ...interface{}) {
 	c.logf(slog.LevelError, format, args...)
+	flushBeforeExit()
 	os.Exit(1)
 }
 
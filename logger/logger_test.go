@@ -1,9 +1,16 @@
 package logger
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"reflect"
+	"sync"
 	"testing"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestDebugf(t *testing.T) {
@@ -49,3 +56,135 @@ func TestWithContext(t *testing.T) {
 	log.Info("context-bound info with fields", Fields{"key": "value"})
 	// Fatalf would exit; skip in test
 }
+
+func TestGetTraceID_PrefersSpanContext(t *testing.T) {
+	tid, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	sid, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := WithTraceID(context.Background(), "from-header")
+	ctx = WithSpanID(ctx, "from-header-span")
+	if got := GetTraceID(ctx); got != "from-header" {
+		t.Errorf("GetTraceID without span context = %q, want from-header", got)
+	}
+
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled,
+	}))
+	if got := GetTraceID(ctx); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("GetTraceID = %q, want span context trace ID", got)
+	}
+	if got := GetSpanID(ctx); got != "00f067aa0ba902b7" {
+		t.Errorf("GetSpanID = %q, want span context span ID", got)
+	}
+}
+
+// captureEntry logs through a handler writing to a buffer with cfg and returns the decoded entry.
+func captureEntry(t *testing.T, cfg Config, log func(l *slog.Logger)) map[string]interface{} {
+	t.Helper()
+	prev := globalCfg.get()
+	globalCfg.set(cfg)
+	defer globalCfg.set(prev)
+
+	var buf bytes.Buffer
+	log(slog.New(newHandler(&buf, slog.LevelDebug, newFormatter(cfg, &buf))))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	return entry
+}
+
+func TestHandler_WithAttrsAndGroups(t *testing.T) {
+	entry := captureEntry(t, Config{}, func(l *slog.Logger) {
+		l.With("order_id", "ord-1").
+			WithGroup("payment").With("method", "card").
+			WithGroup("card").Info("charged", "last4", "4242", slog.Group("issuer", "name", "Visa"))
+	})
+	if entry["order_id"] != "ord-1" {
+		t.Errorf("order_id = %v, want ord-1", entry["order_id"])
+	}
+	want := map[string]interface{}{
+		"method": "card",
+		"card":   map[string]interface{}{"last4": "4242", "issuer": map[string]interface{}{"name": "Visa"}},
+	}
+	if !reflect.DeepEqual(entry["payment"], want) {
+		t.Errorf("payment = %v, want %v", entry["payment"], want)
+	}
+
+	entry = captureEntry(t, Config{}, func(l *slog.Logger) {
+		l.WithGroup("empty").Info("no attrs")
+	})
+	if _, ok := entry["empty"]; ok {
+		t.Error("empty group must be omitted")
+	}
+}
+
+func TestHandler_WithFieldsAndLabels(t *testing.T) {
+	cfg := Config{
+		ServiceName:    "payments-api",
+		ServiceVersion: "1.2.3",
+		Environment:    "staging",
+		Redact: func(key string, v interface{}) interface{} {
+			if key == "pin" {
+				return "***"
+			}
+			return v
+		},
+	}
+	ctx := WithFields(context.Background(), Fields{"tenant": "t-1", "request": "base"})
+	ctx = WithFields(ctx, Fields{"pin": "1234"})
+	entry := captureEntry(t, cfg, func(l *slog.Logger) {
+		l.With("request", "handler").InfoContext(ctx, "msg", "step", "validate")
+	})
+	for k, want := range map[string]interface{}{"tenant": "t-1", "pin": "***", "request": "handler", "step": "validate"} {
+		if entry[k] != want {
+			t.Errorf("%s = %v, want %v", k, entry[k], want)
+		}
+	}
+	labels := map[string]interface{}{"service": "payments-api", "version": "1.2.3", "environment": "staging"}
+	if !reflect.DeepEqual(entry["logging.googleapis.com/labels"], labels) {
+		t.Errorf("labels = %v, want %v", entry["logging.googleapis.com/labels"], labels)
+	}
+
+	entry = captureEntry(t, Config{}, func(l *slog.Logger) { l.Info("no labels") })
+	if _, ok := entry["logging.googleapis.com/labels"]; ok {
+		t.Error("labels must be omitted when no service fields are set")
+	}
+}
+
+func TestHandler_GroupDoesNotWriteContextFields(t *testing.T) {
+	payment := map[string]interface{}{"id": "pay-1"}
+	ctx := WithFields(context.Background(), Fields{"payment": payment})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := slog.New(newHandler(io.Discard, slog.LevelDebug, newFormatter(Config{}, io.Discard)))
+			for range 100 {
+				l.WithGroup("payment").InfoContext(ctx, "charged", "status", "ok")
+			}
+		}()
+	}
+	wg.Wait()
+	if len(payment) != 1 {
+		t.Errorf("context field modified: %v", payment)
+	}
+
+	entry := captureEntry(t, Config{}, func(l *slog.Logger) {
+		l.WithGroup("payment").InfoContext(ctx, "charged", "status", "ok")
+	})
+	if want := map[string]interface{}{"id": "pay-1", "status": "ok"}; !reflect.DeepEqual(entry["payment"], want) {
+		t.Errorf("payment = %v, want %v", entry["payment"], want)
+	}
+}
+
+func TestCtx_With(t *testing.T) {
+	base := WithContext(context.Background())
+	child := base.With(Fields{"order_id": "ord-1"})
+	if got := GetFields(child.Context())["order_id"]; got != "ord-1" {
+		t.Errorf("child field = %v, want ord-1", got)
+	}
+	if GetFields(base.Context()) != nil {
+		t.Error("With must not modify the parent logger")
+	}
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// dropReasonFull: the new entry was dropped because the async buffer was full.
+	dropReasonFull = "full"
+	// dropReasonEvicted: a queued entry was evicted for a higher-severity one (OverflowDropLowest).
+	dropReasonEvicted = "evicted"
+)
+
+// droppedEntriesTotal counts from the start; it is exported once RegisterMetrics is called.
+var droppedEntriesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "log_dropped_entries_total",
+		Help: "Total number of log entries dropped by the async writer, by severity and reason.",
+	},
+	[]string{"severity", "reason"},
+)
+
+// RegisterMetrics registers log_dropped_entries_total on reg (prometheus.DefaultRegisterer when nil).
+// Registering it again on the same registry is a no-op. server.New registers it on Options.Registerer.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	if err := reg.Register(droppedEntriesTotal); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordDropped(level slog.Level, reason string) {
+	droppedEntriesTotal.WithLabelValues(gcpSeverity(level), reason).Inc()
+}
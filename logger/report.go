@@ -0,0 +1,421 @@
+package logger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorEventType is the @type that makes Cloud Error Reporting ingest a log entry as an error event.
+const ErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+const (
+	defaultReportRateLimit = 10
+	defaultReportWindow    = time.Minute
+	defaultReportMaxGroups = 1000
+	maxReportFrames        = 64
+)
+
+// ErrorReportingConfig configures the error reporter (Config.ErrorReporting).
+type ErrorReportingConfig struct {
+	// Enabled turns on ReportError and ReportPanic; when false they do nothing.
+	Enabled bool
+	// RateLimit is the number of events reported per fingerprint per Window; default 10. Further occurrences
+	// are counted and the count is attached to the next reported event of the group.
+	RateLimit int
+	// Window is the rate limit window; default 1m.
+	Window time.Duration
+	// MaxGroups bounds the number of fingerprints tracked; the least recently seen group is evicted. Default 1000.
+	MaxGroups int
+	// Hooks receive every reported (not rate-limited) event after it is logged, e.g. to forward it to
+	// another error tracker. Hooks run synchronously on the reporting goroutine; panics are recovered.
+	Hooks []ReportHook
+}
+
+// ErrorEvent is one reported occurrence of an error or panic.
+type ErrorEvent struct {
+	Time time.Time
+	// Err is the reported error; nil for panics with a non-error value.
+	Err error
+	// Message is the error text, or the panic value for panics.
+	Message string
+	// Type is the Go type of the innermost wrapped error (or of the panic value), e.g. "*net.OpError".
+	Type string
+	// Fingerprint identifies the group: a hash of Type and the function names of Stack.
+	Fingerprint string
+	Panic       bool
+	// Stack is the call stack, starting at the reporting (or panicking) function.
+	Stack []runtime.Frame
+	// Location is the first frame of Stack.
+	Location *SourceLocation
+	// HTTPRequest is the request in the context (WithHTTPRequest), if any.
+	HTTPRequest *HTTPRequest
+	// Status is the HTTP response status, when known (ReportStatus).
+	Status int
+	// Count is the number of occurrences of the group so far, including this one.
+	Count int64
+	// Suppressed is the number of occurrences dropped by the rate limiter since the group's last reported event.
+	Suppressed int64
+}
+
+// ReportHook receives reported error events (ErrorReportingConfig.Hooks).
+type ReportHook interface {
+	Report(ctx context.Context, ev *ErrorEvent)
+}
+
+// ReportHookFunc adapts a function to ReportHook.
+type ReportHookFunc func(ctx context.Context, ev *ErrorEvent)
+
+// Report implements ReportHook.
+func (f ReportHookFunc) Report(ctx context.Context, ev *ErrorEvent) { f(ctx, ev) }
+
+// ErrorGroup summarizes the occurrences of one fingerprint.
+type ErrorGroup struct {
+	Fingerprint string    `json:"fingerprint"`
+	Type        string    `json:"type"`
+	Message     string    `json:"message"` // of the first occurrence
+	Count       int64     `json:"count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// ReportOption customizes one ReportError or ReportPanic call.
+type ReportOption func(*reportOptions)
+
+type reportOptions struct {
+	skip   int
+	status int
+}
+
+// ReportCallerSkip skips n additional frames of the reporting goroutine's stack, so helpers that report on
+// behalf of their caller (e.g. handler.HandleServiceError) are not the report location.
+func ReportCallerSkip(n int) ReportOption {
+	return func(o *reportOptions) { o.skip += n }
+}
+
+// ReportStatus records the HTTP response status written for the error.
+func ReportStatus(status int) ReportOption {
+	return func(o *reportOptions) { o.status = status }
+}
+
+// errorReporter groups events by fingerprint and rate-limits them per group.
+type errorReporter struct {
+	cfg ErrorReportingConfig
+	now func() time.Time
+
+	mu     sync.Mutex
+	groups map[string]*errorGroup
+}
+
+type errorGroup struct {
+	ErrorGroup
+	windowStart time.Time
+	inWindow    int
+	suppressed  int64
+}
+
+var reporter atomic.Pointer[errorReporter]
+
+func newErrorReporter(cfg ErrorReportingConfig) *errorReporter {
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = defaultReportRateLimit
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultReportWindow
+	}
+	if cfg.MaxGroups <= 0 {
+		cfg.MaxGroups = defaultReportMaxGroups
+	}
+	return &errorReporter{cfg: cfg, now: time.Now, groups: make(map[string]*errorGroup)}
+}
+
+// ReportError reports err to Cloud Error Reporting as a log entry with @type ErrorEventType, grouped by
+// error type and stack fingerprint and rate-limited per group (Config.ErrorReporting). The stack starts at
+// the caller. It is a no-op when err is nil or error reporting is disabled.
+func ReportError(ctx context.Context, err error, opts ...ReportOption) {
+	r := reporter.Load()
+	if r == nil || err == nil {
+		return
+	}
+	o := reportOptions{skip: 3} // runtime.Callers, r.capture, ReportError
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ev := &ErrorEvent{Err: err, Message: err.Error(), Type: rootType(err), Status: o.status}
+	ev.Stack = r.capture(o.skip, false)
+	r.report(ctx, ev)
+}
+
+// ReportPanic reports a recovered panic value. Call it from the deferred function that called recover, so the
+// stack starts at the panicking function:
+//
+//	defer func() {
+//	    if v := recover(); v != nil {
+//	        logger.ReportPanic(ctx, v)
+//	    }
+//	}()
+func ReportPanic(ctx context.Context, recovered interface{}, opts ...ReportOption) {
+	r := reporter.Load()
+	if r == nil || recovered == nil {
+		return
+	}
+	o := reportOptions{skip: 3}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ev := &ErrorEvent{Panic: true, Status: o.status}
+	if err, ok := recovered.(error); ok {
+		ev.Err = err
+		ev.Message = err.Error()
+		ev.Type = rootType(err)
+	} else {
+		ev.Message = fmt.Sprint(recovered)
+		ev.Type = fmt.Sprintf("%T", recovered)
+	}
+	ev.Stack = r.capture(o.skip, true)
+	r.report(ctx, ev)
+}
+
+// ErrorGroups returns the error groups seen since Init, most frequent first.
+func ErrorGroups() []ErrorGroup {
+	r := reporter.Load()
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	groups := make([]ErrorGroup, 0, len(r.groups))
+	for _, g := range r.groups {
+		groups = append(groups, g.ErrorGroup)
+	}
+	r.mu.Unlock()
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Fingerprint < groups[j].Fingerprint
+	})
+	return groups
+}
+
+// capture returns the stack above skip frames. For panics, frames up to and including the runtime's panic
+// machinery are dropped so the stack starts at the panicking function.
+func (r *errorReporter) capture(skip int, panicked bool) []runtime.Frame {
+	pcs := make([]uintptr, maxReportFrames)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]runtime.Frame, 0, n)
+	for {
+		f, more := frames.Next()
+		stack = append(stack, f)
+		if !more {
+			break
+		}
+	}
+	if panicked {
+		for i, f := range stack {
+			if f.Function != "runtime.gopanic" {
+				continue
+			}
+			j := i + 1
+			for j < len(stack) && strings.HasPrefix(stack[j].Function, "runtime.") {
+				j++ // runtime.panicmem, runtime.sigpanic, ...
+			}
+			if j < len(stack) {
+				stack = stack[j:]
+			}
+			break
+		}
+	}
+	return stack
+}
+
+func (r *errorReporter) report(ctx context.Context, ev *ErrorEvent) {
+	now := r.now()
+	ev.Time = now
+	ev.Fingerprint = fingerprint(ev.Type, ev.Stack)
+	if len(ev.Stack) > 0 {
+		f := ev.Stack[0]
+		ev.Location = &SourceLocation{File: filepath.Base(f.File), Line: f.Line, Function: f.Function}
+	}
+	ev.HTTPRequest = GetHTTPRequest(ctx)
+	if !r.admit(ev, now) {
+		return
+	}
+	writeErrorEvent(ctx, ev)
+	for _, h := range r.cfg.Hooks {
+		runHook(ctx, h, ev)
+	}
+}
+
+// admit records the occurrence in its group and reports whether the rate limit lets it through; admitted
+// events carry the group's count and the number suppressed since the last one.
+func (r *errorReporter) admit(ev *ErrorEvent, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[ev.Fingerprint]
+	if !ok {
+		if len(r.groups) >= r.cfg.MaxGroups {
+			r.evictOldest()
+		}
+		g = &errorGroup{ErrorGroup: ErrorGroup{
+			Fingerprint: ev.Fingerprint,
+			Type:        ev.Type,
+			Message:     ev.Message,
+			FirstSeen:   now,
+		}}
+		r.groups[ev.Fingerprint] = g
+	}
+	g.Count++
+	g.LastSeen = now
+	ev.Count = g.Count
+	if now.Sub(g.windowStart) >= r.cfg.Window {
+		g.windowStart = now
+		g.inWindow = 0
+	}
+	if g.inWindow >= r.cfg.RateLimit {
+		g.suppressed++
+		return false
+	}
+	g.inWindow++
+	ev.Suppressed = g.suppressed
+	g.suppressed = 0
+	return true
+}
+
+func (r *errorReporter) evictOldest() {
+	var oldest *errorGroup
+	for _, g := range r.groups {
+		if oldest == nil || g.LastSeen.Before(oldest.LastSeen) {
+			oldest = g
+		}
+	}
+	if oldest != nil {
+		delete(r.groups, oldest.Fingerprint)
+	}
+}
+
+func runHook(ctx context.Context, h ReportHook, ev *ErrorEvent) {
+	defer func() {
+		if v := recover(); v != nil {
+			GetLogger().LogAttrs(ctx, slog.LevelWarn, "error report hook panicked", slog.String("panic", fmt.Sprint(v)))
+		}
+	}()
+	h.Report(ctx, ev)
+}
+
+// writeErrorEvent logs ev in the Cloud Error Reporting format: @type, serviceContext, context.reportLocation
+// and context.httpRequest, with the stack in the message as Go prints it. Panics are logged at CRITICAL.
+func writeErrorEvent(ctx context.Context, ev *ErrorEvent) {
+	cfg := globalCfg.get()
+	level := slog.LevelError
+	if ev.Panic {
+		level = LevelCritical
+	}
+	errCtx := map[string]interface{}{}
+	attrs := []slog.Attr{
+		slog.String("@type", ErrorEventType),
+		slog.String("error_type", ev.Type),
+		slog.String("error_fingerprint", ev.Fingerprint),
+		slog.Int64("error_count", ev.Count),
+	}
+	if ev.Suppressed > 0 {
+		attrs = append(attrs, slog.Int64("error_suppressed", ev.Suppressed))
+	}
+	if cfg.ServiceName != "" {
+		sc := map[string]string{"service": cfg.ServiceName}
+		if cfg.ServiceVersion != "" {
+			sc["version"] = cfg.ServiceVersion
+		}
+		attrs = append(attrs, slog.Any("serviceContext", sc))
+	}
+	if loc := ev.Location; loc != nil {
+		errCtx["reportLocation"] = map[string]interface{}{
+			"filePath":     loc.File,
+			"lineNumber":   loc.Line,
+			"functionName": loc.Function,
+		}
+		attrs = append(attrs, slog.String("file", loc.File), slog.Int("line", loc.Line), slog.String("function", loc.Function))
+	}
+	if hr := ev.HTTPRequest; hr != nil || ev.Status != 0 {
+		req := map[string]interface{}{}
+		if hr != nil {
+			setIf(req, "method", hr.RequestMethod)
+			setIf(req, "url", hr.RequestURL)
+			setIf(req, "userAgent", hr.UserAgent)
+			setIf(req, "remoteIp", hr.RemoteIP)
+			if hr.Status != 0 {
+				req["responseStatusCode"] = hr.Status
+			}
+		}
+		if ev.Status != 0 {
+			req["responseStatusCode"] = ev.Status
+		}
+		errCtx["httpRequest"] = req
+	}
+	if len(errCtx) > 0 {
+		attrs = append(attrs, slog.Any("context", errCtx))
+	}
+	GetLogger().LogAttrs(ctx, level, errorEventMessage(ev), attrs...)
+}
+
+// errorEventMessage formats ev as Error Reporting parses Go errors: the message (prefixed "panic: " for
+// panics), a blank line, and the stack in runtime/debug.Stack form.
+func errorEventMessage(ev *ErrorEvent) string {
+	var b strings.Builder
+	if ev.Panic {
+		b.WriteString("panic: ")
+	}
+	b.WriteString(ev.Message)
+	if len(ev.Stack) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n\ngoroutine 1 [running]:\n")
+	for _, f := range ev.Stack {
+		fmt.Fprintf(&b, "%s(...)\n\t%s:%d +0x%x\n", f.Function, f.File, f.Line, f.PC-f.Entry)
+	}
+	return b.String()
+}
+
+// fingerprint hashes the error type and the stack's function names. Line numbers are left out so a group
+// survives unrelated edits to the same functions.
+func fingerprint(typ string, stack []runtime.Frame) string {
+	h := sha256.New()
+	h.Write([]byte(typ))
+	for _, f := range stack {
+		h.Write([]byte{'\n'})
+		h.Write([]byte(f.Function))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// rootType returns the Go type of the innermost error in err's Unwrap chain. For errors wrapping several
+// (errors.Join, Unwrap() []error) it follows the first non-nil one.
+func rootType(err error) string {
+	for {
+		var next error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			next = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				if e != nil {
+					next = e
+					break
+				}
+			}
+		}
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reportTestError struct{ msg string }
+
+func (e *reportTestError) Error() string { return e.msg }
+
+// withReporting initializes the logger with error reporting into a buffer and restores the previous config.
+func withReporting(t *testing.T, cfg ErrorReportingConfig) *bytes.Buffer {
+	t.Helper()
+	prev := globalCfg.get()
+	t.Cleanup(func() { Init(prev) })
+	var buf bytes.Buffer
+	cfg.Enabled = true
+	Init(Config{LogLevel: slog.LevelInfo, Writer: &buf, ServiceName: "payments", ServiceVersion: "1.2.3", ErrorReporting: cfg})
+	return &buf
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var e map[string]interface{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func reportFromA(ctx context.Context, err error) { ReportError(ctx, err) }
+func reportFromB(ctx context.Context, err error) { ReportError(ctx, err) }
+
+func TestReportError_GCPFormat(t *testing.T) {
+	buf := withReporting(t, ErrorReportingConfig{})
+	ctx := WithHTTPRequest(context.Background(), &HTTPRequest{RequestMethod: "POST", RequestURL: "/pay", UserAgent: "test"})
+	err := fmt.Errorf("charge: %w", &reportTestError{msg: "card declined"})
+	ReportError(ctx, err, ReportStatus(500))
+
+	entries := decodeLines(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e["@type"] != ErrorEventType || e["severity"] != "ERROR" {
+		t.Errorf("@type = %v, severity = %v", e["@type"], e["severity"])
+	}
+	if e["error_type"] != "*logger.reportTestError" {
+		t.Errorf("error_type = %v, want *logger.reportTestError", e["error_type"])
+	}
+	msg, _ := e["message"].(string)
+	if !strings.HasPrefix(msg, "charge: card declined\n\ngoroutine 1 [running]:\n") ||
+		!strings.Contains(msg, "TestReportError_GCPFormat") {
+		t.Errorf("message = %q, want error text followed by the stack", msg)
+	}
+	sc, _ := e["serviceContext"].(map[string]interface{})
+	if sc["service"] != "payments" || sc["version"] != "1.2.3" {
+		t.Errorf("serviceContext = %v", e["serviceContext"])
+	}
+	errCtx, _ := e["context"].(map[string]interface{})
+	loc, _ := errCtx["reportLocation"].(map[string]interface{})
+	if fn, _ := loc["functionName"].(string); !strings.HasSuffix(fn, "TestReportError_GCPFormat") {
+		t.Errorf("reportLocation = %v, want the calling test", loc)
+	}
+	req, _ := errCtx["httpRequest"].(map[string]interface{})
+	if req["method"] != "POST" || req["url"] != "/pay" || req["responseStatusCode"] != float64(500) {
+		t.Errorf("httpRequest = %v", req)
+	}
+}
+
+func TestReportError_GroupsAndRateLimit(t *testing.T) {
+	var hooked []*ErrorEvent
+	buf := withReporting(t, ErrorReportingConfig{
+		RateLimit: 2,
+		Window:    time.Minute,
+		Hooks:     []ReportHook{ReportHookFunc(func(_ context.Context, ev *ErrorEvent) { hooked = append(hooked, ev) })},
+	})
+	r := reporter.Load()
+	now := time.Unix(1_700_000_000, 0)
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		reportFromA(ctx, errors.New("boom"))
+	}
+	reportFromB(ctx, errors.New("boom"))
+	reportFromA(ctx, &reportTestError{msg: "boom"})
+
+	if got := len(decodeLines(t, buf)); got != 4 {
+		t.Fatalf("got %d entries, want 4 (2 of A, 1 of B, 1 of A with another type)", got)
+	}
+	if len(hooked) != 4 {
+		t.Fatalf("hook called %d times, want 4", len(hooked))
+	}
+	if hooked[0].Fingerprint != hooked[1].Fingerprint {
+		t.Error("same call site and type: fingerprints differ")
+	}
+	if hooked[0].Fingerprint == hooked[2].Fingerprint || hooked[0].Fingerprint == hooked[3].Fingerprint {
+		t.Error("different stack or type: fingerprints equal")
+	}
+
+	groups := ErrorGroups()
+	if len(groups) != 3 || groups[0].Fingerprint != hooked[0].Fingerprint || groups[0].Count != 5 {
+		t.Fatalf("groups = %+v, want group A first with count 5", groups)
+	}
+
+	now = now.Add(time.Minute)
+	reportFromA(ctx, errors.New("boom"))
+	if ev := hooked[len(hooked)-1]; ev.Suppressed != 3 || ev.Count != 6 {
+		t.Errorf("after window: suppressed = %d, count = %d; want 3, 6", ev.Suppressed, ev.Count)
+	}
+	last := decodeLines(t, buf)
+	if e := last[len(last)-1]; e["error_suppressed"] != float64(3) {
+		t.Errorf("error_suppressed = %v, want 3", e["error_suppressed"])
+	}
+}
+
+func panicker() { panic("nil map write") }
+
+type codeError struct{ code int }
+
+func (e *codeError) Error() string { return "code " + strconv.Itoa(e.code) }
+
+func TestRootType(t *testing.T) {
+	base := &codeError{code: 1}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"plain", base, "*logger.codeError"},
+		{"wrapped", fmt.Errorf("charge: %w", base), "*logger.codeError"},
+		{"joined", errors.Join(nil, fmt.Errorf("charge: %w", base), errors.New("other")), "*logger.codeError"},
+		{"multiple %w", fmt.Errorf("a: %w, b: %w", base, io.EOF), "*logger.codeError"},
+	}
+	for _, tt := range tests {
+		if got := rootType(tt.err); got != tt.want {
+			t.Errorf("%s: rootType = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReportPanic_StackStartsAtPanic(t *testing.T) {
+	var ev *ErrorEvent
+	buf := withReporting(t, ErrorReportingConfig{Hooks: []ReportHook{ReportHookFunc(func(_ context.Context, e *ErrorEvent) { ev = e })}})
+	func() {
+		defer func() {
+			if v := recover(); v != nil {
+				ReportPanic(context.Background(), v)
+			}
+		}()
+		panicker()
+	}()
+
+	if ev == nil || !ev.Panic || ev.Type != "string" {
+		t.Fatalf("event = %+v, want a string panic", ev)
+	}
+	if !strings.HasSuffix(ev.Location.Function, ".panicker") {
+		t.Errorf("location = %+v, want panicker", ev.Location)
+	}
+	e := decodeLines(t, buf)[0]
+	if e["severity"] != "CRITICAL" || !strings.HasPrefix(e["message"].(string), "panic: nil map write\n\ngoroutine") {
+		t.Errorf("severity = %v, message = %q", e["severity"], e["message"])
+	}
+}
+
+func TestReportError_MaxGroupsAndDisabled(t *testing.T) {
+	r := newErrorReporter(ErrorReportingConfig{MaxGroups: 2})
+	now := time.Unix(1_700_000_000, 0)
+	for i, fp := range []string{"a", "b", "c"} {
+		ev := &ErrorEvent{Fingerprint: fp, Type: "t"}
+		r.admit(ev, now.Add(time.Duration(i)*time.Second))
+	}
+	if _, ok := r.groups["a"]; ok || len(r.groups) != 2 {
+		t.Errorf("groups = %v, want a evicted", r.groups)
+	}
+
+	prev := globalCfg.get()
+	defer Init(prev)
+	var buf bytes.Buffer
+	Init(Config{Writer: &buf})
+	ReportError(context.Background(), errors.New("ignored"))
+	if buf.Len() != 0 || ErrorGroups() != nil {
+		t.Errorf("disabled reporter wrote %q", buf.String())
+	}
+}
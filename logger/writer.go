@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output selects the log destination (Config.Output).
+type Output string
+
+const (
+	// OutputStderr writes to standard error (default).
+	OutputStderr Output = "stderr"
+	// OutputStdout writes to standard output.
+	OutputStdout Output = "stdout"
+	// OutputFile writes to Config.File.Path with size-based rotation.
+	OutputFile Output = "file"
+)
+
+const (
+	defaultFileMaxSizeMB  = 100
+	defaultFileMaxBackups = 5
+)
+
+// FileConfig configures OutputFile.
+type FileConfig struct {
+	// Path of the active log file; parent directories are created. Required: with an empty Path, entries
+	// go to stderr.
+	Path string
+	// MaxSizeMB is the size at which the file is rotated; default 100.
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files kept; default 5, negative keeps all.
+	MaxBackups int
+	// MaxAge removes rotated files older than this; 0 keeps them regardless of age.
+	MaxAge time.Duration
+}
+
+// lazyWriter defers resolution of the writer until first Write.
+type lazyWriter struct {
+	once sync.Once
+	open func() io.Writer
+	real io.Writer
+}
+
+func (w *lazyWriter) Write(p []byte) (n int, err error) {
+	w.once.Do(func() { w.real = w.open() })
+	return w.real.Write(p)
+}
+
+func getWriter() io.Writer {
+	return os.Stderr
+}
+
+// newWriter returns the destination for cfg: Writer, a rotating file, stdout, or stderr.
+func newWriter(cfg Config) io.Writer {
+	if cfg.Writer != nil {
+		return cfg.Writer
+	}
+	switch cfg.Output {
+	case OutputFile:
+		if cfg.File.Path != "" {
+			return newRotatingFile(cfg.File)
+		}
+	case OutputStdout:
+		return &lazyWriter{open: func() io.Writer { return os.Stdout }}
+	}
+	return &lazyWriter{open: getWriter}
+}
+
+// isTerminal reports whether w writes to a character device (for console colours).
+func isTerminal(w io.Writer) bool {
+	if lw, ok := w.(*lazyWriter); ok {
+		w = lw.open()
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// rotatingFile is an append-only log file rotated by size. It is opened on first Write and reopened after
+// Close, so loggers still holding it keep working after Init replaces the output.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	file *os.File
+	size int64
+}
+
+func newRotatingFile(cfg FileConfig) *rotatingFile {
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = defaultFileMaxSizeMB
+	}
+	if cfg.MaxBackups == 0 {
+		cfg.MaxBackups = defaultFileMaxBackups
+	}
+	return &rotatingFile{
+		path:       cfg.Path,
+		maxBytes:   int64(cfg.MaxSizeMB) << 20,
+		maxBackups: cfg.MaxBackups,
+		maxAge:     cfg.MaxAge,
+		now:        time.Now,
+	}
+}
+
+// Write appends p, rotating first when p would take the file past its size limit. If the file cannot be
+// opened, p is written to stderr instead so entries are not lost.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return os.Stderr.Write(p)
+		}
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: rotate %s: %v\n", f.path, err)
+			if f.file == nil {
+				return os.Stderr.Write(p)
+			}
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file; a later Write reopens it.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, fi.Size()
+	return nil
+}
+
+// backupTimeLayout is the UTC timestamp in rotated file names.
+const backupTimeLayout = "2006-01-02T15-04-05.000000000"
+
+// rotate renames the current file to <name>-<UTC timestamp><ext>, opens a new one, and prunes backups.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext)
+	backup := prefix + "-" + f.now().UTC().Format(backupTimeLayout) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune(prefix, ext)
+	return nil
+}
+
+// prune removes rotated files beyond maxBackups (oldest first) and older than maxAge. Only names whose
+// middle part is a rotation timestamp count, so siblings such as app-audit.log are left alone.
+func (f *rotatingFile) prune(prefix, ext string) {
+	matches, err := filepath.Glob(prefix + "-*" + ext)
+	if err != nil {
+		return
+	}
+	backups := matches[:0]
+	for _, name := range matches {
+		mid := strings.TrimSuffix(strings.TrimPrefix(name, prefix+"-"), ext)
+		if _, err := time.Parse(backupTimeLayout, mid); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups) // timestamps sort chronologically
+	for i, name := range backups {
+		expired := false
+		if f.maxBackups > 0 && len(backups)-i > f.maxBackups {
+			expired = true
+		} else if f.maxAge > 0 {
+			if fi, err := os.Stat(name); err == nil && f.now().Sub(fi.ModTime()) > f.maxAge {
+				expired = true
+			}
+		}
+		if expired {
+			_ = os.Remove(name)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "app.log")
+	now := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	f := newRotatingFile(FileConfig{Path: path, MaxBackups: 2})
+	f.maxBytes = 10
+	f.now = func() time.Time { now = now.Add(time.Second); return now }
+	foreign := filepath.Join(dir, "logs", "app-audit.log")
+	if err := os.MkdirAll(filepath.Dir(foreign), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(foreign, []byte("audit\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != "dddddd\n" {
+		t.Errorf("active file = %q, want last line", current)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("foreign file pruned: %v", err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "logs", "app-2*.log"))
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2 (MaxBackups)", backups)
+	}
+	oldest, _ := os.ReadFile(backups[0])
+	if string(oldest) != "bbbbbb\n" {
+		t.Errorf("oldest kept backup = %q, want bbbbbb", oldest)
+	}
+
+	// Writes after Close reopen the file.
+	if _, err := f.Write([]byte("e\n")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	current, _ = os.ReadFile(path)
+	if !strings.HasSuffix(string(current), "e\n") {
+		t.Errorf("write after Close not appended: %q", current)
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	if _, ok := newWriter(Config{Output: OutputFile}).(*lazyWriter); !ok {
+		t.Error("OutputFile without a path must fall back to stderr")
+	}
+	if _, ok := newWriter(Config{Output: OutputFile, File: FileConfig{Path: "x.log"}}).(*rotatingFile); !ok {
+		t.Error("OutputFile must use a rotating file")
+	}
+	if w := newWriter(Config{Output: OutputStdout}).(*lazyWriter); w.open() != os.Stdout {
+		t.Error("OutputStdout must resolve to os.Stdout")
+	}
+}
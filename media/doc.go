@@ -0,0 +1,29 @@
+/*
+Package media generates and serves resized variants of stored images (profile pictures, merchant logos) in pure
+Go.
+
+Role in architecture:
+  - Infrastructure service over storage.Storage (GCS through storage.NewGCS in production, Memory in tests):
+    originals are stored once and clients download small variants instead.
+
+Responsibilities:
+  - Media (New, Put, Generate, Variant, Invalidate): Variant specifications (Width, Height, Fit inside or
+    cover, Format JPEG/PNG/WebP, Quality) are rendered eagerly in Put (Options.Eager) or on first request
+    with singleflight, and stored under deterministic keys (VariantKey) that include a hash of the
+    specification. Put deletes the variants of a replaced original, and Variant regenerates a variant whose
+    source ETag (MetadataSourceETag) no longer matches the original's, e.g. one rendered concurrently with Put.
+  - Handler: Gin handler serving originals and variants with Content-Type, ETag, Last-Modified,
+    Cache-Control (Options.CacheControl), nosniff, and 304 on If-None-Match; only keys under
+    Options.OriginalPrefix (required) and image/* originals (not SVG) are served.
+
+Constraints:
+  - Pure Go: decoding via image/jpeg, image/png, image/gif, and golang.org/x/image/webp; resampling with
+    golang.org/x/image/draw (Catmull-Rom); WebP output is lossless (github.com/HugoSmits86/nativewebp).
+  - Sources are never upscaled; originals above Options.MaxBytes (default 50 MiB, checked before reading)
+    or Options.MaxPixels (default 50 MP, checked from the header before decoding) are refused.
+  - Originals are decoded in memory; validate uploads first (package upload), which also strips EXIF.
+
+This package must NOT:
+  - Validate or scan uploads, or decide object keys for originals; only image variants.
+*/
+package media
@@ -0,0 +1,102 @@
+package media
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/response"
+	"github.com/turahe/pkg/storage"
+)
+
+// Handler returns a Gin handler serving originals and variants for a route with a "*key" wildcard:
+//
+//	r.GET("/media/*key", m.Handler())   // GET /media/avatars/u-1.jpg?variant=thumb
+//
+// The "variant" query parameter selects a configured variant, generated on first request; without it the
+// original is served if its content type is image/* (except SVG). Keys outside Options.OriginalPrefix and
+// originals of any other type get 404, so other objects in a shared store are never exposed. Responses
+// carry Content-Type, Content-Length, ETag, Last-Modified, Options.CacheControl, and X-Content-Type-Options:
+// nosniff, and If-None-Match gets 304. Missing images get 404; unknown variants (a validation error) and
+// originals that are not images get 422.
+// Panics if Options.OriginalPrefix is empty: the handler never serves a whole store.
+func (m *Media) Handler() gin.HandlerFunc {
+	if m.opts.OriginalPrefix == "" {
+		panic("media: Handler requires Options.OriginalPrefix")
+	}
+	return func(ctx *gin.Context) {
+		key := strings.TrimPrefix(ctx.Param("key"), "/")
+		reqCtx := ctx.Request.Context()
+		if !strings.HasPrefix(key, m.opts.OriginalPrefix) {
+			m.fail(ctx, key, storage.ErrNotFound)
+			return
+		}
+
+		var info *storage.ObjectInfo
+		var err error
+		if name := ctx.Query("variant"); name != "" {
+			info, err = m.Variant(reqCtx, key, name)
+		} else {
+			info, err = m.store.Stat(reqCtx, key)
+			if err == nil && !servable(info.ContentType) {
+				err = storage.ErrNotFound
+			}
+		}
+		if err != nil {
+			m.fail(ctx, key, err)
+			return
+		}
+
+		etag := info.ETag
+		if etag != "" && !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+			etag = `"` + etag + `"`
+		}
+		headers := map[string]string{
+			"Cache-Control":          m.opts.CacheControl,
+			"X-Content-Type-Options": "nosniff",
+		}
+		if etag != "" {
+			headers["ETag"] = etag
+		}
+		if !info.Updated.IsZero() {
+			headers["Last-Modified"] = info.Updated.UTC().Format(http.TimeFormat)
+		}
+		if etag != "" && ctx.GetHeader("If-None-Match") == etag {
+			for k, v := range headers {
+				ctx.Header(k, v)
+			}
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		rc, err := m.store.Get(reqCtx, info.Key)
+		if err != nil {
+			m.fail(ctx, key, err)
+			return
+		}
+		defer rc.Close()
+		ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, rc, headers)
+	}
+}
+
+// servable reports whether an original of contentType may be served. SVG is excluded: it can carry script.
+func servable(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "image/svg")
+}
+
+func (m *Media) fail(ctx *gin.Context, key string, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownVariant):
+		response.ValidationErrorSimple(ctx, response.ServiceCodeCommon, "variant", "unknown variant")
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrInvalidKey):
+		response.NotFoundError(ctx, response.ServiceCodeCommon, response.CaseCodeResourceNotFound, "Media not found")
+	case errors.Is(err, ErrUnsupportedImage), errors.Is(err, ErrImageTooLarge):
+		response.FailWithDetailed(ctx, http.StatusUnprocessableEntity, response.ServiceCodeCommon, response.CaseCodeInvalidFormat, nil, "Media is not a supported image")
+	default:
+		logger.ErrorfContext(ctx.Request.Context(), "failed to serve media %s: %v", key, err)
+		response.FailWithDetailed(ctx, http.StatusInternalServerError, response.ServiceCodeCommon, response.CaseCodeInternalError, nil, "Internal Server Error")
+	}
+}
@@ -0,0 +1,103 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/turahe/pkg/storage"
+)
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, _ := newTestMedia(WithOriginalPrefix("avatars/"), WithCacheControl("public, max-age=600"))
+	m.Put(context.Background(), "avatars/u-1.jpg", bytes.NewReader(testJPEG(t, 400, 300)), storage.PutOptions{})
+	m.Put(context.Background(), "avatars/readme.txt", bytes.NewReader([]byte("hello")), storage.PutOptions{})
+	r := gin.New()
+	r.GET("/media/*key", m.Handler())
+
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/media/avatars/u-1.jpg?variant=webp", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/webp" {
+		t.Fatalf("variant = %d %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || etag[0] != '"' || w.Header().Get("Cache-Control") != "public, max-age=600" ||
+		w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("Last-Modified") == "" {
+		t.Errorf("headers = %v", w.Header())
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("RIFF")) {
+		t.Error("body is not WebP")
+	}
+
+	if w := get("/media/avatars/u-1.jpg?variant=webp", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("revalidation = %d, %d bytes", w.Code, w.Body.Len())
+	}
+	if w := get("/media/avatars/u-1.jpg", nil); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Errorf("original = %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	for target, want := range map[string]int{
+		"/media/avatars/u-1.jpg?variant=huge":     http.StatusUnprocessableEntity,
+		"/media/avatars/u-2.jpg?variant=thumb":    http.StatusNotFound,
+		"/media/../etc/passwd":                    http.StatusNotFound,
+		"/media/avatars/readme.txt?variant=thumb": http.StatusUnprocessableEntity,
+		"/media/avatars/readme.txt":               http.StatusNotFound,
+	} {
+		if w := get(target, nil); w.Code != want {
+			t.Errorf("%s = %d, want %d", target, w.Code, want)
+		}
+	}
+}
+
+func TestHandler_RequiresOriginalPrefix(t *testing.T) {
+	m, _ := newTestMedia()
+	defer func() {
+		if recover() == nil {
+			t.Error("Handler without OriginalPrefix did not panic")
+		}
+	}()
+	m.Handler()
+}
+
+func TestHandler_OriginalPrefix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, store := newTestMedia(WithOriginalPrefix("avatars/"))
+	ctx := context.Background()
+	m.Put(ctx, "avatars/u-1.jpg", bytes.NewReader(testJPEG(t, 100, 100)), storage.PutOptions{})
+	store.Put(ctx, "kyc/u-1.jpg", bytes.NewReader(testJPEG(t, 100, 100)), storage.PutOptions{})
+	store.Put(ctx, "avatars/u-2.svg", bytes.NewReader([]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)), storage.PutOptions{ContentType: "image/svg+xml"})
+	r := gin.New()
+	r.GET("/media/*key", m.Handler())
+
+	for target, want := range map[string]int{
+		"/media/avatars/u-1.jpg":               http.StatusOK,
+		"/media/avatars/u-1.jpg?variant=thumb": http.StatusOK,
+		"/media/kyc/u-1.jpg":                   http.StatusNotFound,
+		"/media/kyc/u-1.jpg?variant=thumb":     http.StatusNotFound,
+		"/media/avatars/u-2.svg":               http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != want {
+			t.Errorf("%s = %d, want %d", target, w.Code, want)
+		}
+	}
+	key, _ := m.VariantKey("kyc/u-1.jpg", "thumb")
+	if ok, _ := store.Exists(ctx, key); ok {
+		t.Error("variant generated outside OriginalPrefix")
+	}
+}
@@ -0,0 +1,151 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Registered decoders for source images.
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+)
+
+// Fit is how a variant is sized to its Width and Height.
+type Fit string
+
+const (
+	// FitInside scales the image down to fit within Width × Height, keeping its aspect ratio (default).
+	FitInside Fit = "inside"
+	// FitCover scales and center-crops the image to fill Width × Height exactly; it needs both.
+	FitCover Fit = "cover"
+)
+
+// Format is the encoding of a variant.
+type Format string
+
+const (
+	// FormatAuto keeps the source encoding: JPEG stays JPEG, WebP stays WebP, PNG and GIF become PNG (default).
+	FormatAuto Format = ""
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	// FormatWebP encodes lossless WebP (pure Go; there is no pure-Go lossy encoder).
+	FormatWebP Format = "webp"
+)
+
+// Variant specifies a generated image. Sources are never upscaled.
+type Variant struct {
+	// Width and Height bound the output in pixels; 0 leaves that axis unconstrained. Both 0 keeps the size
+	// (e.g. a format-only variant).
+	Width, Height int
+	Fit           Fit
+	Format        Format
+	// Quality is the JPEG quality, 1-100; default 82.
+	Quality int
+}
+
+// decode decodes r after checking its dimensions against maxPixels. Only the header is read before the check;
+// it is replayed to the full decode.
+func decode(r io.Reader, maxPixels int) (image.Image, string, error) {
+	var head bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrUnsupportedImage, err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrImageTooLarge, cfg.Width, cfg.Height, maxPixels)
+	}
+	img, _, err := image.Decode(io.MultiReader(&head, r))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrUnsupportedImage, err)
+	}
+	return img, format, nil
+}
+
+// render resizes src for v and encodes it, returning the bytes and their content type.
+func render(src image.Image, srcFormat string, v Variant) ([]byte, string, error) {
+	img := resize(src, v)
+	format := v.Format
+	if format == FormatAuto {
+		switch srcFormat {
+		case "jpeg":
+			format = FormatJPEG
+		case "webp":
+			format = FormatWebP
+		default:
+			format = FormatPNG
+		}
+	}
+	var buf bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: v.Quality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	case FormatPNG:
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := enc.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	case FormatWebP:
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/webp", nil
+	}
+	return nil, "", fmt.Errorf("unknown image format %q", format)
+}
+
+// resize scales (and for FitCover crops) src to v's bounds with Catmull-Rom resampling.
+func resize(src image.Image, v Variant) image.Image {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	crop := sb
+	w, h := sw, sh
+	switch {
+	case v.Fit == FitCover && v.Width > 0 && v.Height > 0:
+		// Largest centered region with the target aspect ratio.
+		cw, ch := sw, sw*v.Height/v.Width
+		if ch > sh {
+			cw, ch = sh*v.Width/v.Height, sh
+		}
+		x, y := sb.Min.X+(sw-cw)/2, sb.Min.Y+(sh-ch)/2
+		crop = image.Rect(x, y, x+cw, y+ch)
+		w, h = min(v.Width, cw), min(v.Height, ch)
+	case v.Width > 0 || v.Height > 0:
+		scale := 1.0
+		if v.Width > 0 {
+			scale = min(scale, float64(v.Width)/float64(sw))
+		}
+		if v.Height > 0 {
+			scale = min(scale, float64(v.Height)/float64(sh))
+		}
+		w, h = max(int(float64(sw)*scale+0.5), 1), max(int(float64(sh)*scale+0.5), 1)
+	}
+	if crop == sb && w == sw && h == sh {
+		return src
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
+
+// flatten draws img over white, since JPEG has no alpha channel.
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,189 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"io"
+	"maps"
+	"slices"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/turahe/pkg/storage"
+)
+
+var (
+	// ErrUnknownVariant is returned (wrapped) for a variant name that is not configured.
+	ErrUnknownVariant = errors.New("media: unknown variant")
+	// ErrUnsupportedImage is returned (wrapped) when the original is not a decodable JPEG, PNG, GIF, or WebP.
+	ErrUnsupportedImage = errors.New("media: unsupported image")
+	// ErrImageTooLarge is returned (wrapped) when the original exceeds Options.MaxPixels or Options.MaxBytes.
+	ErrImageTooLarge = errors.New("media: image too large")
+)
+
+// Metadata keys set on generated variants.
+const (
+	MetadataVariant    = "variant"
+	MetadataSourceETag = "source-etag"
+)
+
+// Media stores images and their resized variants in a storage.Storage. Variants live under deterministic
+// keys (VariantKey) that change with their specification, so a changed Variant never serves stale output.
+type Media struct {
+	store storage.Storage
+	opts  Options
+	group singleflight.Group
+}
+
+// New returns a Media storing into store (e.g. storage.NewGCS). Options are applied first, then override.
+func New(store storage.Storage, opts Options, override ...Option) *Media {
+	for _, o := range override {
+		o(&opts)
+	}
+	opts.applyDefaults()
+	return &Media{store: store, opts: opts}
+}
+
+// VariantKey returns the key of variant name of the original key:
+// VariantPrefix + key + "/" + name + "-" + a hash of the variant specification.
+func (m *Media) VariantKey(key, name string) (string, error) {
+	v, ok := m.opts.Variants[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownVariant, name)
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%d:%s:%s:%d", v.Width, v.Height, v.Fit, v.Format, v.Quality)
+	return fmt.Sprintf("%s%s/%s-%08x", m.opts.VariantPrefix, key, name, h.Sum32()), nil
+}
+
+// Put stores the original image under key and deletes its previous variants. With Options.Eager it then
+// generates every variant, failing with ErrUnsupportedImage if r is not an image.
+func (m *Media) Put(ctx context.Context, key string, r io.Reader, opts storage.PutOptions) (*storage.ObjectInfo, error) {
+	info, err := m.store.Put(ctx, key, r, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Invalidate(ctx, key); err != nil {
+		return nil, err
+	}
+	if m.opts.Eager {
+		if err := m.Generate(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// Generate (re)generates variants names of key, or every variant when names is empty, decoding the original
+// once.
+func (m *Media) Generate(ctx context.Context, key string, names ...string) error {
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(m.opts.Variants))
+	}
+	for _, name := range names {
+		if _, ok := m.opts.Variants[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownVariant, name)
+		}
+	}
+	src, format, info, err := m.load(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := m.generate(ctx, key, name, src, format, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Variant returns variant name of key, generating it on first use. Concurrent requests for a missing variant
+// generate it once; generation is not cancelled when the first caller's ctx is. A variant whose
+// MetadataSourceETag differs from the original's ETag is regenerated, so a render of a replaced original that
+// finished after Put invalidated the variants is not served.
+func (m *Media) Variant(ctx context.Context, key, name string) (*storage.ObjectInfo, error) {
+	vkey, err := m.VariantKey(key, name)
+	if err != nil {
+		return nil, err
+	}
+	src, err := m.store.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := m.store.Stat(ctx, vkey)
+	switch {
+	case err == nil && info.Metadata[MetadataSourceETag] == src.ETag:
+		return info, nil
+	case err != nil && !errors.Is(err, storage.ErrNotFound):
+		return nil, err
+	}
+	v, err, _ := m.group.Do(vkey, func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+		src, format, srcInfo, err := m.load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return m.generate(ctx, key, name, src, format, srcInfo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*storage.ObjectInfo), nil
+}
+
+// Invalidate deletes every generated variant of key.
+func (m *Media) Invalidate(ctx context.Context, key string) error {
+	for name := range m.opts.Variants {
+		vkey, _ := m.VariantKey(key, name)
+		if err := m.store.Delete(ctx, vkey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// load reads and decodes the original, refusing objects above Options.MaxBytes before reading them. The ETag
+// is taken before the content, so a concurrent replacement yields a variant tagged with the older ETag, which
+// Variant regenerates.
+func (m *Media) load(ctx context.Context, key string) (image.Image, string, *storage.ObjectInfo, error) {
+	info, err := m.store.Stat(ctx, key)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if info.Size > m.opts.MaxBytes {
+		return nil, "", nil, fmt.Errorf("image %s: %w: %d bytes exceeds %d", key, ErrImageTooLarge, info.Size, m.opts.MaxBytes)
+	}
+	rc, err := m.store.Get(ctx, key)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer rc.Close()
+	// The limit also holds if the object grew after Stat.
+	lr := &io.LimitedReader{R: rc, N: m.opts.MaxBytes + 1}
+	img, format, err := decode(lr, m.opts.MaxPixels)
+	if lr.N <= 0 {
+		return nil, "", nil, fmt.Errorf("image %s: %w: exceeds %d bytes", key, ErrImageTooLarge, m.opts.MaxBytes)
+	}
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("image %s: %w", key, err)
+	}
+	return img, format, info, nil
+}
+
+// generate renders and stores one variant.
+func (m *Media) generate(ctx context.Context, key, name string, src image.Image, format string, srcInfo *storage.ObjectInfo) (*storage.ObjectInfo, error) {
+	vkey, _ := m.VariantKey(key, name)
+	data, contentType, err := render(src, format, m.opts.Variants[name])
+	if err != nil {
+		return nil, fmt.Errorf("failed to render variant %s of %s: %w", name, key, err)
+	}
+	return m.store.Put(ctx, vkey, bytes.NewReader(data), storage.PutOptions{
+		ContentType:  contentType,
+		CacheControl: m.opts.CacheControl,
+		Metadata:     map[string]string{MetadataVariant: name, MetadataSourceETag: srcInfo.ETag},
+	})
+}
@@ -0,0 +1,289 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/image/webp"
+
+	"github.com/turahe/pkg/storage"
+)
+
+// countingStore counts Put calls on a Memory store.
+type countingStore struct {
+	*storage.Memory
+	puts atomic.Int32
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, r io.Reader, opts storage.PutOptions) (*storage.ObjectInfo, error) {
+	s.puts.Add(1)
+	return s.Memory.Put(ctx, key, r, opts)
+}
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestMedia(override ...Option) (*Media, *countingStore) {
+	store := &countingStore{Memory: storage.NewMemory()}
+	opts := []Option{
+		WithVariant("thumb", Variant{Width: 64, Height: 64, Fit: FitCover}),
+		WithVariant("medium", Variant{Width: 200}),
+		WithVariant("webp", Variant{Width: 100, Format: FormatWebP}),
+	}
+	return New(store, Options{}, append(opts, override...)...), store
+}
+
+// readImage decodes the stored object key and returns its size and content type.
+func readImage(t *testing.T, s storage.Storage, key string) (image.Point, string) {
+	t.Helper()
+	ctx := context.Background()
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat %s: %v", key, err)
+	}
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	var img image.Image
+	if info.ContentType == "image/webp" {
+		img, err = webp.Decode(rc)
+	} else {
+		img, _, err = image.Decode(rc)
+	}
+	if err != nil {
+		t.Fatalf("decode %s: %v", key, err)
+	}
+	return img.Bounds().Size(), info.ContentType
+}
+
+func TestMedia_LazyVariants(t *testing.T) {
+	m, store := newTestMedia()
+	ctx := context.Background()
+	if _, err := m.Put(ctx, "avatars/u-1.jpg", bytes.NewReader(testJPEG(t, 400, 300)), storage.PutOptions{}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if n := store.puts.Load(); n != 1 {
+		t.Fatalf("puts after lazy Put = %d, want 1", n)
+	}
+
+	tests := []struct {
+		name        string
+		size        image.Point
+		contentType string
+	}{
+		{"thumb", image.Pt(64, 64), "image/jpeg"},
+		{"medium", image.Pt(200, 150), "image/jpeg"},
+		{"webp", image.Pt(100, 75), "image/webp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := m.Variant(ctx, "avatars/u-1.jpg", tt.name)
+			if err != nil {
+				t.Fatalf("Variant: %v", err)
+			}
+			want, _ := m.VariantKey("avatars/u-1.jpg", tt.name)
+			if info.Key != want || !strings.HasPrefix(want, "variants/avatars/u-1.jpg/"+tt.name+"-") {
+				t.Errorf("key = %s, want %s", info.Key, want)
+			}
+			if info.Metadata[MetadataVariant] != tt.name || info.Metadata[MetadataSourceETag] == "" {
+				t.Errorf("metadata = %v", info.Metadata)
+			}
+			size, ct := readImage(t, store, info.Key)
+			if size != tt.size || ct != tt.contentType {
+				t.Errorf("variant = %v %s, want %v %s", size, ct, tt.size, tt.contentType)
+			}
+		})
+	}
+
+	before := store.puts.Load()
+	if _, err := m.Variant(ctx, "avatars/u-1.jpg", "thumb"); err != nil {
+		t.Fatal(err)
+	}
+	if store.puts.Load() != before {
+		t.Error("existing variant regenerated")
+	}
+	if _, err := m.Variant(ctx, "avatars/u-1.jpg", "huge"); !errors.Is(err, ErrUnknownVariant) {
+		t.Errorf("unknown variant: err = %v", err)
+	}
+	if _, err := m.Variant(ctx, "avatars/missing.jpg", "thumb"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("missing original: err = %v", err)
+	}
+}
+
+func TestMedia_VariantGeneratedOnce(t *testing.T) {
+	m, store := newTestMedia()
+	ctx := context.Background()
+	m.Put(ctx, "logo.jpg", bytes.NewReader(testJPEG(t, 300, 300)), storage.PutOptions{})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Variant(ctx, "logo.jpg", "medium"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := store.puts.Load(); n > 2 {
+		t.Errorf("puts = %d, want the original and one variant", n)
+	}
+}
+
+func TestMedia_EagerAndInvalidate(t *testing.T) {
+	m, store := newTestMedia(WithEager())
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	logo := image.NewNRGBA(image.Rect(0, 0, 50, 40)) // transparent PNG smaller than every variant
+	png.Encode(&buf, logo)
+	if _, err := m.Put(ctx, "logos/m-1.png", &buf, storage.PutOptions{}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	for name, want := range map[string]image.Point{"thumb": image.Pt(40, 40), "medium": image.Pt(50, 40), "webp": image.Pt(50, 40)} {
+		key, _ := m.VariantKey("logos/m-1.png", name)
+		if size, ct := readImage(t, store, key); size != want || (name != "webp" && ct != "image/png") {
+			t.Errorf("%s = %v %s, want %v (no upscaling)", name, size, ct, want)
+		}
+	}
+
+	if _, err := m.Put(ctx, "logos/m-1.png", bytes.NewReader(testJPEG(t, 120, 120)), storage.PutOptions{}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	key, _ := m.VariantKey("logos/m-1.png", "thumb")
+	if size, _ := readImage(t, store, key); size != image.Pt(64, 64) {
+		t.Errorf("thumb after replace = %v, want regenerated 64x64", size)
+	}
+
+	if _, err := m.Put(ctx, "logos/doc.png", strings.NewReader("not an image"), storage.PutOptions{}); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("eager non-image: err = %v, want ErrUnsupportedImage", err)
+	}
+
+	m.opts.Eager = false
+	m.Put(ctx, "logos/m-1.png", bytes.NewReader(testJPEG(t, 120, 120)), storage.PutOptions{})
+	if ok, _ := store.Exists(ctx, key); ok {
+		t.Error("variant kept after the original was replaced")
+	}
+}
+
+func TestMedia_StaleVariantRegenerated(t *testing.T) {
+	m, store := newTestMedia()
+	ctx := context.Background()
+	m.Put(ctx, "avatars/u-1.jpg", bytes.NewReader(testJPEG(t, 400, 300)), storage.PutOptions{})
+	stale, err := m.Variant(ctx, "avatars/u-1.jpg", "medium")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A render of the old original that finished after Put invalidated the variants.
+	store.Memory.Put(ctx, "avatars/u-1.jpg", bytes.NewReader(testJPEG(t, 300, 300)), storage.PutOptions{})
+	info, err := m.Variant(ctx, "avatars/u-1.jpg", "medium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Metadata[MetadataSourceETag] == stale.Metadata[MetadataSourceETag] {
+		t.Error("stale variant served after the original changed")
+	}
+	if size, _ := readImage(t, store, info.Key); size != image.Pt(200, 200) {
+		t.Errorf("variant = %v, want 200x200 from the new original", size)
+	}
+}
+
+func TestMedia_PixelLimit(t *testing.T) {
+	m, _ := newTestMedia()
+	m.opts.MaxPixels = 100 * 100
+	ctx := context.Background()
+	m.Put(ctx, "big.jpg", bytes.NewReader(testJPEG(t, 200, 200)), storage.PutOptions{})
+	if _, err := m.Variant(ctx, "big.jpg", "thumb"); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("err = %v, want ErrImageTooLarge", err)
+	}
+}
+
+// readCountingStore counts the bytes read through Get on a Memory store.
+type readCountingStore struct {
+	*storage.Memory
+	read atomic.Int64
+}
+
+func (s *readCountingStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := s.Memory.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{readerFunc(func(p []byte) (int, error) {
+		n, err := rc.Read(p)
+		s.read.Add(int64(n))
+		return n, err
+	}), rc}, nil
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+func TestMedia_ByteLimit(t *testing.T) {
+	store := &readCountingStore{Memory: storage.NewMemory()}
+	m := New(store, Options{MaxBytes: 1 << 20}, WithVariant("thumb", Variant{Width: 64}))
+	ctx := context.Background()
+	store.Put(ctx, "big.bin", bytes.NewReader(make([]byte, 2<<20)), storage.PutOptions{})
+	if _, err := m.Variant(ctx, "big.bin", "thumb"); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("oversized: err = %v, want ErrImageTooLarge", err)
+	}
+	if n := store.read.Load(); n != 0 {
+		t.Errorf("oversized: read %d bytes, want none", n)
+	}
+
+	store.Put(ctx, "doc.bin", bytes.NewReader(make([]byte, 512<<10)), storage.PutOptions{})
+	if _, err := m.Variant(ctx, "doc.bin", "thumb"); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("non-image: err = %v, want ErrUnsupportedImage", err)
+	}
+	if n := store.read.Load(); n > 64<<10 {
+		t.Errorf("non-image: read %d bytes before rejecting it", n)
+	}
+}
+
+func TestNew_OptionsNotShared(t *testing.T) {
+	variants := map[string]Variant{"thumb": {Width: 64}}
+	m := New(storage.NewMemory(), Options{Variants: variants}, WithVariant("medium", Variant{Width: 200}))
+	if len(variants) != 1 || len(m.opts.Variants) != 2 {
+		t.Errorf("caller's Variants = %v, Media's = %v", variants, m.opts.Variants)
+	}
+}
+
+func TestVariantKey_ChangesWithSpec(t *testing.T) {
+	a, _ := newTestMedia()
+	b, _ := newTestMedia(WithVariant("thumb", Variant{Width: 128, Height: 128, Fit: FitCover}))
+	ka, _ := a.VariantKey("x.jpg", "thumb")
+	kb, _ := b.VariantKey("x.jpg", "thumb")
+	if ka == kb {
+		t.Errorf("same key %s for different specifications", ka)
+	}
+}
@@ -0,0 +1,85 @@
+package media
+
+import "maps"
+
+const (
+	defaultVariantPrefix = "variants/"
+	defaultCacheControl  = "public, max-age=86400"
+	// defaultMaxPixels bounds decoded images (about 200 MB as RGBA) against decompression bombs.
+	defaultMaxPixels = 50_000_000
+	defaultMaxBytes  = 50 << 20
+	defaultQuality   = 82
+)
+
+// Options holds Media settings. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Variants maps a variant name ("thumb", "medium", "webp") to its specification.
+	Variants map[string]Variant
+	// Eager generates every variant in Put; default false generates each one on its first request.
+	Eager bool
+	// OriginalPrefix restricts Handler to keys under this prefix (e.g. "avatars/"). It has no default and
+	// Handler panics without it; use a prefix that holds only public images, never a parent of documents or
+	// quarantined uploads.
+	OriginalPrefix string
+	// VariantPrefix is the key prefix of generated variants; default "variants/".
+	VariantPrefix string
+	// CacheControl is sent by Handler with originals and variants; default "public, max-age=86400".
+	// Responses also carry an ETag, so clients revalidate cheaply after it expires.
+	CacheControl string
+	// MaxPixels is the largest source image (width × height) that is decoded; default 50 megapixels.
+	MaxPixels int
+	// MaxBytes is the largest source object that is read for decoding; default 50 MiB. Larger objects are
+	// refused with ErrImageTooLarge without being downloaded.
+	MaxBytes int64
+}
+
+func (o *Options) applyDefaults() {
+	if o.VariantPrefix == "" {
+		o.VariantPrefix = defaultVariantPrefix
+	}
+	if o.CacheControl == "" {
+		o.CacheControl = defaultCacheControl
+	}
+	if o.MaxPixels <= 0 {
+		o.MaxPixels = defaultMaxPixels
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = defaultMaxBytes
+	}
+	variants := make(map[string]Variant, len(o.Variants))
+	for name, v := range o.Variants {
+		if v.Quality <= 0 || v.Quality > 100 {
+			v.Quality = defaultQuality
+		}
+		variants[name] = v
+	}
+	o.Variants = variants
+}
+
+// Option is a functional option applied to Options.
+type Option func(*Options)
+
+// WithVariant adds or replaces variant name. The caller's Options.Variants map is not modified.
+func WithVariant(name string, v Variant) Option {
+	return func(o *Options) {
+		variants := make(map[string]Variant, len(o.Variants)+1)
+		maps.Copy(variants, o.Variants)
+		variants[name] = v
+		o.Variants = variants
+	}
+}
+
+// WithOriginalPrefix restricts Handler to keys under prefix.
+func WithOriginalPrefix(prefix string) Option {
+	return func(o *Options) { o.OriginalPrefix = prefix }
+}
+
+// WithEager generates every variant when the original is stored.
+func WithEager() Option {
+	return func(o *Options) { o.Eager = true }
+}
+
+// WithCacheControl sets the Cache-Control header served by Handler.
+func WithCacheControl(cc string) Option {
+	return func(o *Options) { o.CacheControl = cc }
+}
@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/turahe/pkg/logger"
+)
+
+// bodyLogKey is the gin context key under which BodyLogger leaves captured fields for LoggerMiddleware.
+const bodyLogKey = "middlewares.body_log"
+
+const defaultMaxBodyBytes = 16 << 10
+
+// BodyLogOptions configures BodyLogger.
+type BodyLogOptions struct {
+	// MaxBodyBytes caps each captured body; a larger body is not logged (it could not be redacted reliably).
+	// Default 16 KiB.
+	MaxBodyBytes int
+	// RedactFields are field names masked at any depth, in addition to DefaultRedactFields.
+	RedactFields []string
+	// RedactPaths are JSONPath expressions masked in JSON bodies, e.g. "$.beneficiary.account",
+	// "$.items[*].pan". Supported: $.key, [n], [*], and * as a key.
+	RedactPaths []string
+	// RedactHeaders are header names masked in addition to DefaultRedactHeaders.
+	RedactHeaders []string
+	// SkipResponseBody disables response body capture (headers are still logged).
+	SkipResponseBody bool
+}
+
+// BodyLogger returns an opt-in, per-route middleware that captures request and response payloads for audit.
+// JSON and form bodies are logged with sensitive fields, JSONPath matches, and card numbers (Luhn-valid,
+// last four kept) masked; other content types are logged as a placeholder with size only. Headers are logged
+// with sensitive values masked.
+//
+// The result is attached to the LoggerMiddleware entry for the request as fields request_headers,
+// request_body, response_headers, and response_body, so LoggerMiddleware must run earlier in the chain.
+// Apply only to routes that need it, e.g. router.POST("/transfers", middlewares.BodyLogger(opts), h).
+// Panics if a RedactPaths expression is invalid.
+func BodyLogger(opts BodyLogOptions) gin.HandlerFunc {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	fields := append(append([]string{}, DefaultRedactFields...), opts.RedactFields...)
+	redactor, err := newJSONRedactor(fields, opts.RedactPaths)
+	if err != nil {
+		panic("middlewares: BodyLogger: " + err.Error())
+	}
+	sensitiveHeaders := make(map[string]struct{})
+	for _, h := range append(append([]string{}, DefaultRedactHeaders...), opts.RedactHeaders...) {
+		sensitiveHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		req := c.Request
+		var reqBody []byte
+		reqTruncated := false
+		if req.Body != nil && req.Body != http.NoBody {
+			buf, _ := io.ReadAll(io.LimitReader(req.Body, int64(opts.MaxBodyBytes)+1))
+			reqTruncated = len(buf) > opts.MaxBodyBytes
+			req.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), closer: req.Body}
+			reqBody = buf
+		}
+
+		var w *bodyCaptureWriter
+		if !opts.SkipResponseBody {
+			w = &bodyCaptureWriter{ResponseWriter: c.Writer, max: opts.MaxBodyBytes}
+			c.Writer = w
+		}
+
+		c.Next()
+
+		captured := logger.Fields{
+			"request_headers":  redactHeaders(req.Header, sensitiveHeaders),
+			"response_headers": redactHeaders(c.Writer.Header(), sensitiveHeaders),
+		}
+		if v := renderBody(redactor, reqBody, reqTruncated, req.Header.Get("Content-Type"), opts.MaxBodyBytes); v != nil {
+			captured["request_body"] = v
+		}
+		if w != nil {
+			if v := renderBody(redactor, w.buf.Bytes(), w.truncated, c.Writer.Header().Get("Content-Type"), opts.MaxBodyBytes); v != nil {
+				captured["response_body"] = v
+			}
+		}
+		c.Set(bodyLogKey, captured)
+	}
+}
+
+// renderBody decodes and redacts a captured body; nil when empty.
+func renderBody(r *jsonRedactor, body []byte, truncated bool, contentType string, max int) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+	if truncated {
+		return fmt.Sprintf("[omitted: body exceeds %d bytes]", max)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[omitted: invalid form body]"
+		}
+		form := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			if len(v) == 1 {
+				form[k] = v[0]
+			} else {
+				items := make([]interface{}, len(v))
+				for i := range v {
+					items[i] = v[i]
+				}
+				form[k] = items
+			}
+		}
+		return r.redact(form)
+	case mediaType == "application/json" || (len(mediaType) > 5 && mediaType[len(mediaType)-5:] == "+json") ||
+		(mediaType == "" && (body[0] == '{' || body[0] == '[')):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return "[omitted: invalid JSON body]"
+		}
+		return r.redact(v)
+	default:
+		if mediaType == "" {
+			mediaType = "unknown content type"
+		}
+		return fmt.Sprintf("[omitted: %s, %d bytes]", mediaType, len(body))
+	}
+}
+
+// replayBody serves the captured prefix followed by the unread remainder, and closes the original body.
+type replayBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b *replayBody) Close() error { return b.closer.Close() }
+
+// bodyCaptureWriter copies up to max bytes of the response body while writing it through.
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) capture(b []byte) {
+	if w.truncated {
+		return
+	}
+	if room := w.max - w.buf.Len(); len(b) > room {
+		w.buf.Write(b[:room])
+		w.truncated = true
+		return
+	}
+	w.buf.Write(b)
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
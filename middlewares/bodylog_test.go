@@ -0,0 +1,154 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turahe/pkg/logger"
+)
+
+// serveBodyLogged runs req through BodyLogger and returns the captured fields and the body the handler read.
+func serveBodyLogged(t *testing.T, opts BodyLogOptions, req *http.Request, respond func(c *gin.Context)) (logger.Fields, string) {
+	t.Helper()
+	var captured logger.Fields
+	var handlerBody string
+	router := setupRouter()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		if v, ok := c.Get(bodyLogKey); ok {
+			captured = v.(logger.Fields)
+		}
+	})
+	router.Handle(req.Method, req.URL.Path, BodyLogger(opts), func(c *gin.Context) {
+		b, _ := io.ReadAll(c.Request.Body)
+		handlerBody = string(b)
+		respond(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotNil(t, captured)
+	return captured, handlerBody
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestBodyLogger_RedactsJSON(t *testing.T) {
+	payload := `{"amount":100000,"cardNumber":"4111 1111 1111 1111","otp":"123456",` +
+		`"beneficiary":{"name":"Budi","account":"1234567890"},"note":"card 4111111111111111",` +
+		`"items":[{"pan":"5500005555555559","memo":"5500005555555559","ref":5500005555555559,"qty":2}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/transfers", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Request-Id", "req-1")
+
+	fields, handlerBody := serveBodyLogged(t, BodyLogOptions{RedactPaths: []string{"$.beneficiary.account"}}, req,
+		func(c *gin.Context) {
+			c.Header("Set-Cookie", "session=abc")
+			c.JSON(http.StatusCreated, gin.H{"id": "trx-1", "access_token": "tok"})
+		})
+
+	assert.Equal(t, payload, handlerBody, "handler still reads the full body")
+
+	reqBody := toJSON(t, fields["request_body"])
+	assert.Contains(t, reqBody, `"amount":100000`)
+	assert.Contains(t, reqBody, `"cardNumber":"[REDACTED]"`)
+	assert.Contains(t, reqBody, `"otp":"[REDACTED]"`)
+	assert.Contains(t, reqBody, `"account":"[REDACTED]"`)
+	assert.Contains(t, reqBody, `"name":"Budi"`)
+	assert.Contains(t, reqBody, `"pan":"[REDACTED]"`)
+	assert.Contains(t, reqBody, `"memo":"************5559"`, "Luhn-valid card numbers are masked by value")
+	assert.Contains(t, reqBody, `"ref":"************5559"`, "card numbers sent as JSON numbers are masked")
+	assert.Contains(t, reqBody, `"qty":2`)
+	assert.Contains(t, reqBody, `"note":"card 4111111111111111"`, "free text is not scanned")
+
+	headers := fields["request_headers"].(map[string]string)
+	assert.Equal(t, RedactionMask, headers["Authorization"])
+	assert.Equal(t, "req-1", headers["X-Request-Id"])
+	assert.Equal(t, RedactionMask, fields["response_headers"].(map[string]string)["Set-Cookie"])
+
+	respBody := toJSON(t, fields["response_body"])
+	assert.Contains(t, respBody, `"id":"trx-1"`)
+	assert.Contains(t, respBody, `"access_token":"[REDACTED]"`)
+}
+
+func TestBodyLogger_SizeCapAndContentTypes(t *testing.T) {
+	t.Run("oversized body is omitted but passed through", func(t *testing.T) {
+		payload := `{"data":"` + strings.Repeat("x", 100) + `"}`
+		req, _ := http.NewRequest(http.MethodPost, "/upload", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		fields, handlerBody := serveBodyLogged(t, BodyLogOptions{MaxBodyBytes: 32, SkipResponseBody: true}, req,
+			func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+		assert.Equal(t, payload, handlerBody)
+		assert.Equal(t, "[omitted: body exceeds 32 bytes]", fields["request_body"])
+		assert.NotContains(t, fields, "response_body")
+	})
+
+	t.Run("form bodies are redacted", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/login", strings.NewReader("username=budi&password=hunter2"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		fields, _ := serveBodyLogged(t, BodyLogOptions{}, req, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		form := fields["request_body"].(map[string]interface{})
+		assert.Equal(t, "budi", form["username"])
+		assert.Equal(t, RedactionMask, form["password"])
+	})
+
+	t.Run("other content types are summarised", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/raw", strings.NewReader("plain text"))
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		fields, _ := serveBodyLogged(t, BodyLogOptions{}, req, func(c *gin.Context) { c.Status(http.StatusOK) })
+		assert.Equal(t, "[omitted: text/plain, 10 bytes]", fields["request_body"])
+	})
+}
+
+func TestBodyLogger_InvalidPathPanics(t *testing.T) {
+	assert.Panics(t, func() { BodyLogger(BodyLogOptions{RedactPaths: []string{"beneficiary.account"}}) })
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathSegment
+		wantErr bool
+	}{
+		{"$.a.b", []pathSegment{{key: "a"}, {key: "b"}}, false},
+		{"$.items[*].pan", []pathSegment{{key: "items"}, {index: -1, isIdx: true}, {key: "pan"}}, false},
+		{"$[0].x", []pathSegment{{index: 0, isIdx: true}, {key: "x"}}, false},
+		{"$.*.secret", []pathSegment{{key: "*"}, {key: "secret"}}, false},
+		{"a.b", nil, true},
+		{"$", nil, true},
+		{"$.a[", nil, true},
+		{"$.a[x]", nil, true},
+		{"$..a", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseJSONPath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMaskPAN(t *testing.T) {
+	assert.Equal(t, "************1111", maskPAN("4111111111111111"))
+	assert.Equal(t, "************1111", maskPAN("4111-1111-1111-1111"))
+	assert.Equal(t, "4111111111111112", maskPAN("4111111111111112"), "fails Luhn")
+	assert.Equal(t, "123456", maskPAN("123456"))
+	assert.Equal(t, "ID 4111111111111111", maskPAN("ID 4111111111111111"))
+}
@@ -5,10 +5,12 @@ Role in architecture:
   - Adapters: sit between the HTTP server and handlers; no business logic, only request/response and infra calls.
 
 Responsibilities:
-  - Recovery: catch panics, log stack, return JSON 500.
-  - Tracing: inject request/trace/correlation IDs from headers or generate UUIDs; store in context for logger.
-  - Logging: log each request (method, path, status, latency, IP) with context-bound logger.
-  - Metrics: expose Prometheus counters, histogram, and in-flight gauge (route pattern as label).
+  - Recovery: catch panics, log stack, report to error reporting (logger.ReportPanic), return JSON 500.
+  - Tracing: inject request/trace/correlation IDs from headers (W3C traceparent, X-Cloud-Trace-Context, X-Trace-Id) or generate UUIDs; store in context for logger.
+  - Logging: log each request as structured attributes (method, route, path, status, latency_ms, bytes, client IP, user ID, response code) with context-bound logger; LoggerWithOptions adds a message template, the legacy printf format, skip paths, per-route sampling of successes keyed on trace ID, and a slow-request threshold.
+  - Body logging: opt-in per route (BodyLogger); request/response payloads and headers attached to the request log with sensitive fields, JSONPath matches, and card numbers masked.
+  - Log levels: LogLevelsHandler views and changes per-logger levels at runtime; DebugOverride enables Debug logging for one request carrying a valid signed X-Debug-Token.
+  - Metrics: expose Prometheus counters, duration and size histograms, and in-flight gauge (route pattern as label); NewMetrics for a custom registry, namespace, buckets, and extra labels.
   - Timeout: set request context deadline so downstream DB/Redis respect it.
   - CORS: set Access-Control-* headers from config.
   - Auth: validate Bearer JWT via jwt.TokenVerifier (Manager or Verifier); set user_id and impersonation fields in context. Use AuthMiddleware(verifier) with jwt.NewManager or jwt.NewVerifier for verification-only services.
@@ -16,6 +18,7 @@ Responsibilities:
 
 Constraints:
   - Rate limiter requires Redis enabled and config.RateLimiter.Enabled; fails open on Redis error.
+  - LogLevelsHandler has no access control of its own; mount it behind authentication.
   - Auth requires a non-nil jwt.TokenVerifier (*jwt.Manager or *jwt.Verifier); pass it to AuthMiddleware(verifier).
   - No provider switching or fallbacks inside middleware; config is read once at middleware build.
 
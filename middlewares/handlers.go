@@ -25,7 +25,8 @@ func NoRouteHandler() gin.HandlerFunc {
 	}
 }
 
-// RecoveryHandler recovers from panics, logs structured CRITICAL with trace/correlation preserved, and responds with 500.
+// RecoveryHandler recovers from panics, logs structured CRITICAL with trace/correlation preserved, reports the
+// panic to error reporting (logger.ReportPanic), and responds with 500.
 // Register with gin.Use(RecoveryHandler). Do not use for fatal errors in request context; use logger.Errorf + abort.
 func RecoveryHandler(ctx *gin.Context) {
 	defer func() {
@@ -34,6 +35,7 @@ func RecoveryHandler(ctx *gin.Context) {
 			logger.GetLogger().LogAttrs(reqCtx, logger.LevelCritical, "panic recovered",
 				slog.String("panic", errorToString(err)),
 				slog.String("stacktrace", string(debug.Stack())))
+			logger.ReportPanic(reqCtx, err, logger.ReportStatus(http.StatusInternalServerError))
 			response.FailWithDetailed(ctx, http.StatusInternalServerError, response.ServiceCodeCommon, response.CaseCodeInternalError, nil, errorToString(err))
 			ctx.Abort()
 		}
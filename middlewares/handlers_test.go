@@ -1,6 +1,8 @@
 package middlewares
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/turahe/pkg/logger"
 	"github.com/turahe/pkg/response"
 )
 
@@ -136,6 +139,30 @@ func TestRecoveryHandler(t *testing.T) {
 	assert.NotContains(t, w.Body.String(), "should not reach here")
 }
 
+func TestRecoveryHandler_ReportsPanic(t *testing.T) {
+	var events []*logger.ErrorEvent
+	logger.Init(logger.Config{Writer: &bytes.Buffer{}, ErrorReporting: logger.ErrorReportingConfig{
+		Enabled: true,
+		Hooks: []logger.ReportHook{logger.ReportHookFunc(func(_ context.Context, ev *logger.ErrorEvent) {
+			events = append(events, ev)
+		})},
+	}})
+	defer logger.Init(logger.Config{})
+
+	router := setupRouter()
+	router.Use(RecoveryHandler)
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	require.Len(t, events, 1)
+	assert.True(t, events[0].Panic)
+	assert.Equal(t, "boom", events[0].Message)
+	assert.Equal(t, http.StatusInternalServerError, events[0].Status)
+	assert.Contains(t, events[0].Location.Function, "TestRecoveryHandler_ReportsPanic")
+}
+
 func TestRecoveryHandler_NoPanic(t *testing.T) {
 	router := setupRouter()
 	router.Use(RecoveryHandler)
@@ -1,67 +1,289 @@
 package middlewares
 
 import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
-// LoggerMiddleware returns a Gin middleware that logs each request after Next(): method, path, status,
-// latency, client IP, user-agent. Uses logger.WithContext(ctx) so trace_id, span_id, correlation_id,
-// and httpRequest (if HTTPInstrumentation ran) appear in JSON. Log level: 5xx Error, 4xx Warn, 2xx/3xx Info.
+// DefaultAccessLogTemplate is the access log message used when AccessLogOptions.MessageTemplate is empty.
+const DefaultAccessLogTemplate = "{method} {path} {status} {latency_ms}ms"
+
+// AccessLogOptions configures LoggerWithOptions. The zero value logs every request, like LoggerMiddleware.
+type AccessLogOptions struct {
+	// SkipPaths are path prefixes that are never logged (e.g. "/livez", "/readyz", "/metrics").
+	SkipPaths []string
+	// SampleRate is the fraction of successful (2xx/3xx) requests logged, in (0, 1]. 0 means 1 (log all).
+	SampleRate float64
+	// RouteSampleRates overrides SampleRate per route template (c.FullPath(), e.g. "/users/:id").
+	// An explicit 0 drops all successful requests on that route.
+	RouteSampleRates map[string]float64
+	// SlowThreshold: requests at or above this latency are always logged regardless of sampling. 0 disables.
+	SlowThreshold time.Duration
+	// RedactQueryParams are query parameter names whose values are masked in the logged query, in addition
+	// to DefaultRedactFields (matched the same way). Card numbers in any parameter are masked too.
+	RedactQueryParams []string
+
+	// MessageTemplate is the entry message; {name} placeholders are replaced by the attribute of that name
+	// (method, route, path, query, status, latency_ms, bytes_in, bytes_out, client_ip, user_id, user_agent,
+	// response_code, error). Default DefaultAccessLogTemplate.
+	MessageTemplate string
+	// LegacyFormat logs the pre-structured "[METHOD] path ip status latency user-agent" message without
+	// attributes, for log queries that still parse it.
+	LegacyFormat bool
+}
+
+// accessLogAttrs are the attribute names available to MessageTemplate.
+var accessLogAttrs = map[string]struct{}{
+	"method": {}, "route": {}, "path": {}, "query": {}, "status": {}, "latency_ms": {}, "bytes_in": {},
+	"bytes_out": {}, "client_ip": {}, "user_id": {}, "user_agent": {}, "response_code": {}, "error": {},
+}
+
+// LoggerMiddleware returns a Gin middleware that logs each request after Next() as a structured entry with
+// attributes method, route (c.FullPath()), path, status, latency_ms, bytes_in, bytes_out, client_ip,
+// user_agent, and when available query, user_id (AuthMiddleware), response_code (response package), and
+// error (c.Errors). Values of query parameters named in DefaultRedactFields and card numbers are masked in
+// query. Uses logger.WithContext(ctx) so trace_id, span_id, correlation_id, and httpRequest
+// (if HTTPInstrumentation ran) appear in JSON. Log level: 5xx Error, 4xx Warn, 2xx/3xx Info.
 // Register after CloudTraceMiddleware (or TraceMiddleware) and HTTPInstrumentation for full GCP fields.
+// When BodyLogger runs on the route, its redacted headers and payloads are added as fields to the entry.
 func LoggerMiddleware() gin.HandlerFunc {
+	return LoggerWithOptions(AccessLogOptions{})
+}
+
+// LoggerWithOptions is LoggerMiddleware with path filtering, sampling of successful requests, and a
+// configurable message. 4xx/5xx responses, requests at or above SlowThreshold, and requests whose bodies
+// BodyLogger captured are always logged. The sampling decision is a hash of the trace ID, so every service
+// and log line of a sampled trace makes the same choice; requests without a trace ID are sampled randomly.
+// Panics if MessageTemplate references an unknown attribute or has an unclosed placeholder.
+func LoggerWithOptions(opts AccessLogOptions) gin.HandlerFunc {
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		opts.SampleRate = 1
+	}
+	if opts.MessageTemplate == "" {
+		opts.MessageTemplate = DefaultAccessLogTemplate
+	}
+	tmpl, err := parseAccessLogTemplate(opts.MessageTemplate)
+	if err != nil {
+		panic("middlewares: LoggerWithOptions: " + err.Error())
+	}
+	queryRedactor, _ := newJSONRedactor(append(append([]string{}, DefaultRedactFields...), opts.RedactQueryParams...), nil)
+
 	return func(ctx *gin.Context) {
-		// Start timer
 		start := time.Now()
 		path := ctx.Request.URL.Path
 		raw := ctx.Request.URL.RawQuery
 
-		// Process request
 		ctx.Next()
 
-		// Calculate latency
+		if shouldSkipPath(path, opts.SkipPaths) {
+			return
+		}
+		raw = queryRedactor.redactQuery(raw)
 		latency := time.Since(start)
-
-		// Get client IP
-		clientIP := ctx.ClientIP()
-
-		// Get method and status code
-		method := ctx.Request.Method
 		statusCode := ctx.Writer.Status()
-
-		// Build query string if exists
-		if raw != "" {
-			path = path + "?" + raw
+		if !opts.keep(ctx, statusCode, latency) {
+			return
 		}
 
-		// Log request details; use context-bound logger so trace_id/correlation_id appear in JSON
 		log := logger.WithContext(ctx.Request.Context())
-		errorMsg := ctx.Errors.String()
-		if statusCode >= 500 {
-			// Server errors
-			if errorMsg != "" {
-				log.Errorf("[%s] %s %s %d %v %s - Error: %s",
-					method, path, clientIP, statusCode, latency, ctx.Request.UserAgent(), errorMsg)
-			} else {
-				log.Errorf("[%s] %s %s %d %v %s",
-					method, path, clientIP, statusCode, latency, ctx.Request.UserAgent())
-			}
-		} else if statusCode >= 400 {
-			// Client errors
-			if errorMsg != "" {
-				log.Warnf("[%s] %s %s %d %v %s - Error: %s",
-					method, path, clientIP, statusCode, latency, ctx.Request.UserAgent(), errorMsg)
-			} else {
-				log.Warnf("[%s] %s %s %d %v %s",
-					method, path, clientIP, statusCode, latency, ctx.Request.UserAgent())
+		if opts.LegacyFormat {
+			logLegacy(ctx, log, path, raw, statusCode, latency)
+			return
+		}
+
+		fields := accessLogFields(ctx, path, raw, statusCode, latency)
+		msg := tmpl.render(fields)
+		if v, ok := ctx.Get(bodyLogKey); ok {
+			// BodyLogger ran on this route: add the captured payloads.
+			body, _ := v.(logger.Fields)
+			for k, val := range body {
+				fields[k] = val
 			}
+		}
+		switch {
+		case statusCode >= 500:
+			log.Error(msg, fields)
+		case statusCode >= 400:
+			log.Warn(msg, fields)
+		default:
+			log.Info(msg, fields)
+		}
+	}
+}
+
+// accessLogFields returns the structured attributes of a finished request.
+func accessLogFields(ctx *gin.Context, path, rawQuery string, status int, latency time.Duration) logger.Fields {
+	fields := logger.Fields{
+		"method":     ctx.Request.Method,
+		"route":      ctx.FullPath(),
+		"path":       path,
+		"status":     status,
+		"latency_ms": float64(latency.Microseconds()) / 1000,
+		"bytes_in":   max(int(ctx.Request.ContentLength), 0),
+		"bytes_out":  max(ctx.Writer.Size(), 0),
+		"client_ip":  ctx.ClientIP(),
+		"user_agent": ctx.Request.UserAgent(),
+	}
+	if rawQuery != "" {
+		fields["query"] = rawQuery
+	}
+	if userID := ctx.GetString("user_id"); userID != "" {
+		fields["user_id"] = userID
+	}
+	if code, ok := ctx.Get(response.CodeKey); ok {
+		fields["response_code"] = code
+	}
+	if errs := ctx.Errors.String(); errs != "" {
+		fields["error"] = errs
+	}
+	return fields
+}
+
+// logLegacy writes the pre-structured printf access log (LegacyFormat).
+func logLegacy(ctx *gin.Context, log *logger.Ctx, path, raw string, statusCode int, latency time.Duration) {
+	clientIP := ctx.ClientIP()
+	method := ctx.Request.Method
+	if raw != "" {
+		path = path + "?" + raw
+	}
+	errorMsg := ctx.Errors.String()
+	if v, ok := ctx.Get(bodyLogKey); ok {
+		// BodyLogger ran on this route: same message, with the captured payloads as structured fields.
+		fields, _ := v.(logger.Fields)
+		msg := fmt.Sprintf("[%s] %s %s %d %v %s", method, path, clientIP, statusCode, latency, ctx.Request.UserAgent())
+		if errorMsg != "" {
+			msg += " - Error: " + errorMsg
+		}
+		switch {
+		case statusCode >= 500:
+			log.Error(msg, fields)
+		case statusCode >= 400:
+			log.Warn(msg, fields)
+		default:
+			log.Info(msg, fields)
+		}
+		return
+	}
+	if statusCode >= 500 {
+		// Server errors
+		if errorMsg != "" {
+			log.Errorf("[%s] %s %s %d %v %s - Error: %s",
+				method, path, clientIP, statusCode, latency, ctx.Request.UserAgent(), errorMsg)
 		} else {
-			// Success (2xx, 3xx)
-			log.Infof("[%s] %s %s %d %v %s",
+			log.Errorf("[%s] %s %s %d %v %s",
 				method, path, clientIP, statusCode, latency, ctx.Request.UserAgent())
 		}
+	} else if statusCode >= 400 {
+		// Client errors
+		if errorMsg != "" {
+			log.Warnf("[%s] %s %s %d %v %s - Error: %s",
+				method, path, clientIP, statusCode, latency, ctx.Request.UserAgent(), errorMsg)
+		} else {
+			log.Warnf("[%s] %s %s %d %v %s",
+				method, path, clientIP, statusCode, latency, ctx.Request.UserAgent())
+		}
+	} else {
+		// Success (2xx, 3xx)
+		log.Infof("[%s] %s %s %d %v %s",
+			method, path, clientIP, statusCode, latency, ctx.Request.UserAgent())
+	}
+}
+
+// accessLogTemplate is a parsed MessageTemplate: literal text alternating with attribute names.
+type accessLogTemplate struct {
+	literals []string // len(names)+1
+	names    []string
+}
+
+func parseAccessLogTemplate(s string) (*accessLogTemplate, error) {
+	t := &accessLogTemplate{}
+	for {
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			t.literals = append(t.literals, s)
+			return t, nil
+		}
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("template: unclosed placeholder at %q", s[open:])
+		}
+		name := s[open+1 : open+end]
+		if _, ok := accessLogAttrs[name]; !ok {
+			return nil, fmt.Errorf("template: unknown attribute %q", name)
+		}
+		t.literals = append(t.literals, s[:open])
+		t.names = append(t.names, name)
+		s = s[open+end+1:]
+	}
+}
+
+// render substitutes attribute values; missing attributes render as "-".
+func (t *accessLogTemplate) render(fields logger.Fields) string {
+	var b strings.Builder
+	for i, name := range t.names {
+		b.WriteString(t.literals[i])
+		switch v := fields[name].(type) {
+		case nil:
+			b.WriteByte('-')
+		case string:
+			if v == "" {
+				v = "-"
+			}
+			b.WriteString(v)
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+	b.WriteString(t.literals[len(t.literals)-1])
+	return b.String()
+}
+
+// keep reports whether a finished request is logged under opts. Errors, slow requests, requests with a
+// debug override (DebugOverride) and requests whose bodies BodyLogger captured are always kept.
+func (opts AccessLogOptions) keep(ctx *gin.Context, status int, latency time.Duration) bool {
+	if _, ok := ctx.Get(bodyLogKey); ok {
+		return true
+	}
+	if status >= 400 || (opts.SlowThreshold > 0 && latency >= opts.SlowThreshold) || logger.DebugEnabled(ctx.Request.Context()) {
+		return true
+	}
+	rate := opts.SampleRate
+	if r, ok := opts.RouteSampleRates[ctx.FullPath()]; ok {
+		rate = r
+	}
+	return sampled(logger.GetTraceID(ctx.Request.Context()), rate)
+}
+
+// sampled makes a deterministic decision for traceID at rate (random when traceID is empty).
+func sampled(traceID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	if traceID == "" {
+		return rand.Float64() < rate
 	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(traceID))
+	// FNV-1a barely mixes trailing bytes into the high bits; finalize (splitmix64) before taking them.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/(1<<53) < rate
 }
@@ -1,12 +1,21 @@
 package middlewares
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/response"
 )
 
 func TestLoggerMiddleware(t *testing.T) {
@@ -119,3 +128,158 @@ func TestLoggerMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
 }
+
+func TestAccessLogOptions_Keep(t *testing.T) {
+	opts := AccessLogOptions{
+		SampleRate:       0.5,
+		RouteSampleRates: map[string]float64{"/hot/:id": 0, "/audit": 1},
+		SlowThreshold:    time.Second,
+	}
+	decide := func(route, path, traceID string, status int, latency time.Duration) bool {
+		var kept bool
+		router := setupRouter()
+		router.GET(route, func(c *gin.Context) {
+			if traceID != "" {
+				c.Request = c.Request.WithContext(logger.WithTraceID(c.Request.Context(), traceID))
+			}
+			kept = opts.keep(c, status, latency)
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		return kept
+	}
+
+	assert.False(t, decide("/hot/:id", "/hot/1", "trace-a", http.StatusOK, time.Millisecond), "route rate 0 drops successes")
+	assert.True(t, decide("/hot/:id", "/hot/1", "trace-a", http.StatusBadRequest, time.Millisecond), "4xx always kept")
+	assert.True(t, decide("/hot/:id", "/hot/1", "trace-a", http.StatusBadGateway, time.Millisecond), "5xx always kept")
+	assert.True(t, decide("/hot/:id", "/hot/1", "trace-a", http.StatusOK, 2*time.Second), "slow requests always kept")
+	assert.True(t, decide("/audit", "/audit", "trace-a", http.StatusOK, time.Millisecond), "route rate 1 keeps all")
+
+	// Decisions are stable per trace ID and roughly follow the rate.
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("%032x", i)
+		first := decide("/other", "/other", id, http.StatusOK, time.Millisecond)
+		assert.Equal(t, first, decide("/other", "/other", id, http.StatusOK, time.Millisecond))
+		if first {
+			kept++
+		}
+	}
+	assert.InDelta(t, 500, kept, 100)
+}
+
+func TestLoggerWithOptions_SkipPaths(t *testing.T) {
+	router := setupRouter()
+	router.Use(LoggerWithOptions(AccessLogOptions{SkipPaths: []string{"/livez", "/metrics"}}))
+	router.GET("/livez", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
+
+func TestLoggerWithOptions_KeepsBodyLoggedRequests(t *testing.T) {
+	var buf bytes.Buffer
+	logger.Init(logger.Config{Writer: &buf})
+	defer logger.Init(logger.Config{})
+
+	router := setupRouter()
+	router.Use(LoggerWithOptions(AccessLogOptions{SampleRate: 0.0001}))
+	router.POST("/transfers", BodyLogger(BodyLogOptions{}), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"amount":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, buf.String(), "request_body")
+}
+
+func TestAccessLogFields(t *testing.T) {
+	var fields logger.Fields
+	router := setupRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "user-42")
+		c.Next()
+		fields = accessLogFields(c, c.Request.URL.Path, c.Request.URL.RawQuery, c.Writer.Status(), 1500*time.Microsecond)
+	})
+	router.POST("/accounts/:id/transfers", func(c *gin.Context) {
+		response.Created(c, response.ServiceCodeCommon, gin.H{"id": "trx-1"}, "")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts/acc-9/transfers?dry_run=1", strings.NewReader(`{"amount":1}`))
+	req.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.MethodPost, fields["method"])
+	assert.Equal(t, "/accounts/:id/transfers", fields["route"])
+	assert.Equal(t, "/accounts/acc-9/transfers", fields["path"])
+	assert.Equal(t, "dry_run=1", fields["query"])
+	assert.Equal(t, http.StatusCreated, fields["status"])
+	assert.Equal(t, 1.5, fields["latency_ms"])
+	assert.Equal(t, 12, fields["bytes_in"])
+	assert.Equal(t, w.Body.Len(), fields["bytes_out"])
+	assert.Equal(t, "user-42", fields["user_id"])
+	assert.Equal(t, "test-agent", fields["user_agent"])
+	assert.Equal(t, response.BuildResponseCode(http.StatusCreated, response.ServiceCodeCommon, response.CaseCodeCreated), fields["response_code"])
+	assert.NotContains(t, fields, "error")
+}
+
+func TestLoggerWithOptions_RedactsQuery(t *testing.T) {
+	var buf bytes.Buffer
+	logger.Init(logger.Config{Writer: &buf})
+	defer logger.Init(logger.Config{})
+
+	router := setupRouter()
+	router.Use(LoggerWithOptions(AccessLogOptions{RedactQueryParams: []string{"session"}, MessageTemplate: "{query}"}))
+	router.GET("/callback", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet,
+		"/callback?state=abc&access_token=tok-1&Session=s-1&card=4111-1111-1111-1111&flag", nil))
+
+	for _, secret := range []string{"tok-1", "s-1", "4111-1111-1111-1111"} {
+		assert.NotContains(t, buf.String(), secret)
+	}
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	want := "state=abc&access_token=[REDACTED]&Session=[REDACTED]&card=************1111&flag"
+	assert.Equal(t, want, entry["query"])
+	assert.Equal(t, want, entry["message"])
+}
+
+func TestRedactQuery(t *testing.T) {
+	r, err := newJSONRedactor(DefaultRedactFields, nil)
+	require.NoError(t, err)
+	for raw, want := range map[string]string{
+		"":                           "",
+		"q=shoes&page=2":             "q=shoes&page=2",
+		"api%5Fkey=k&password=p":     "api%5Fkey=[REDACTED]&password=[REDACTED]",
+		"pan=4111111111111111":       "pan=[REDACTED]",
+		"ref=4111+1111+1111+1111":    "ref=************1111",
+		"otp&token=":                 "otp&token=[REDACTED]",
+		"bad=%zz&clientSecret=x%20y": "bad=%zz&clientSecret=[REDACTED]",
+	} {
+		assert.Equal(t, want, r.redactQuery(raw), raw)
+	}
+}
+
+func TestAccessLogTemplate(t *testing.T) {
+	tmpl, err := parseAccessLogTemplate("{method} {route} -> {status} in {latency_ms}ms user={user_id}")
+	require.NoError(t, err)
+	msg := tmpl.render(logger.Fields{"method": "GET", "route": "/users/:id", "status": 200, "latency_ms": 2.25})
+	assert.Equal(t, "GET /users/:id -> 200 in 2.25ms user=-", msg)
+
+	_, err = parseAccessLogTemplate("{method} {unknown}")
+	assert.Error(t, err)
+	_, err = parseAccessLogTemplate("{method")
+	assert.Error(t, err)
+	assert.Panics(t, func() { LoggerWithOptions(AccessLogOptions{MessageTemplate: "{nope}"}) })
+}
+
+func TestLoggerWithOptions_LegacyFormat(t *testing.T) {
+	router := setupRouter()
+	router.Use(LoggerWithOptions(AccessLogOptions{LegacyFormat: true}))
+	router.GET("/legacy", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/legacy?q=1", nil))
+	assert.Equal(t, "ok", w.Body.String())
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/response"
+)
+
+// logLevelRequest is the body of a PUT/POST to LogLevelsHandler.
+type logLevelRequest struct {
+	Logger string `json:"logger" binding:"required"`
+	Level  string `json:"level" binding:"required"`
+	// TTL is a Go duration ("15m"); empty or "0" keeps the override until reset.
+	TTL string `json:"ttl"`
+}
+
+// LogLevelsHandler returns an admin handler for runtime log levels:
+//
+//	GET               current global level and per-logger overrides (logger.Levels)
+//	PUT or POST       {"logger":"db","level":"debug","ttl":"15m"} sets an override (logger.SetLevel)
+//	DELETE ?logger=db removes an override (logger.ResetLevel)
+//
+// Mount it behind authentication, e.g. admin.Any("/log-levels", middlewares.LogLevelsHandler()). Changes are
+// logged at Warn and are local to the process.
+func LogLevelsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet:
+			response.OkWithData(ctx, logger.Levels())
+		case http.MethodPut, http.MethodPost:
+			var req logLevelRequest
+			if err := ctx.ShouldBindJSON(&req); err != nil {
+				response.ValidationError(ctx, response.ServiceCodeCommon, err)
+				return
+			}
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+				response.ValidationErrorSimple(ctx, response.ServiceCodeCommon, "level", "level must be debug, info, warn or error")
+				return
+			}
+			var ttl time.Duration
+			if s := strings.TrimSpace(req.TTL); s != "" {
+				d, err := time.ParseDuration(s)
+				if err != nil || d < 0 {
+					response.ValidationErrorSimple(ctx, response.ServiceCodeCommon, "ttl", "ttl must be a positive duration such as 15m")
+					return
+				}
+				ttl = d
+			}
+			logger.SetLevel(req.Logger, level, ttl)
+			logger.GetLogger().LogAttrs(ctx.Request.Context(), slog.LevelWarn, "log level changed",
+				slog.String("target_logger", req.Logger),
+				slog.String("level", level.String()),
+				slog.Duration("ttl", ttl),
+				slog.String("client_ip", ctx.ClientIP()))
+			response.OkWithData(ctx, logger.Levels())
+		case http.MethodDelete:
+			name := ctx.Query("logger")
+			if name == "" {
+				response.ValidationErrorSimple(ctx, response.ServiceCodeCommon, "logger", "logger is required")
+				return
+			}
+			logger.ResetLevel(name)
+			logger.GetLogger().LogAttrs(ctx.Request.Context(), slog.LevelWarn, "log level reset",
+				slog.String("target_logger", name),
+				slog.String("client_ip", ctx.ClientIP()))
+			response.OkWithData(ctx, logger.Levels())
+		default:
+			ctx.Header("Allow", "GET, PUT, POST, DELETE")
+			response.FailWithDetailed(ctx, http.StatusMethodNotAllowed, response.ServiceCodeCommon, response.CaseCodeOperationNotAllowed, nil, "Method Not Allowed")
+		}
+	}
+}
+
+// DebugOverride returns a middleware that enables Debug logging for a single request carrying a valid
+// logger.DebugHeader token signed with secret (logger.SignDebugToken). Every log entry of that request,
+// including the access log, is written regardless of levels and carries field "debug_override" with the
+// token subject. Invalid or expired tokens are logged at Warn and otherwise ignored. With an empty secret
+// the middleware is a no-op.
+func DebugOverride(secret []byte) gin.HandlerFunc {
+	if len(secret) == 0 {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	return func(ctx *gin.Context) {
+		token := ctx.GetHeader(logger.DebugHeader)
+		if token == "" {
+			ctx.Next()
+			return
+		}
+		reqCtx := ctx.Request.Context()
+		subject, err := logger.VerifyDebugToken(secret, token, time.Now())
+		if err != nil {
+			logger.GetLogger().LogAttrs(reqCtx, slog.LevelWarn, "debug token rejected",
+				slog.String("error", err.Error()),
+				slog.String("client_ip", ctx.ClientIP()))
+			ctx.Next()
+			return
+		}
+		ctx.Request = ctx.Request.WithContext(logger.WithDebug(reqCtx, subject))
+		ctx.Next()
+	}
+}
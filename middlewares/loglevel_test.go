@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turahe/pkg/logger"
+)
+
+func levelsFromBody(t *testing.T, w *httptest.ResponseRecorder) logger.LevelState {
+	t.Helper()
+	var resp struct {
+		Data logger.LevelState `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+func TestLogLevelsHandler(t *testing.T) {
+	router := setupRouter()
+	router.Any("/admin/log-levels", LogLevelsHandler())
+	defer logger.ResetLevel("test.mw")
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/admin/log-levels", `{"logger":"test.mw","level":"debug","ttl":"15m"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	state := levelsFromBody(t, w)
+	var found *logger.LevelOverride
+	for i := range state.Overrides {
+		if state.Overrides[i].Logger == "test.mw" {
+			found = &state.Overrides[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, "DEBUG", found.Level)
+	require.NotNil(t, found.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), *found.ExpiresAt, time.Minute)
+
+	w = do(http.MethodGet, "/admin/log-levels", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, levelsFromBody(t, w).Level)
+
+	w = do(http.MethodDelete, "/admin/log-levels?logger=test.mw", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	for _, o := range levelsFromBody(t, w).Overrides {
+		assert.NotEqual(t, "test.mw", o.Logger)
+	}
+}
+
+func TestLogLevelsHandler_Validation(t *testing.T) {
+	router := setupRouter()
+	router.Any("/admin/log-levels", LogLevelsHandler())
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"missing logger", http.MethodPut, "/admin/log-levels", `{"level":"debug"}`, http.StatusUnprocessableEntity},
+		{"bad level", http.MethodPut, "/admin/log-levels", `{"logger":"db","level":"verbose"}`, http.StatusUnprocessableEntity},
+		{"bad ttl", http.MethodPost, "/admin/log-levels", `{"logger":"db","level":"debug","ttl":"soon"}`, http.StatusUnprocessableEntity},
+		{"delete without logger", http.MethodDelete, "/admin/log-levels", "", http.StatusUnprocessableEntity},
+		{"unsupported method", http.MethodPatch, "/admin/log-levels", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestDebugOverride(t *testing.T) {
+	secret := []byte("debug-secret")
+	router := setupRouter()
+	router.Use(DebugOverride(secret))
+	var enabled bool
+	var subject interface{}
+	router.GET("/test", func(c *gin.Context) {
+		enabled = logger.DebugEnabled(c.Request.Context())
+		subject = logger.GetFields(c.Request.Context())["debug_override"]
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		token       string
+		wantEnabled bool
+	}{
+		{"no header", "", false},
+		{"valid token", logger.SignDebugToken(secret, "cust-42", time.Now().Add(time.Minute)), true},
+		{"expired token", logger.SignDebugToken(secret, "cust-42", time.Now().Add(-time.Minute)), false},
+		{"wrong secret", logger.SignDebugToken([]byte("other"), "cust-42", time.Now().Add(time.Minute)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled, subject = false, nil
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.token != "" {
+				req.Header.Set(logger.DebugHeader, tt.token)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.wantEnabled, enabled)
+			if tt.wantEnabled {
+				assert.Equal(t, "cust-42", subject)
+			}
+		})
+	}
+}
@@ -1,69 +1,274 @@
 package middlewares
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
-	httpRequestsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests.",
-		},
-		[]string{"method", "path", "status"},
-	)
-
-	httpRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "Duration of HTTP requests in seconds.",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "path", "status"},
-	)
-
-	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "http_requests_in_flight",
-		Help: "Current number of HTTP requests being processed.",
-	})
-)
+// DefaultSizeBuckets are the request/response size histogram buckets in bytes (100B to 10MB).
+var DefaultSizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+// MetricsLabel is an extra label whose value is computed per request (e.g. tenant, service code).
+// Extractors must return a small, bounded set of values; never user IDs or raw paths.
+type MetricsLabel struct {
+	Name    string
+	Extract func(c *gin.Context) string
+}
+
+// HeaderLabel returns a MetricsLabel that takes its value from a request header ("" when absent).
+func HeaderLabel(name, header string) MetricsLabel {
+	return MetricsLabel{Name: name, Extract: func(c *gin.Context) string { return c.GetHeader(header) }}
+}
 
-// Metrics returns a Gin middleware that exposes Prometheus HTTP metrics:
-//   - http_requests_total (counter, labels: method, path, status)
-//   - http_request_duration_seconds (histogram, labels: method, path, status)
+// ContextLabel returns a MetricsLabel that takes its value from a string stored with c.Set(key, ...)
+// by an earlier handler or middleware ("" when absent).
+func ContextLabel(name, key string) MetricsLabel {
+	return MetricsLabel{Name: name, Extract: func(c *gin.Context) string { return c.GetString(key) }}
+}
+
+// MetricsOptions configures NewMetrics. Zero values use the defaults noted per field.
+type MetricsOptions struct {
+	// Registerer receives the collectors; default prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+	// Gatherer is served by Handler; default the Registerer when it is a *prometheus.Registry,
+	// otherwise prometheus.DefaultGatherer.
+	Gatherer prometheus.Gatherer
+	// Namespace and Subsystem prefix metric names (e.g. "payments_api_http_requests_total").
+	Namespace string
+	Subsystem string
+	// DurationBuckets for the request duration histogram; default prometheus.DefBuckets.
+	DurationBuckets []float64
+	// SizeBuckets for request/response size histograms; default DefaultSizeBuckets.
+	SizeBuckets []float64
+	// Labels are appended after method, path, status on every per-request metric.
+	Labels []MetricsLabel
+	// SkipPaths are path prefixes that are not instrumented; default ["/metrics"].
+	SkipPaths []string
+}
+
+// HTTPMetrics holds the Prometheus collectors for one NewMetrics configuration.
+type HTTPMetrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	requestSize     *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+	labels          []MetricsLabel
+	skipPaths       []string
+	gatherer        prometheus.Gatherer
+}
+
+// NewMetrics creates and registers HTTP metrics:
+//   - http_requests_total (counter, labels: method, path, status, extra labels)
+//   - http_request_duration_seconds (histogram, same labels)
+//   - http_request_size_bytes, http_response_size_bytes (histograms, same labels)
 //   - http_requests_in_flight (gauge)
 //
-// Register the /metrics endpoint separately using promhttp.Handler().
-func Metrics() gin.HandlerFunc {
+// Names are prefixed with Namespace/Subsystem when set. Creating metrics twice with identical options on
+// the same registry reuses the registered collectors; a conflicting definition (labels or buckets) returns
+// an error and leaves the registry as it was.
+func NewMetrics(opts MetricsOptions) (m *HTTPMetrics, err error) {
+	if opts.Registerer == nil {
+		opts.Registerer = prometheus.DefaultRegisterer
+	}
+	if opts.Gatherer == nil {
+		if g, ok := opts.Registerer.(prometheus.Gatherer); ok {
+			opts.Gatherer = g
+		} else {
+			opts.Gatherer = prometheus.DefaultGatherer
+		}
+	}
+	if len(opts.DurationBuckets) == 0 {
+		opts.DurationBuckets = prometheus.DefBuckets
+	}
+	if len(opts.SizeBuckets) == 0 {
+		opts.SizeBuckets = DefaultSizeBuckets
+	}
+	if opts.SkipPaths == nil {
+		opts.SkipPaths = []string{"/metrics"}
+	}
+
+	labelNames := []string{"method", "path", "status"}
+	for _, l := range opts.Labels {
+		if l.Name == "" || l.Extract == nil {
+			return nil, errors.New("middlewares: metrics label requires Name and Extract")
+		}
+		labelNames = append(labelNames, l.Name)
+	}
+
+	m = &HTTPMetrics{labels: opts.Labels, skipPaths: opts.SkipPaths, gatherer: opts.Gatherer}
+	var added []prometheus.Collector
+	defer func() {
+		if err != nil {
+			for _, c := range added {
+				opts.Registerer.Unregister(c)
+			}
+		}
+	}()
+	if m.requestsTotal, err = registerCollector(opts.Registerer, &added, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests.",
+	}, labelNames), nil); err != nil {
+		return nil, err
+	}
+	if m.requestDuration, err = registerCollector(opts.Registerer, &added, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests in seconds.",
+		Buckets:   opts.DurationBuckets,
+	}, labelNames), opts.DurationBuckets); err != nil {
+		return nil, err
+	}
+	if m.requestSize, err = registerCollector(opts.Registerer, &added, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      "http_request_size_bytes",
+		Help:      "Size of HTTP request bodies in bytes (Content-Length).",
+		Buckets:   opts.SizeBuckets,
+	}, labelNames), opts.SizeBuckets); err != nil {
+		return nil, err
+	}
+	if m.responseSize, err = registerCollector(opts.Registerer, &added, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      "http_response_size_bytes",
+		Help:      "Size of HTTP response bodies in bytes.",
+		Buckets:   opts.SizeBuckets,
+	}, labelNames), opts.SizeBuckets); err != nil {
+		return nil, err
+	}
+	if m.inFlight, err = registerCollector(opts.Registerer, &added, prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: opts.Namespace,
+		Subsystem: opts.Subsystem,
+		Name:      "http_requests_in_flight",
+		Help:      "Current number of HTTP requests being processed.",
+	}), nil); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// registeredCollector is a collector as registerCollector registers it: with its descriptors and histogram
+// buckets, which the registry does not compare, so a later registration can check it is identical.
+type registeredCollector[T prometheus.Collector] struct {
+	prometheus.Collector
+	c    T
+	spec string
+}
+
+// registerCollector registers c, or returns the already registered collector with the same descriptor if
+// it was registered here with the same help, labels, and buckets. Collectors it registers are appended to
+// added.
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, added *[]prometheus.Collector, c T, buckets []float64) (T, error) {
+	descs := make(chan *prometheus.Desc, 1)
+	go func() {
+		c.Describe(descs)
+		close(descs)
+	}()
+	var spec strings.Builder
+	for d := range descs {
+		spec.WriteString(d.String())
+	}
+	fmt.Fprint(&spec, buckets)
+
+	rc := &registeredCollector[T]{Collector: c, c: c, spec: spec.String()}
+	if err := reg.Register(rc); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return c, err
+		}
+		switch existing := are.ExistingCollector.(type) {
+		case *registeredCollector[T]:
+			if existing.spec != rc.spec {
+				return c, fmt.Errorf("middlewares: metric already registered with a different definition: %s", existing.spec)
+			}
+			return existing.c, nil
+		case T:
+			return existing, nil
+		}
+		return c, err
+	}
+	*added = append(*added, rc)
+	return c, nil
+}
+
+// Middleware returns the Gin middleware that records the metrics.
+func (m *HTTPMetrics) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip the metrics endpoint itself to avoid self-instrumentation noise.
-		if c.Request.URL.Path == "/metrics" {
+		if shouldSkipPath(c.Request.URL.Path, m.skipPaths) {
 			c.Next()
 			return
 		}
 
 		start := time.Now()
-		httpRequestsInFlight.Inc()
+		m.inFlight.Inc()
 
 		c.Next()
 
-		httpRequestsInFlight.Dec()
+		m.inFlight.Dec()
 
-		status := strconv.Itoa(c.Writer.Status())
 		// Use the matched route pattern (e.g. "/items/:id") not the raw path,
 		// so high-cardinality IDs don't create unbounded label values.
 		route := c.FullPath()
 		if route == "" {
 			route = "unmatched"
 		}
+		values := make([]string, 0, 3+len(m.labels))
+		values = append(values, c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		for _, l := range m.labels {
+			values = append(values, l.Extract(c))
+		}
 
-		elapsed := time.Since(start).Seconds()
-		httpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
-		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(elapsed)
+		m.requestsTotal.WithLabelValues(values...).Inc()
+		m.requestDuration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+		m.requestSize.WithLabelValues(values...).Observe(float64(max(int(c.Request.ContentLength), 0)))
+		m.responseSize.WithLabelValues(values...).Observe(float64(max(c.Writer.Size(), 0)))
 	}
 }
+
+// Handler returns a Gin handler serving the configured Gatherer in the Prometheus exposition format.
+func (m *HTTPMetrics) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{}))
+}
+
+var (
+	defaultMetrics     *HTTPMetrics
+	defaultMetricsOnce sync.Once
+)
+
+// Metrics returns a Gin middleware that exposes Prometheus HTTP metrics on the default registry
+// (see NewMetrics for the metric set). Safe to call more than once; all calls share one set of collectors.
+//
+// Register the /metrics endpoint separately using promhttp.Handler() or MetricsHandler().
+func Metrics() gin.HandlerFunc {
+	return defaultHTTPMetrics().Middleware()
+}
+
+// MetricsHandler returns a Gin handler for the default registry, e.g. router.GET("/metrics", MetricsHandler()).
+func MetricsHandler() gin.HandlerFunc {
+	return defaultHTTPMetrics().Handler()
+}
+
+func defaultHTTPMetrics() *HTTPMetrics {
+	defaultMetricsOnce.Do(func() {
+		m, err := NewMetrics(MetricsOptions{})
+		if err != nil {
+			// Same behaviour as promauto: a conflicting default registration is a programming error.
+			panic(err)
+		}
+		defaultMetrics = m
+	})
+	return defaultMetrics
+}
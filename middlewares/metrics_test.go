@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMetrics_CustomRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewMetrics(MetricsOptions{
+		Registerer: reg,
+		Namespace:  "payments",
+		Subsystem:  "api",
+		Labels:     []MetricsLabel{HeaderLabel("tenant", "X-Tenant-Id")},
+	})
+	require.NoError(t, err)
+
+	router := setupRouter()
+	router.Use(m.Middleware())
+	router.GET("/metrics", m.Handler())
+	router.POST("/items/:id", func(c *gin.Context) { c.String(http.StatusCreated, "created") })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/items/42", strings.NewReader("body"))
+	req.Header.Set("X-Tenant-Id", "acme")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requestsTotal.WithLabelValues("POST", "/items/:id", "201", "acme")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.inFlight))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/metrics", nil)
+	router.ServeHTTP(w, req)
+	body := w.Body.String()
+	assert.Contains(t, body, `payments_api_http_requests_total{method="POST",path="/items/:id",status="201",tenant="acme"} 1`)
+	assert.Contains(t, body, "payments_api_http_request_size_bytes_sum")
+	assert.Contains(t, body, "payments_api_http_response_size_bytes_sum")
+	assert.NotContains(t, body, `path="/metrics"`, "metrics endpoint is skipped")
+}
+
+func TestNewMetrics_ReusesCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a, err := NewMetrics(MetricsOptions{Registerer: reg})
+	require.NoError(t, err)
+	b, err := NewMetrics(MetricsOptions{Registerer: reg})
+	require.NoError(t, err)
+	assert.Same(t, a.requestsTotal, b.requestsTotal)
+
+	_, err = NewMetrics(MetricsOptions{Registerer: reg, Labels: []MetricsLabel{ContextLabel("service", "service_code")}})
+	assert.Error(t, err, "same names with different labels must conflict")
+	_, err = NewMetrics(MetricsOptions{Registerer: reg, DurationBuckets: []float64{0.1, 1}})
+	assert.Error(t, err, "same names with different buckets must conflict")
+	_, err = NewMetrics(MetricsOptions{Registerer: reg, SizeBuckets: []float64{1 << 10, 1 << 20}})
+	assert.Error(t, err, "same names with different buckets must conflict")
+
+	c, err := NewMetrics(MetricsOptions{Registerer: reg})
+	require.NoError(t, err, "conflicts must leave the registry as it was")
+	assert.Same(t, a.requestDuration, c.requestDuration)
+}
+
+func TestNewMetrics_ConflictRollsBack(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "http_request_size_bytes", Help: "other"}))
+	_, err := NewMetrics(MetricsOptions{Registerer: reg})
+	require.Error(t, err)
+
+	// Unregister reports whether an identical collector was registered.
+	labels := []string{"method", "path", "status"}
+	assert.False(t, reg.Unregister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total", Help: "Total number of HTTP requests.",
+	}, labels)), "http_requests_total left registered")
+	assert.False(t, reg.Unregister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "http_request_duration_seconds", Help: "Duration of HTTP requests in seconds.",
+	}, labels)), "http_request_duration_seconds left registered")
+}
+
+func TestNewMetrics_InvalidLabel(t *testing.T) {
+	_, err := NewMetrics(MetricsOptions{Registerer: prometheus.NewRegistry(), Labels: []MetricsLabel{{Name: "tenant"}}})
+	assert.Error(t, err)
+}
+
+func TestMetrics_DefaultIsIdempotent(t *testing.T) {
+	assert.NotPanics(t, func() {
+		router := setupRouter()
+		router.Use(Metrics(), Metrics())
+		router.GET("/metrics", MetricsHandler())
+	})
+}
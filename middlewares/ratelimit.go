@@ -2,10 +2,12 @@ package middlewares
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/logger"
 	"github.com/turahe/pkg/redis"
 	"github.com/turahe/pkg/response"
 
@@ -15,6 +17,8 @@ import (
 const (
 	defaultWindowSec   = 60
 	rateLimitKeyPrefix = "rate_limit:"
+	// rateLimitLoggerName is the logger name for rate limiter entries (logger.SetLevel).
+	rateLimitLoggerName = "ratelimit"
 )
 
 // Sliding-window rate limit: one Lua script, one round-trip. Uses a ZSET keyed by timestamp;
@@ -85,10 +89,12 @@ func RateLimiter() gin.HandlerFunc {
 		redisKey := rateLimitKeyPrefix + key
 		now := time.Now()
 		nowSec := now.Unix()
-		reqCtx := ctx.Request.Context()
+		reqCtx := logger.WithName(ctx.Request.Context(), rateLimitLoggerName)
 
 		result, err := rdb.Eval(reqCtx, slidingWindowScript, []string{redisKey}, nowSec, windowSec, requests, makeUniqueRequestID(now)).Result()
 		if err != nil {
+			logger.GetLogger().LogAttrs(reqCtx, slog.LevelWarn, "rate limiter unavailable, allowing request",
+				slog.String("key", redisKey), slog.String("error", err.Error()))
 			ctx.Next()
 			return
 		}
@@ -104,6 +110,10 @@ func RateLimiter() gin.HandlerFunc {
 			ttlSec = 0
 		}
 
+		logger.GetLogger().LogAttrs(reqCtx, slog.LevelDebug, "rate limit checked",
+			slog.String("key", redisKey), slog.Int64("current", current), slog.Int("limit", requests),
+			slog.Bool("allowed", current >= 0 && current <= int64(requests)))
+
 		ctx.Header("X-RateLimit-Limit", fmt.Sprintf("%d", requests))
 		ctx.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", max(0, requests-int(current))))
 		ctx.Header("X-RateLimit-Reset", fmt.Sprintf("%d", now.Unix()+ttlSec))
@@ -0,0 +1,256 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RedactionMask replaces redacted values in captured bodies and headers.
+const RedactionMask = "[REDACTED]"
+
+// DefaultRedactFields are JSON/form field names redacted at any depth (compared case-insensitively, ignoring
+// "_" and "-", so "cardNumber", "card_number", and "Card-Number" all match).
+var DefaultRedactFields = []string{
+	"password", "passwd", "pwd", "pin", "otp", "cvv", "cvc", "cvv2",
+	"token", "access_token", "refresh_token", "id_token", "secret", "client_secret", "api_key", "apikey",
+	"authorization", "card_number", "pan", "account_number", "ssn", "nik",
+}
+
+// DefaultRedactHeaders are request/response headers whose values are redacted.
+var DefaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token", "X-Debug-Token",
+}
+
+func normalizeFieldName(s string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+}
+
+// pathSegment is one step of a JSONPath: an object key ("*" for any key) or an array index (-1 for [*]).
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseJSONPath parses the subset of JSONPath used for redaction: "$.a.b", "$.items[*].pan", "$.items[0].pan",
+// "$.*.secret". Returns an error for anything else.
+func parseJSONPath(p string) ([]pathSegment, error) {
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("jsonpath %q: must start with $", p)
+	}
+	rest := p[1:]
+	var segs []pathSegment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("jsonpath %q: empty key", p)
+			}
+			segs = append(segs, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing ]", p)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if inner == "*" {
+				segs = append(segs, pathSegment{index: -1, isIdx: true})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("jsonpath %q: invalid index %q", p, inner)
+			}
+			segs = append(segs, pathSegment{index: n, isIdx: true})
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", p, rest[0])
+		}
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("jsonpath %q: no segments", p)
+	}
+	return segs, nil
+}
+
+// jsonRedactor masks decoded JSON values (map[string]interface{}, []interface{}, scalars) in place.
+type jsonRedactor struct {
+	fields map[string]struct{}
+	paths  [][]pathSegment
+}
+
+func newJSONRedactor(fields, paths []string) (*jsonRedactor, error) {
+	r := &jsonRedactor{fields: make(map[string]struct{}, len(fields))}
+	for _, f := range fields {
+		r.fields[normalizeFieldName(f)] = struct{}{}
+	}
+	for _, p := range paths {
+		segs, err := parseJSONPath(p)
+		if err != nil {
+			return nil, err
+		}
+		r.paths = append(r.paths, segs)
+	}
+	return r, nil
+}
+
+// redact returns v with sensitive fields, configured paths, and card numbers masked.
+func (r *jsonRedactor) redact(v interface{}) interface{} {
+	for _, segs := range r.paths {
+		v = redactPath(v, segs)
+	}
+	return r.redactFields(v)
+}
+
+func (r *jsonRedactor) redactFields(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if _, ok := r.fields[normalizeFieldName(k)]; ok {
+				t[k] = RedactionMask
+				continue
+			}
+			t[k] = r.redactFields(child)
+		}
+		return t
+	case []interface{}:
+		for i, child := range t {
+			t[i] = r.redactFields(child)
+		}
+		return t
+	case string:
+		return maskPAN(t)
+	case json.Number:
+		// Bodies are decoded with UseNumber, so a card number sent as a JSON number arrives here.
+		if masked := maskPAN(t.String()); masked != t.String() {
+			return masked
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func redactPath(v interface{}, segs []pathSegment) interface{} {
+	if len(segs) == 0 {
+		return RedactionMask
+	}
+	seg, rest := segs[0], segs[1:]
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if seg.isIdx {
+			return v
+		}
+		if seg.key == "*" {
+			for k, child := range t {
+				t[k] = redactPath(child, rest)
+			}
+		} else if child, ok := t[seg.key]; ok {
+			t[seg.key] = redactPath(child, rest)
+		}
+		return t
+	case []interface{}:
+		if !seg.isIdx {
+			return v
+		}
+		if seg.index < 0 {
+			for i, child := range t {
+				t[i] = redactPath(child, rest)
+			}
+		} else if seg.index < len(t) {
+			t[seg.index] = redactPath(t[seg.index], rest)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// redactQuery returns the raw query string with the values of sensitive parameters masked and card numbers
+// in other values masked by maskPAN; the rest is kept as sent.
+func (r *jsonRedactor) redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	params := strings.Split(raw, "&")
+	for i, p := range params {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok {
+			continue
+		}
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if _, sensitive := r.fields[normalizeFieldName(name)]; sensitive {
+			params[i] = p[:len(p)-len(value)] + RedactionMask
+		} else if v, err := url.QueryUnescape(value); err == nil {
+			if masked := maskPAN(v); masked != v {
+				params[i] = p[:len(p)-len(value)] + masked
+			}
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// maskPAN masks s when it looks like a payment card number (13-19 digits, optionally separated by spaces or
+// dashes, passing the Luhn check), keeping the last four digits.
+func maskPAN(s string) string {
+	if len(s) < 13 || len(s) > 23 {
+		return s
+	}
+	digits := make([]byte, 0, 19)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == ' ' || c == '-':
+		default:
+			return s
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 || !luhnValid(digits) {
+		return s
+	}
+	return strings.Repeat("*", len(digits)-4) + string(digits[len(digits)-4:])
+}
+
+func luhnValid(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// redactHeaders returns a flat copy of h with values of sensitive headers masked.
+func redactHeaders(h http.Header, sensitive map[string]struct{}) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if _, ok := sensitive[http.CanonicalHeaderKey(k)]; ok {
+			out[k] = RedactionMask
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
@@ -1,11 +1,14 @@
 package middlewares
 
 import (
+	"context"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/turahe/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // GCP format: X-Cloud-Trace-Context: TRACE_ID/SPAN_ID;o=TRACE_TRUE
@@ -17,6 +20,104 @@ const (
 	HeaderRequestID         = "X-Request-Id"
 )
 
+// W3C Trace Context: traceparent: VERSION-TRACE_ID-PARENT_ID-FLAGS, tracestate: vendor-specific list.
+// See: https://www.w3.org/TR/trace-context/
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+// ParseTraceparent parses a W3C traceparent header.
+// Format: 00-{32 hex trace-id}-{16 hex parent-id}-{2 hex flags}; sampled is the low bit of flags.
+// Returns ok false for malformed headers, version ff, and all-zero trace or parent IDs.
+// Future versions are accepted as long as the first four fields are well-formed.
+func ParseTraceparent(header string) (traceID, spanID string, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return "", "", false, false
+	}
+	version, tid, sid, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false, false
+	}
+	if !isLowerHex(tid, 32) || !isLowerHex(sid, 16) || !isLowerHex(flags, 2) {
+		return "", "", false, false
+	}
+	if strings.Trim(tid, "0") == "" || strings.Trim(sid, "0") == "" {
+		return "", "", false, false
+	}
+	return tid, sid, hexValue(flags[1])&1 == 1, true
+}
+
+// FormatTraceparent builds a version 00 traceparent header value from IDs and the sampled flag.
+func FormatTraceparent(traceID, spanID string, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + traceID + "-" + spanID + "-" + flags
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func hexValue(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
+
+// incomingTrace resolves trace and span IDs from, in order: an active OpenTelemetry span already in ctx
+// (e.g. started by tracing.Middleware), the W3C traceparent/tracestate headers, and X-Cloud-Trace-Context.
+// A valid traceparent is stored in the returned context as the remote parent span context, so outbound
+// propagation (tracing.NewTransport, any OTel propagator) continues the caller's trace. w3c reports
+// whether the IDs came from an OpenTelemetry or W3C source.
+func incomingTrace(ctx context.Context, req *http.Request) (_ context.Context, traceID, spanID string, w3c bool) {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return ctx, sc.TraceID().String(), sc.SpanID().String(), true
+	}
+	if v := req.Header.Get(HeaderTraceparent); v != "" {
+		if tid, sid, sampled, ok := ParseTraceparent(v); ok {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, remoteSpanContext(tid, sid, sampled, req.Header.Get(HeaderTracestate)))
+			return ctx, tid, sid, true
+		}
+	}
+	if v := req.Header.Get(HeaderCloudTraceContext); v != "" {
+		if tid, sid, ok := ParseCloudTraceContext(v); ok {
+			return ctx, tid, sid, false
+		}
+	}
+	return ctx, "", "", false
+}
+
+// remoteSpanContext converts parsed traceparent fields into an OpenTelemetry span context. An invalid
+// tracestate is dropped, as the W3C spec requires, without discarding the traceparent.
+func remoteSpanContext(traceID, spanID string, sampled bool, tracestate string) trace.SpanContext {
+	tid, _ := trace.TraceIDFromHex(traceID)
+	sid, _ := trace.SpanIDFromHex(spanID)
+	cfg := trace.SpanContextConfig{TraceID: tid, SpanID: sid, Remote: true}
+	if sampled {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	if tracestate != "" {
+		if ts, err := trace.ParseTraceState(tracestate); err == nil {
+			cfg.TraceState = ts
+		}
+	}
+	return trace.NewSpanContext(cfg)
+}
+
 // ParseCloudTraceContext parses X-Cloud-Trace-Context header.
 // Format: TRACE_ID/SPAN_ID;o=TRACE_TRUE (o=1 means sampled).
 // Returns traceID, spanID, and whether the header was present.
@@ -39,23 +140,14 @@ func ParseCloudTraceContext(header string) (traceID, spanID string, ok bool) {
 	return traceID, spanID, true
 }
 
-// CloudTraceMiddleware returns Gin middleware that extracts X-Cloud-Trace-Context,
+// CloudTraceMiddleware returns Gin middleware that extracts W3C traceparent/tracestate, X-Cloud-Trace-Context,
 // X-Request-ID, and X-Correlation-ID, injects traceID/spanID/correlationID into context,
 // and echoes headers. Correlation ID is never overwritten when already set (business transaction ID).
 func CloudTraceMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		req := c.Request
-		ctx := req.Context()
-
-		traceID := ""
-		spanID := ""
+		ctx, traceID, spanID, w3c := incomingTrace(req.Context(), req)
 
-		if v := req.Header.Get(HeaderCloudTraceContext); v != "" {
-			if tid, sid, ok := ParseCloudTraceContext(v); ok {
-				traceID = tid
-				spanID = sid
-			}
-		}
 		if traceID == "" {
 			traceID = req.Header.Get(HeaderTraceID)
 		}
@@ -84,7 +176,13 @@ func CloudTraceMiddleware() gin.HandlerFunc {
 		c.Request = req.WithContext(ctx)
 
 		c.Header(HeaderTraceID, traceID)
-		if spanID != "" {
+		if w3c {
+			sc := trace.SpanContextFromContext(ctx)
+			c.Header(HeaderTraceparent, FormatTraceparent(traceID, spanID, sc.IsSampled()))
+			if ts := sc.TraceState().String(); ts != "" {
+				c.Header(HeaderTracestate, ts)
+			}
+		} else if spanID != "" {
 			c.Header("X-Cloud-Trace-Context", traceID+"/"+spanID+";o=1")
 		}
 		c.Header(HeaderCorrelationID, correlationID)
@@ -124,22 +222,13 @@ func RequestID() gin.HandlerFunc {
 }
 
 // TraceMiddleware returns Gin middleware that reads X-Trace-Id, X-Correlation-Id, X-Request-Id,
-// and optionally X-Cloud-Trace-Context. Correlation ID is never overwritten when already set.
+// and optionally W3C traceparent/tracestate or X-Cloud-Trace-Context. An active OpenTelemetry span in
+// the request context takes precedence. Correlation ID is never overwritten when already set.
 func TraceMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		req := c.Request
-		ctx := req.Context()
+		ctx, traceID, spanID, _ := incomingTrace(req.Context(), req)
 
-		traceID := ""
-		spanID := ""
-		if v := req.Header.Get(HeaderCloudTraceContext); v != "" {
-			var ok bool
-			traceID, spanID, ok = ParseCloudTraceContext(v)
-			if !ok {
-				traceID = ""
-				spanID = ""
-			}
-		}
 		if traceID == "" {
 			traceID = req.Header.Get(HeaderTraceID)
 		}
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/turahe/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestTraceMiddleware(t *testing.T) {
@@ -195,3 +196,100 @@ func TestRequestID(t *testing.T) {
 		assert.Equal(t, "log-me", logger.GetCorrelationID(loggedCtx))
 	})
 }
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantTraceID string
+		wantSpanID  string
+		wantSampled bool
+		wantOK      bool
+	}{
+		{"valid sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true, true},
+		{"valid not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", false, true},
+		{"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true, true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", "", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", "", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", "", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", "", false, false},
+		{"uppercase hex", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", "", false, false},
+		{"short trace id", "00-4bf92f35-00f067aa0ba902b7-01", "", "", false, false},
+		{"empty", "", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, spanID, sampled, ok := ParseTraceparent(tt.header)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantTraceID, traceID)
+			assert.Equal(t, tt.wantSpanID, spanID)
+			assert.Equal(t, tt.wantSampled, sampled)
+		})
+	}
+}
+
+func TestTraceMiddleware_Traceparent(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("traceparent takes precedence over X-Cloud-Trace-Context and X-Trace-Id", func(t *testing.T) {
+		var gotTraceID, gotSpanID string
+		var gotSpanContext trace.SpanContext
+		router := setupRouter()
+		router.Use(TraceMiddleware())
+		router.GET("/", func(c *gin.Context) {
+			gotTraceID = logger.GetTraceID(c.Request.Context())
+			gotSpanID = logger.GetSpanID(c.Request.Context())
+			gotSpanContext = trace.SpanContextFromContext(c.Request.Context())
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set(HeaderTraceparent, traceparent)
+		req.Header.Set(HeaderTracestate, "congo=t61rcWkgMzE")
+		req.Header.Set(HeaderCloudTraceContext, "105445aa7843bc8bf206b12000100000/1;o=1")
+		req.Header.Set(HeaderTraceID, "trace-ignored")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", gotTraceID)
+		assert.Equal(t, "00f067aa0ba902b7", gotSpanID)
+		assert.True(t, gotSpanContext.IsRemote())
+		assert.True(t, gotSpanContext.IsSampled())
+		assert.Equal(t, "congo=t61rcWkgMzE", gotSpanContext.TraceState().String())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(HeaderTraceID))
+	})
+
+	t.Run("invalid traceparent falls back to X-Cloud-Trace-Context", func(t *testing.T) {
+		var gotTraceID string
+		router := setupRouter()
+		router.Use(TraceMiddleware())
+		router.GET("/", func(c *gin.Context) {
+			gotTraceID = logger.GetTraceID(c.Request.Context())
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set(HeaderTraceparent, "garbage")
+		req.Header.Set(HeaderCloudTraceContext, "105445aa7843bc8bf206b12000100000/1;o=1")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "105445aa7843bc8bf206b12000100000", gotTraceID)
+	})
+
+	t.Run("CloudTraceMiddleware echoes traceparent and tracestate", func(t *testing.T) {
+		router := setupRouter()
+		router.Use(CloudTraceMiddleware())
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set(HeaderTraceparent, traceparent)
+		req.Header.Set(HeaderTracestate, "congo=t61rcWkgMzE")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, traceparent, w.Header().Get(HeaderTraceparent))
+		assert.Equal(t, "congo=t61rcWkgMzE", w.Header().Get(HeaderTracestate))
+		assert.Empty(t, w.Header().Get(HeaderCloudTraceContext))
+	})
+}
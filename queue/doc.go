@@ -0,0 +1,36 @@
+/*
+Package queue provides a reliable background job queue on Redis Streams: producers Enqueue typed jobs, and
+workers in any number of processes handle them with bounded concurrency, retries, and dead-lettering.
+
+Role in architecture:
+  - Infrastructure service over the client of package redis (any redis.Cmdable, standalone or cluster);
+    services enqueue work such as e-mails or webhooks and register handlers, and server.New runs the
+    workers as a server.Component (Start, Stop).
+
+Responsibilities:
+  - Enqueue: JSON-encoded jobs with optional Delay or At (kept in a sorted set and moved to the stream when
+    due) and per-job MaxAttempts.
+  - Handle[T] and Run / Start / Stop: consumer-group reads with at most Options.Concurrency jobs in flight;
+    a failed job is retried with full-jitter exponential backoff (RetryBaseDelay, RetryMaxDelay) until
+    MaxAttempts, then moved to the dead-letter stream, as are Permanent errors, undecodable payloads, and
+    unknown job types. A panicking handler is recovered and reported like an error.
+  - Crash recovery: jobs left pending by a worker that died are claimed by another after ClaimIdle, and
+    consumers idle that long with nothing pending are removed from the group; running jobs are kept alive
+    so they are not claimed while their handler runs.
+  - Graceful shutdown: reads stop, in-flight jobs finish within ShutdownTimeout (or Stop's context), and jobs
+    cancelled after that are retried.
+  - Stats, DeadLetters, Requeue: queue depth and dead-letter inspection and replay.
+  - Metrics (Queue.RegisterMetrics): enqueued and processed jobs, handler duration, wait time, in-flight
+    jobs, and queue_depth by state.
+
+Constraints:
+  - Delivery is at-least-once: a job may run again after a crash or a lost acknowledgement, so handlers
+    must be idempotent.
+  - The keys of a queue (queue:{Name}:stream, :delayed, :dead) share a hash tag and live in one cluster
+    slot; spread load with several queues.
+  - Retries and delayed jobs run no earlier than their time, up to SchedulerInterval later.
+
+This package must NOT:
+  - Know about repositories, models, or HTTP; job types and handlers come from callers.
+*/
+package queue
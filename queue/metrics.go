@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// depthTimeout bounds the Redis calls made for one scrape of queue_depth.
+const depthTimeout = 2 * time.Second
+
+// Job metrics are shared by every Queue and distinguished by the queue label (Options.Name).
+var (
+	jobsEnqueuedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "queue_jobs_enqueued_total",
+			Help: "Jobs enqueued by type.",
+		},
+		[]string{"queue", "type"},
+	)
+	jobsProcessedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "queue_jobs_processed_total",
+			Help: "Job attempts by type and outcome (succeeded, retried, dead).",
+		},
+		[]string{"queue", "type", "status"},
+	)
+	jobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "queue_job_duration_seconds",
+			Help:    "Duration of job handlers in seconds by type and outcome.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		},
+		[]string{"queue", "type", "status"},
+	)
+	jobWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "queue_job_wait_seconds",
+			Help:    "Time from when a job was due to when its handler started, in seconds.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+		},
+		[]string{"queue", "type"},
+	)
+	jobsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "queue_jobs_in_flight",
+			Help: "Jobs being handled by this process.",
+		},
+		[]string{"queue"},
+	)
+)
+
+// depthCollector reports Stats of one queue on each scrape.
+type depthCollector struct {
+	q    *Queue
+	desc *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector.
+func (c *depthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector. Nothing is reported when Redis cannot be reached.
+func (c *depthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), depthTimeout)
+	defer cancel()
+	s, err := c.q.Stats(ctx)
+	if err != nil {
+		return
+	}
+	for state, v := range map[string]int64{"ready": s.Ready, "pending": s.Pending, "delayed": s.Delayed, "dead": s.Dead} {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(v), state)
+	}
+}
+
+// RegisterMetrics registers the job metrics on reg (prometheus.DefaultRegisterer when nil) together with
+// queue_depth for this queue (label state: ready, pending, delayed, dead), read from Redis on each scrape:
+// queue_jobs_enqueued_total, queue_jobs_processed_total, queue_job_duration_seconds, queue_job_wait_seconds,
+// and queue_jobs_in_flight. Call once per queue.
+func (q *Queue) RegisterMetrics(reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	for _, c := range []prometheus.Collector{jobsEnqueuedTotal, jobsProcessedTotal, jobDuration, jobWaitSeconds, jobsInFlight} {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				return err
+			}
+		}
+	}
+	return reg.Register(&depthCollector{
+		q: q,
+		desc: prometheus.NewDesc("queue_depth", "Jobs in the queue by state.",
+			[]string{"state"}, prometheus.Labels{"queue": q.opts.Name}),
+	})
+}
@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+const (
+	defaultName              = "default"
+	defaultGroup             = "workers"
+	defaultConcurrency       = 10
+	defaultMaxAttempts       = 5
+	defaultRetryBaseDelay    = time.Second
+	defaultRetryMaxDelay     = 10 * time.Minute
+	defaultClaimIdle         = 5 * time.Minute
+	defaultClaimInterval     = 30 * time.Second
+	defaultSchedulerInterval = time.Second
+	defaultBlockTimeout      = 5 * time.Second
+	defaultShutdownTimeout   = 30 * time.Second
+)
+
+// Options holds Queue settings. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Name selects the Redis keys (queue:{Name}:stream, :delayed, :dead) and labels metrics; default
+	// "default".
+	Name string
+	// Group is the consumer group shared by all workers of the queue; default "workers".
+	Group string
+	// Consumer names this worker within Group; default hostname plus a random suffix. Pending jobs of a
+	// consumer that disappears are claimed by the others after ClaimIdle, then the consumer is deleted.
+	Consumer string
+	// Concurrency is the number of jobs handled at once by Run; default 10.
+	Concurrency int
+	// MaxAttempts is the default number of attempts per job before it is dead-lettered; default 5.
+	MaxAttempts int
+	// RetryBaseDelay and RetryMaxDelay bound the full-jitter exponential backoff between attempts; default 1s
+	// and 10m.
+	RetryBaseDelay, RetryMaxDelay time.Duration
+	// ClaimIdle is how long a delivered job may go without progress before another worker claims it;
+	// default 5m. Running jobs are kept alive while their handler runs, so it need not exceed the longest
+	// job.
+	ClaimIdle time.Duration
+	// ClaimInterval is how often pending jobs are checked for claiming; default 30s.
+	ClaimInterval time.Duration
+	// SchedulerInterval is how often due delayed jobs and retries are moved to the stream; default 1s.
+	SchedulerInterval time.Duration
+	// BlockTimeout is the longest a read waits for new jobs; default 5s.
+	BlockTimeout time.Duration
+	// ShutdownTimeout is how long Run waits for in-flight jobs after its context is cancelled before
+	// cancelling their contexts; default 30s. Stop uses its own context instead.
+	ShutdownTimeout time.Duration
+}
+
+func (o *Options) applyDefaults() {
+	if o.Name == "" {
+		o.Name = defaultName
+	}
+	if o.Group == "" {
+		o.Group = defaultGroup
+	}
+	if o.Consumer == "" {
+		o.Consumer = defaultConsumer()
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	if o.RetryBaseDelay <= 0 {
+		o.RetryBaseDelay = defaultRetryBaseDelay
+	}
+	if o.RetryMaxDelay < o.RetryBaseDelay {
+		o.RetryMaxDelay = max(defaultRetryMaxDelay, o.RetryBaseDelay)
+	}
+	if o.ClaimIdle <= 0 {
+		o.ClaimIdle = defaultClaimIdle
+	}
+	if o.ClaimInterval <= 0 {
+		o.ClaimInterval = defaultClaimInterval
+	}
+	if o.SchedulerInterval <= 0 {
+		o.SchedulerInterval = defaultSchedulerInterval
+	}
+	if o.BlockTimeout <= 0 {
+		o.BlockTimeout = defaultBlockTimeout
+	}
+	if o.ShutdownTimeout <= 0 {
+		o.ShutdownTimeout = defaultShutdownTimeout
+	}
+}
+
+func defaultConsumer() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "worker"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// Option is a functional option applied to Options.
+type Option func(*Options)
+
+// WithName sets the queue name.
+func WithName(name string) Option {
+	return func(o *Options) { o.Name = name }
+}
+
+// WithConcurrency sets the number of jobs handled at once.
+func WithConcurrency(n int) Option {
+	return func(o *Options) { o.Concurrency = n }
+}
+
+// WithMaxAttempts sets the default number of attempts per job.
+func WithMaxAttempts(n int) Option {
+	return func(o *Options) { o.MaxAttempts = n }
+}
+
+// WithRetryDelay sets the base and maximum retry backoff.
+func WithRetryDelay(base, maxDelay time.Duration) Option {
+	return func(o *Options) {
+		o.RetryBaseDelay = base
+		o.RetryMaxDelay = maxDelay
+	}
+}
+
+// WithClaimIdle sets how long a job may go without progress before another worker claims it.
+func WithClaimIdle(d time.Duration) Option {
+	return func(o *Options) { o.ClaimIdle = d }
+}
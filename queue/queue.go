@@ -0,0 +1,264 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNoHandler is recorded on jobs dead-lettered because no handler is registered for their type.
+var ErrNoHandler = errors.New("queue: no handler for job type")
+
+// jobField is the stream entry field holding the encoded Job.
+const jobField = "job"
+
+// Job is a unit of work. It is stored as JSON in the stream, the delayed set, and the dead-letter stream.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempt     int             `json:"attempt"` // 1 on the first run
+	MaxAttempts int             `json:"max_attempts"`
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+	RunAt       time.Time       `json:"run_at"`
+	// Error is the last failure, set on retried and dead-lettered jobs.
+	Error string `json:"error,omitempty"`
+	// StreamID is the Redis stream entry ID of a job read from a stream.
+	StreamID string `json:"-"`
+}
+
+// EnqueueOptions configures one job.
+type EnqueueOptions struct {
+	// Delay runs the job after this duration.
+	Delay time.Duration
+	// At runs the job at this time; Delay takes precedence.
+	At time.Time
+	// MaxAttempts overrides Options.MaxAttempts.
+	MaxAttempts int
+}
+
+// permanentError marks a failure that is not retried.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered at once instead of retried, e.g. for invalid payloads.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+type jobKey struct{}
+
+// JobFromContext returns the job being handled, for handlers that need its ID or attempt number.
+func JobFromContext(ctx context.Context) (*Job, bool) {
+	j, ok := ctx.Value(jobKey{}).(*Job)
+	return j, ok
+}
+
+// Queue is a reliable job queue on a Redis stream with a consumer group: a job is removed only after its
+// handler succeeds or it is dead-lettered, so a crashed worker's jobs are claimed by another. Producers call
+// Enqueue; workers register handlers with Handle and call Run (or Start and Stop).
+type Queue struct {
+	client  redis.Cmdable
+	opts    Options
+	stream  string
+	delayed string
+	dead    string
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]handlerFunc
+
+	runMu   sync.Mutex
+	running *run
+}
+
+// New returns a Queue on client, e.g. redis.GetUniversalClient() from package github.com/turahe/pkg/redis.
+// Options are applied first, then override. The keys share a hash tag, so the queue works on Redis Cluster.
+func New(client redis.Cmdable, opts Options, override ...Option) *Queue {
+	for _, o := range override {
+		o(&opts)
+	}
+	opts.applyDefaults()
+	prefix := "queue:{" + opts.Name + "}:"
+	return &Queue{
+		client:   client,
+		opts:     opts,
+		stream:   prefix + "stream",
+		delayed:  prefix + "delayed",
+		dead:     prefix + "dead",
+		now:      time.Now,
+		handlers: make(map[string]handlerFunc),
+	}
+}
+
+// Handle registers h for jobs of jobType, replacing any previous handler. Payloads are decoded from JSON
+// into T; a payload that does not decode is dead-lettered. Returning an error retries the job with backoff
+// (Permanent dead-letters it at once); a panic counts as an error. Register handlers before Run.
+func Handle[T any](q *Queue, jobType string, h func(ctx context.Context, payload T) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode %s payload: %w", jobType, err))
+		}
+		return h(ctx, payload)
+	}
+}
+
+// Enqueue adds a job of jobType with payload encoded as JSON and returns its ID. Jobs with a Delay or a
+// future At wait in the delayed set until they are due.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any, opts EnqueueOptions) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encode %s payload: %w", jobType, err)
+	}
+	now := q.now()
+	job := &Job{
+		ID:          newID(),
+		Type:        jobType,
+		Payload:     data,
+		Attempt:     1,
+		MaxAttempts: q.opts.MaxAttempts,
+		EnqueuedAt:  now,
+		RunAt:       now,
+	}
+	if opts.MaxAttempts > 0 {
+		job.MaxAttempts = opts.MaxAttempts
+	}
+	switch {
+	case opts.Delay > 0:
+		job.RunAt = now.Add(opts.Delay)
+	case opts.At.After(now):
+		job.RunAt = opts.At
+	}
+	b, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	if job.RunAt.After(now) {
+		err = q.client.ZAdd(ctx, q.delayed, redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: b}).Err()
+	} else {
+		err = q.client.XAdd(ctx, &redis.XAddArgs{Stream: q.stream, Values: map[string]interface{}{jobField: b}}).Err()
+	}
+	if err != nil {
+		return "", fmt.Errorf("enqueue %s: %w", jobType, err)
+	}
+	jobsEnqueuedTotal.WithLabelValues(q.opts.Name, jobType).Inc()
+	return job.ID, nil
+}
+
+// Stats is a snapshot of the queue depth.
+type Stats struct {
+	// Ready jobs wait in the stream; Pending jobs are being handled (or were, by a worker that died).
+	Ready, Pending int64
+	// Delayed jobs and retries wait for their time; Dead jobs exhausted their attempts.
+	Delayed, Dead int64
+}
+
+// Stats returns the current queue depth.
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	var s Stats
+	length, err := q.client.XLen(ctx, q.stream).Result()
+	if err != nil {
+		return s, err
+	}
+	pending, err := q.client.XPending(ctx, q.stream, q.opts.Group).Result()
+	switch {
+	case err == nil:
+		s.Pending = pending.Count
+	case !isNoGroup(err):
+		return s, err
+	}
+	s.Ready = max(length-s.Pending, 0)
+	if s.Delayed, err = q.client.ZCard(ctx, q.delayed).Result(); err != nil {
+		return s, err
+	}
+	if s.Dead, err = q.client.XLen(ctx, q.dead).Result(); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// DeadLetters returns up to count dead-lettered jobs, newest first. Job.StreamID identifies them for
+// Requeue.
+func (q *Queue) DeadLetters(ctx context.Context, count int64) ([]*Job, error) {
+	msgs, err := q.client.XRevRangeN(ctx, q.dead, "+", "-", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(msgs))
+	for _, m := range msgs {
+		job, err := decodeJob(m)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Requeue moves dead-lettered job streamID back to the queue with a fresh set of attempts.
+func (q *Queue) Requeue(ctx context.Context, streamID string) error {
+	msgs, err := q.client.XRange(ctx, q.dead, streamID, streamID).Result()
+	if err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("queue: dead letter %s not found", streamID)
+	}
+	job, err := decodeJob(msgs[0])
+	if err != nil {
+		return err
+	}
+	job.Attempt, job.Error, job.RunAt = 1, "", q.now()
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.stream, Values: map[string]interface{}{jobField: b}})
+		pipe.XDel(ctx, q.dead, streamID)
+		return nil
+	})
+	return err
+}
+
+// decodeJob decodes a stream entry.
+func decodeJob(m redis.XMessage) (*Job, error) {
+	raw, ok := m.Values[jobField].(string)
+	if !ok {
+		return nil, fmt.Errorf("queue: entry %s has no %q field", m.ID, jobField)
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("queue: entry %s: %w", m.ID, err)
+	}
+	job.StreamID = m.ID
+	return &job, nil
+}
+
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
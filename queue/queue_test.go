@@ -0,0 +1,403 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/turahe/pkg/redis"
+)
+
+func TestOptions_Defaults(t *testing.T) {
+	var o Options
+	o.applyDefaults()
+	if o.Name != defaultName || o.Group != defaultGroup || o.Consumer == "" || o.Concurrency != defaultConcurrency ||
+		o.MaxAttempts != defaultMaxAttempts || o.RetryMaxDelay != defaultRetryMaxDelay || o.ClaimIdle != defaultClaimIdle {
+		t.Errorf("defaults = %+v", o)
+	}
+	q := New(nil, Options{}, WithName("mail"), WithConcurrency(3), WithRetryDelay(time.Millisecond, time.Second))
+	if q.stream != "queue:{mail}:stream" || q.opts.Concurrency != 3 || q.opts.RetryBaseDelay != time.Millisecond {
+		t.Errorf("New: stream %q, opts %+v", q.stream, q.opts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	q := New(nil, Options{RetryBaseDelay: 100 * time.Millisecond, RetryMaxDelay: time.Second})
+	for n, upper := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second, 100: time.Second} {
+		for range 50 {
+			if d := q.backoff(n); d <= 0 || d > upper {
+				t.Fatalf("backoff(%d) = %v, want (0, %v]", n, d, upper)
+			}
+		}
+	}
+}
+
+func TestBackoffCeiling(t *testing.T) {
+	tests := []struct {
+		base, limit time.Duration
+		n           int
+		want        time.Duration
+	}{
+		{100 * time.Millisecond, time.Second, 1, 100 * time.Millisecond},
+		{100 * time.Millisecond, time.Second, 4, 800 * time.Millisecond},
+		{100 * time.Millisecond, time.Second, 5, time.Second},
+		{time.Second, 10 * time.Minute, 10, 512 * time.Second},
+		{time.Second, 10 * time.Minute, 40, 10 * time.Minute},
+		{time.Second, 10 * time.Minute, 70, 10 * time.Minute},
+		{time.Nanosecond, time.Duration(1<<63 - 1), 63, 1 << 62},
+		{time.Nanosecond, time.Duration(1<<63 - 1), 70, time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		q := New(nil, Options{RetryBaseDelay: tt.base, RetryMaxDelay: tt.limit})
+		if got := q.backoffCeiling(tt.n); got != tt.want {
+			t.Errorf("backoffCeiling(%d) with base %v, max %v = %v, want %v", tt.n, tt.base, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("bad payload")
+	err := Permanent(base)
+	var perm *permanentError
+	if !errors.Is(err, base) || !errors.As(err, &perm) {
+		t.Errorf("Permanent(%v) = %v: does not wrap", base, err)
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+}
+
+func TestDecodeJob(t *testing.T) {
+	job, err := decodeJob(goredis.XMessage{ID: "1-0", Values: map[string]interface{}{jobField: `{"id":"a","type":"email","payload":{"to":"x"},"attempt":2}`}})
+	if err != nil || job.ID != "a" || job.Type != "email" || job.Attempt != 2 || job.StreamID != "1-0" {
+		t.Errorf("decodeJob = %+v, %v", job, err)
+	}
+	if _, err := decodeJob(goredis.XMessage{ID: "2-0"}); err == nil {
+		t.Error("decodeJob without job field: want error")
+	}
+}
+
+type email struct {
+	To string `json:"to"`
+}
+
+// newIntegrationQueue returns a Queue on a reachable Redis with fast timings, or skips the test.
+func newIntegrationQueue(t *testing.T, override ...Option) *Queue {
+	t.Helper()
+	host, port := os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if port == "" {
+		port = "6379"
+	}
+	if !redis.Available(host, port, 500*time.Millisecond) {
+		t.Skip("Redis not available")
+	}
+	client := goredis.NewClient(&goredis.Options{Addr: host + ":" + port})
+	opts := Options{
+		Name:              "pkg-integration-" + t.Name(),
+		RetryBaseDelay:    10 * time.Millisecond,
+		RetryMaxDelay:     20 * time.Millisecond,
+		SchedulerInterval: 10 * time.Millisecond,
+		ClaimInterval:     20 * time.Millisecond,
+		BlockTimeout:      50 * time.Millisecond,
+	}
+	q := New(client, opts, override...)
+	t.Cleanup(func() {
+		client.Del(context.Background(), q.stream, q.delayed, q.dead)
+		client.Close()
+	})
+	client.Del(context.Background(), q.stream, q.delayed, q.dead)
+	return q
+}
+
+// runQueue runs q until the test ends.
+func runQueue(t *testing.T, q *Queue) {
+	t.Helper()
+	if err := q.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := q.Stop(ctx); err != nil {
+			t.Errorf("Stop: %v", err)
+		}
+	})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIntegration_EnqueueAndHandle(t *testing.T) {
+	q := newIntegrationQueue(t)
+	var mu sync.Mutex
+	var got []string
+	Handle(q, "email", func(ctx context.Context, e email) error {
+		job, ok := JobFromContext(ctx)
+		if !ok || job.Type != "email" || job.Attempt != 1 {
+			t.Errorf("JobFromContext = %+v, %v", job, ok)
+		}
+		mu.Lock()
+		got = append(got, e.To)
+		mu.Unlock()
+		return nil
+	})
+	ctx := context.Background()
+	for _, to := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if _, err := q.Enqueue(ctx, "email", email{To: to}, EnqueueOptions{}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	runQueue(t, q)
+	waitFor(t, "3 jobs", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 3
+	})
+	waitFor(t, "empty stream", func() bool {
+		s, err := q.Stats(ctx)
+		return err == nil && s == Stats{}
+	})
+}
+
+func TestIntegration_Delayed(t *testing.T) {
+	q := newIntegrationQueue(t)
+	ran := make(chan time.Time, 1)
+	Handle(q, "reminder", func(context.Context, email) error {
+		ran <- time.Now()
+		return nil
+	})
+	runQueue(t, q)
+	ctx := context.Background()
+	start := time.Now()
+	if _, err := q.Enqueue(ctx, "reminder", email{}, EnqueueOptions{Delay: 200 * time.Millisecond}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if s, _ := q.Stats(ctx); s.Delayed != 1 {
+		t.Errorf("Stats.Delayed = %d, want 1", s.Delayed)
+	}
+	select {
+	case at := <-ran:
+		if at.Sub(start) < 200*time.Millisecond {
+			t.Errorf("ran after %v, before its delay", at.Sub(start))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delayed job did not run")
+	}
+}
+
+func TestIntegration_RetryThenDeadLetter(t *testing.T) {
+	q := newIntegrationQueue(t)
+	var attempts atomic.Int32
+	Handle(q, "flaky", func(ctx context.Context, _ email) error {
+		attempts.Add(1)
+		return errors.New("smtp down")
+	})
+	var okAttempts atomic.Int32
+	Handle(q, "recovers", func(ctx context.Context, _ email) error {
+		if okAttempts.Add(1) < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	runQueue(t, q)
+	ctx := context.Background()
+	q.Enqueue(ctx, "flaky", email{}, EnqueueOptions{MaxAttempts: 3})
+	q.Enqueue(ctx, "recovers", email{}, EnqueueOptions{})
+
+	waitFor(t, "dead letter", func() bool {
+		s, err := q.Stats(ctx)
+		return err == nil && s.Dead == 1 && s.Delayed == 0 && s.Ready == 0 && s.Pending == 0
+	})
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("flaky attempts = %d, want 3", n)
+	}
+	if n := okAttempts.Load(); n != 2 {
+		t.Errorf("recovers attempts = %d, want 2", n)
+	}
+	dead, err := q.DeadLetters(ctx, 10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("DeadLetters = %v, %v", dead, err)
+	}
+	if dead[0].Type != "flaky" || dead[0].Attempt != 3 || dead[0].Error != "smtp down" {
+		t.Errorf("dead letter = %+v", dead[0])
+	}
+
+	if err := q.Requeue(ctx, dead[0].StreamID); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	waitFor(t, "requeued job to run", func() bool { return attempts.Load() > 3 })
+}
+
+func TestIntegration_PermanentAndUnknownType(t *testing.T) {
+	q := newIntegrationQueue(t)
+	var calls atomic.Int32
+	Handle(q, "invalid", func(context.Context, email) error {
+		calls.Add(1)
+		return Permanent(errors.New("no such user"))
+	})
+	runQueue(t, q)
+	ctx := context.Background()
+	q.Enqueue(ctx, "invalid", email{}, EnqueueOptions{})
+	q.Enqueue(ctx, "unregistered", email{}, EnqueueOptions{})
+	q.Enqueue(ctx, "invalid", "not an object", EnqueueOptions{})
+
+	waitFor(t, "3 dead letters", func() bool {
+		s, err := q.Stats(ctx)
+		return err == nil && s.Dead == 3
+	})
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler calls = %d, want 1 (no retries, undecodable payload not handled)", n)
+	}
+}
+
+func TestIntegration_ClaimFromDeadConsumer(t *testing.T) {
+	q := newIntegrationQueue(t, WithClaimIdle(100*time.Millisecond))
+	ctx := context.Background()
+	if err := q.ensureGroup(ctx); err != nil {
+		t.Fatalf("ensureGroup: %v", err)
+	}
+	q.Enqueue(ctx, "email", email{To: "x"}, EnqueueOptions{})
+	// A worker reads the job and dies before acknowledging it.
+	streams, err := q.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group: q.opts.Group, Consumer: "dead-worker", Streams: []string{q.stream, ">"}, Count: 1,
+	}).Result()
+	if err != nil {
+		t.Fatalf("XReadGroup: %v", err)
+	}
+	// Touch the job once more: some Redis emulations only record a consumer's idle time on XCLAIM.
+	if err := q.client.XClaim(ctx, &goredis.XClaimArgs{
+		Stream: q.stream, Group: q.opts.Group, Consumer: "dead-worker", Messages: []string{streams[0].Messages[0].ID},
+	}).Err(); err != nil {
+		t.Fatalf("XClaim: %v", err)
+	}
+
+	done := make(chan *Job, 1)
+	Handle(q, "email", func(ctx context.Context, _ email) error {
+		job, _ := JobFromContext(ctx)
+		done <- job
+		return nil
+	})
+	runQueue(t, q)
+	select {
+	case job := <-done:
+		if job.Attempt != 2 || job.Error != errAbandoned.Error() {
+			t.Errorf("claimed job = %+v, want attempt 2 after abandonment", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned job was not claimed")
+	}
+	waitFor(t, "dead consumer to be pruned", func() bool {
+		consumers, err := q.client.XInfoConsumers(ctx, q.stream, q.opts.Group).Result()
+		if err != nil {
+			t.Fatalf("XInfoConsumers: %v", err)
+		}
+		for _, c := range consumers {
+			if c.Name == "dead-worker" {
+				return false
+			}
+		}
+		return len(consumers) == 1
+	})
+}
+
+func TestIntegration_GracefulShutdown(t *testing.T) {
+	q := newIntegrationQueue(t)
+	started := make(chan struct{})
+	var finished atomic.Bool
+	Handle(q, "slow", func(ctx context.Context, _ email) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+	ctx := context.Background()
+	q.Enqueue(ctx, "slow", email{}, EnqueueOptions{})
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- q.Run(runCtx) }()
+	<-started
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Run returned before the in-flight job finished")
+	}
+	if s, _ := q.Stats(ctx); s != (Stats{}) {
+		t.Errorf("Stats after shutdown = %+v, want empty", s)
+	}
+}
+
+func TestIntegration_StopTimeoutCancelsJobs(t *testing.T) {
+	q := newIntegrationQueue(t)
+	started := make(chan struct{})
+	Handle(q, "stuck", func(ctx context.Context, _ email) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx := context.Background()
+	q.Enqueue(ctx, "stuck", email{}, EnqueueOptions{})
+	if err := q.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	<-started
+	stopCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	q.Stop(stopCtx)
+	// The cancelled job is scheduled for a retry rather than lost.
+	waitFor(t, "retry", func() bool {
+		s, err := q.Stats(ctx)
+		return err == nil && s.Delayed+s.Ready == 1 && s.Pending == 0
+	})
+}
+
+func TestIntegration_Metrics(t *testing.T) {
+	q := newIntegrationQueue(t)
+	reg := prometheus.NewRegistry()
+	if err := q.RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+	ctx := context.Background()
+	q.Enqueue(ctx, "email", email{}, EnqueueOptions{})
+	q.Enqueue(ctx, "email", email{}, EnqueueOptions{Delay: time.Hour})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	depth := map[string]float64{}
+	for _, f := range families {
+		if f.GetName() != "queue_depth" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "state" {
+					depth[l.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	if depth["ready"] != 1 || depth["delayed"] != 1 || depth["pending"] != 0 || depth["dead"] != 0 {
+		t.Errorf("queue_depth = %v", depth)
+	}
+}
@@ -0,0 +1,465 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/turahe/pkg/logger"
+)
+
+// schedulerBatch is the most delayed jobs moved to the stream per script call.
+const schedulerBatch = 100
+
+// errAbandoned is recorded on jobs claimed from a worker that stopped making progress.
+var errAbandoned = errors.New("queue: worker stopped responding")
+
+// moveDueScript moves due jobs (score <= ARGV[1]) from the delayed set KEYS[1] to the stream KEYS[2]
+// atomically, ARGV[2] at a time.
+var moveDueScript = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, job in ipairs(due) do
+	redis.call("XADD", KEYS[2], "*", "job", job)
+	redis.call("ZREM", KEYS[1], job)
+end
+return #due`)
+
+// pruneConsumersScript deletes the consumers of group ARGV[1] on stream KEYS[1], other than ARGV[2], that
+// have no pending jobs and have been idle for at least ARGV[3] milliseconds. Checking and deleting in one
+// script keeps a consumer from reading a job in between, which DELCONSUMER would then drop.
+var pruneConsumersScript = redis.NewScript(`
+local pruned = 0
+for _, c in ipairs(redis.call("XINFO", "CONSUMERS", KEYS[1], ARGV[1])) do
+	local info = {}
+	for i = 1, #c, 2 do
+		info[c[i]] = c[i + 1]
+	end
+	if info.name ~= ARGV[2] and info.pending == 0 and info.idle >= tonumber(ARGV[3]) then
+		redis.call("XGROUP", "DELCONSUMER", KEYS[1], ARGV[1], info.name)
+		pruned = pruned + 1
+	end
+end
+return pruned`)
+
+// run is the state of a Run call, used by Stop.
+type run struct {
+	cancel context.CancelFunc
+	done   chan error
+	mu     sync.Mutex
+	grace  context.Context // set by Stop: how long in-flight jobs may finish
+}
+
+func (r *run) setGrace(ctx context.Context) {
+	r.mu.Lock()
+	r.grace = ctx
+	r.mu.Unlock()
+}
+
+// graceContext returns the context set by Stop, or one that ends after timeout.
+func (r *run) graceContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.grace != nil {
+		return r.grace, func() {}
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// Run handles jobs until ctx is cancelled, then stops fetching and waits up to Options.ShutdownTimeout for
+// in-flight jobs before cancelling their contexts; jobs that then fail are retried. Jobs still running when
+// the process exits stay pending and are claimed by another worker after ClaimIdle. It returns nil after a
+// graceful stop, or an error if the consumer group cannot be created.
+func (q *Queue) Run(ctx context.Context) error {
+	return q.run(ctx, &run{})
+}
+
+// Start runs the queue in the background; it returns once the consumer group exists. Use Start and Stop
+// as a server.Component.
+func (q *Queue) Start(ctx context.Context) error {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+	if q.running != nil {
+		return errors.New("queue: already started")
+	}
+	if err := q.ensureGroup(ctx); err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r := &run{cancel: cancel, done: make(chan error, 1)}
+	q.running = r
+	go func() { r.done <- q.run(runCtx, r) }()
+	return nil
+}
+
+// Stop stops fetching jobs and waits for in-flight jobs until ctx is done, then cancels their contexts and
+// returns ctx.Err() if they have not finished. No-op if the queue was not started.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.runMu.Lock()
+	r := q.running
+	q.running = nil
+	q.runMu.Unlock()
+	if r == nil {
+		return nil
+	}
+	r.setGrace(ctx)
+	r.cancel()
+	select {
+	case err := <-r.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run fetches and dispatches jobs until ctx is done, then drains in-flight jobs within r's grace context.
+func (q *Queue) run(ctx context.Context, r *run) error {
+	if err := q.ensureGroup(ctx); err != nil {
+		return err
+	}
+	jobsCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+	slots := make(chan struct{}, q.opts.Concurrency)
+	var jobs, background sync.WaitGroup
+	dispatch := func(m redis.XMessage, claimed bool) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			defer func() { <-slots }()
+			q.process(jobsCtx, m, claimed)
+		}()
+	}
+
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		q.schedule(ctx)
+	}()
+	go func() {
+		defer background.Done()
+		q.claim(ctx, slots, dispatch)
+	}()
+
+	for {
+		n := acquire(ctx, slots)
+		if n == 0 {
+			break
+		}
+		streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			Streams:  []string{q.stream, ">"},
+			Count:    int64(n),
+			Block:    q.opts.BlockTimeout,
+		}).Result()
+		got := 0
+		for _, s := range streams {
+			for _, m := range s.Messages {
+				got++
+				dispatch(m, false)
+			}
+		}
+		release(slots, n-got)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				break
+			}
+			if isNoGroup(err) {
+				err = q.ensureGroup(ctx)
+			}
+			if err != nil {
+				logger.WarnfContext(ctx, "queue %s: read failed: %v", q.opts.Name, err)
+				sleep(ctx, time.Second)
+			}
+		}
+	}
+	background.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(done)
+	}()
+	grace, cancel := r.graceContext(q.opts.ShutdownTimeout)
+	defer cancel()
+	select {
+	case <-done:
+	case <-grace.Done():
+		logger.Warnf("queue %s: shutdown timeout, cancelling in-flight jobs", q.opts.Name)
+		cancelJobs()
+		<-done
+	}
+	return nil
+}
+
+// ensureGroup creates the stream and consumer group if they do not exist.
+func (q *Queue) ensureGroup(ctx context.Context) error {
+	err := q.client.XGroupCreateMkStream(ctx, q.stream, q.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("queue %s: create consumer group: %w", q.opts.Name, err)
+	}
+	return nil
+}
+
+// process handles one delivered job and acknowledges, retries, or dead-letters it. jobsCtx is cancelled
+// only when a shutdown runs out of time.
+func (q *Queue) process(jobsCtx context.Context, m redis.XMessage, claimed bool) {
+	ctx := context.WithoutCancel(jobsCtx) // Redis bookkeeping still runs after the handler was cancelled
+	job, err := decodeJob(m)
+	if err != nil {
+		logger.ErrorfContext(ctx, "queue %s: dead-lettering undecodable entry: %v", q.opts.Name, err)
+		if _, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(m.Values) > 0 { // entries deleted while pending are claimed without values
+				pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.dead, Values: m.Values})
+			}
+			q.ack(ctx, pipe, m.ID)
+			return nil
+		}); err != nil {
+			logger.ErrorfContext(ctx, "queue %s: dead-letter entry %s: %v", q.opts.Name, m.ID, err)
+		}
+		return
+	}
+	if claimed {
+		q.fail(ctx, job, errAbandoned)
+		return
+	}
+
+	q.mu.RLock()
+	h := q.handlers[job.Type]
+	q.mu.RUnlock()
+	if h == nil {
+		q.fail(ctx, job, Permanent(fmt.Errorf("%w %q", ErrNoHandler, job.Type)))
+		return
+	}
+
+	jobWaitSeconds.WithLabelValues(q.opts.Name, job.Type).Observe(max(q.now().Sub(job.RunAt), 0).Seconds())
+	jobsInFlight.WithLabelValues(q.opts.Name).Inc()
+	defer jobsInFlight.WithLabelValues(q.opts.Name).Dec()
+	start := time.Now()
+	err = q.call(jobsCtx, h, job)
+	status := "succeeded"
+	if err != nil {
+		status = q.fail(ctx, job, err)
+	} else if _, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		q.ack(ctx, pipe, job.StreamID)
+		return nil
+	}); err != nil {
+		// The job stays pending and runs again once claimed: handlers must be idempotent.
+		logger.ErrorfContext(ctx, "queue %s: ack job %s: %v", q.opts.Name, job.ID, err)
+	}
+	jobDuration.WithLabelValues(q.opts.Name, job.Type, status).Observe(time.Since(start).Seconds())
+	if status == "succeeded" {
+		jobsProcessedTotal.WithLabelValues(q.opts.Name, job.Type, status).Inc()
+	}
+}
+
+// call runs h with the job in its context, converting a panic to an error, and keeps the job from being
+// claimed while it runs.
+func (q *Queue) call(ctx context.Context, h handlerFunc, job *Job) (err error) {
+	hctx, cancel := context.WithCancel(context.WithValue(ctx, jobKey{}, job))
+	defer cancel()
+	go q.keepAlive(hctx, job.StreamID)
+	defer func() {
+		if v := recover(); v != nil {
+			logger.ReportPanic(hctx, v)
+			err = fmt.Errorf("queue: handler panic: %v", v)
+		}
+	}()
+	return h(hctx, job.Payload)
+}
+
+// keepAlive resets the idle time of a running job every ClaimIdle/3, so other workers do not claim it.
+func (q *Queue) keepAlive(ctx context.Context, id string) {
+	t := time.NewTicker(q.opts.ClaimIdle / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		err := q.client.XClaimJustID(ctx, &redis.XClaimArgs{
+			Stream:   q.stream,
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			Messages: []string{id},
+		}).Err()
+		if err != nil && ctx.Err() == nil {
+			logger.WarnfContext(ctx, "queue %s: keep-alive of %s failed: %v", q.opts.Name, id, err)
+		}
+	}
+}
+
+// fail schedules a retry with backoff, or dead-letters the job when it is permanent or out of attempts, and
+// returns the resulting status.
+func (q *Queue) fail(ctx context.Context, job *Job, cause error) string {
+	streamID := job.StreamID
+	job.Error = cause.Error()
+	var perm *permanentError
+	status := "retried"
+	if errors.As(cause, &perm) || job.Attempt >= job.MaxAttempts {
+		status = "dead"
+	}
+	retry := *job
+	if status == "retried" {
+		retry.RunAt = q.now().Add(q.backoff(job.Attempt))
+		retry.Attempt++
+	}
+	b, err := json.Marshal(&retry)
+	if err == nil {
+		_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if status == "dead" {
+				pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.dead, Values: map[string]interface{}{jobField: b}})
+			} else {
+				pipe.ZAdd(ctx, q.delayed, redis.Z{Score: float64(retry.RunAt.UnixMilli()), Member: b})
+			}
+			q.ack(ctx, pipe, streamID)
+			return nil
+		})
+	}
+	if err != nil {
+		logger.ErrorfContext(ctx, "queue %s: %s job %s: %v", q.opts.Name, status, job.ID, err)
+	} else if status == "dead" {
+		logger.ErrorfContext(ctx, "queue %s: job %s (%s) dead-lettered after %d attempts: %v", q.opts.Name, job.ID, job.Type, job.Attempt, cause)
+	} else {
+		logger.WarnfContext(ctx, "queue %s: job %s (%s) attempt %d failed, retrying at %s: %v", q.opts.Name, job.ID, job.Type, job.Attempt, retry.RunAt.Format(time.RFC3339), cause)
+	}
+	jobsProcessedTotal.WithLabelValues(q.opts.Name, job.Type, status).Inc()
+	return status
+}
+
+// ack acknowledges and deletes a stream entry, so the stream only holds unfinished jobs.
+func (q *Queue) ack(ctx context.Context, pipe redis.Pipeliner, id string) {
+	pipe.XAck(ctx, q.stream, q.opts.Group, id)
+	pipe.XDel(ctx, q.stream, id)
+}
+
+// backoff returns the delay after failed attempt n: full-jitter exponential backoff capped at RetryMaxDelay.
+func (q *Queue) backoff(n int) time.Duration {
+	return rand.N(q.backoffCeiling(n)) + 1
+}
+
+// backoffCeiling is RetryBaseDelay doubled n-1 times, capped at RetryMaxDelay before the shift can overflow.
+func (q *Queue) backoffCeiling(n int) time.Duration {
+	base, limit := q.opts.RetryBaseDelay, q.opts.RetryMaxDelay
+	shift := max(n-1, 0)
+	if shift >= 63 || base > limit>>shift {
+		return limit
+	}
+	return base << shift
+}
+
+// schedule moves due delayed jobs to the stream every SchedulerInterval until ctx is done.
+func (q *Queue) schedule(ctx context.Context) {
+	t := time.NewTicker(q.opts.SchedulerInterval)
+	defer t.Stop()
+	for {
+		for {
+			moved, err := moveDueScript.Run(ctx, q.client, []string{q.delayed, q.stream}, q.now().UnixMilli(), schedulerBatch).Int()
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.WarnfContext(ctx, "queue %s: scheduling delayed jobs failed: %v", q.opts.Name, err)
+				}
+				break
+			}
+			if moved < schedulerBatch {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// claim takes over jobs left pending longer than ClaimIdle by workers that died, every ClaimInterval, and
+// prunes consumers left with nothing pending. It waits for a slot like the reader does; blocked senders are
+// served in order, so it is not starved by the reader re-acquiring slots between reads.
+func (q *Queue) claim(ctx context.Context, slots chan struct{}, dispatch func(redis.XMessage, bool)) {
+	t := time.NewTicker(q.opts.ClaimInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		q.pruneConsumers(ctx)
+		n := acquire(ctx, slots)
+		if n == 0 {
+			return
+		}
+		msgs, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   q.stream,
+			Group:    q.opts.Group,
+			Consumer: q.opts.Consumer,
+			MinIdle:  q.opts.ClaimIdle,
+			Start:    "0-0",
+			Count:    int64(n),
+		}).Result()
+		if err != nil && ctx.Err() == nil && !errors.Is(err, redis.Nil) {
+			logger.WarnfContext(ctx, "queue %s: claiming pending jobs failed: %v", q.opts.Name, err)
+		}
+		for _, m := range msgs {
+			dispatch(m, true)
+		}
+		release(slots, n-len(msgs))
+	}
+}
+
+// pruneConsumers removes consumers of the group that have no pending jobs and have not read for ClaimIdle
+// (and at least two BlockTimeouts, as a live reader blocks that long), so workers that died do not pile up
+// in the group once their jobs are claimed.
+func (q *Queue) pruneConsumers(ctx context.Context) {
+	idle := max(q.opts.ClaimIdle, 2*q.opts.BlockTimeout)
+	err := pruneConsumersScript.Run(ctx, q.client, []string{q.stream}, q.opts.Group, q.opts.Consumer, idle.Milliseconds()).Err()
+	if err != nil && ctx.Err() == nil {
+		logger.WarnfContext(ctx, "queue %s: pruning idle consumers failed: %v", q.opts.Name, err)
+	}
+}
+
+// acquire blocks until a slot is free, then takes every free slot; it returns 0 when ctx is done.
+func acquire(ctx context.Context, slots chan struct{}) int {
+	select {
+	case <-ctx.Done():
+		return 0
+	case slots <- struct{}{}:
+	}
+	return 1 + tryAcquire(slots, cap(slots)-1)
+}
+
+// tryAcquire takes up to n free slots without blocking.
+func tryAcquire(slots chan struct{}, n int) int {
+	got := 0
+	for ; got < n; got++ {
+		select {
+		case slots <- struct{}{}:
+		default:
+			return got
+		}
+	}
+	return got
+}
+
+func release(slots chan struct{}, n int) {
+	for range n {
+		<-slots
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
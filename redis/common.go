@@ -97,14 +97,17 @@ func SRem(ctx context.Context, key string, members ...interface{}) error {
 
 // Lock
 
+// Deprecated: use NewLocker; ExtendLock and ReleaseLock do not check ownership.
 func AcquireLock(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
 	return getClient().SetNX(ctx, key, value, expiration).Result()
 }
 
+// Deprecated: use Lock.Extend, which only extends a lock still held by its owner.
 func ExtendLock(ctx context.Context, key string, expiration time.Duration) error {
 	return getClient().Expire(ctx, key, expiration).Err()
 }
 
+// Deprecated: use Lock.Release, which only deletes a lock still held by its owner.
 func ReleaseLock(ctx context.Context, key string) error {
 	return getClient().Del(ctx, key).Err()
 }
@@ -9,6 +9,10 @@ Responsibilities:
   - Available: TCP reachability check for host:port without using the Redis client (no connection logs).
   - IsAlive: ping check. GetRedis/GetRedisCluster/GetUniversalClient: access the client.
   - Close: close the active client and release connections; safe to call when not enabled.
+  - Metrics: InstrumentMetrics/RegisterMetrics export pool stats and per-command latency to Prometheus.
+  - Locks: Locker (NewLocker) acquires leases with random owner tokens (TryAcquire, or Acquire with jittered
+    backoff); Lock.Extend and Lock.Release are Lua compare-and-act scripts, Lock.KeepAlive renews in the
+    background and cancels its context when the lock is lost, and Lock.Fence returns a fencing token.
 
 Constraints:
   - Single client per process; no provider switching or multi-instance.
@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultLockTTL      = 30 * time.Second
+	defaultLockRetryMin = 50 * time.Millisecond
+	defaultLockRetryMax = time.Second
+)
+
+var (
+	// ErrNotAcquired is returned by TryAcquire when the lock is held by someone else.
+	ErrNotAcquired = errors.New("redis: lock not acquired")
+	// ErrLockNotHeld is returned by Extend and Release when the lock expired or is held by someone else.
+	ErrLockNotHeld = errors.New("redis: lock not held")
+)
+
+// Lua scripts run atomically, so ownership is checked and acted on in one step. KEYS[1] is the lock key,
+// KEYS[2] its fencing counter, ARGV[1] the owner token.
+var (
+	acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// LockOptions configures a Locker. Zero values take package defaults.
+type LockOptions struct {
+	// TTL is the lease of an acquired lock; default 30s. KeepAlive renews it every TTL/3.
+	TTL time.Duration
+	// RetryMin and RetryMax bound the jittered exponential backoff of Acquire; default 50ms and 1s.
+	RetryMin, RetryMax time.Duration
+}
+
+func (o *LockOptions) applyDefaults() {
+	if o.TTL <= 0 {
+		o.TTL = defaultLockTTL
+	}
+	if o.RetryMin <= 0 {
+		o.RetryMin = defaultLockRetryMin
+	}
+	if o.RetryMax < o.RetryMin {
+		o.RetryMax = max(defaultLockRetryMax, o.RetryMin)
+	}
+}
+
+// Locker acquires distributed locks on one Redis client (standalone or cluster).
+type Locker struct {
+	client redis.Cmdable
+	opts   LockOptions
+}
+
+// NewLocker returns a Locker on client, e.g. GetUniversalClient().
+func NewLocker(client redis.Cmdable, opts LockOptions) *Locker {
+	opts.applyDefaults()
+	return &Locker{client: client, opts: opts}
+}
+
+// Lock is a held lock. Its random token identifies the owner, so Extend and Release never touch a lock
+// that has since expired and been acquired by someone else.
+type Lock struct {
+	client redis.Cmdable
+	key    string
+	token  string
+	fence  int64
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	expiry time.Time
+}
+
+// TryAcquire acquires key once, returning ErrNotAcquired if it is held.
+func (l *Locker) TryAcquire(ctx context.Context, key string) (*Lock, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	fence, err := acquireScript.Run(ctx, l.client, []string{key, fenceKey(key)}, token, l.opts.TTL.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if fence == 0 {
+		return nil, ErrNotAcquired
+	}
+	return &Lock{client: l.client, key: key, token: token, fence: fence, ttl: l.opts.TTL, expiry: start.Add(l.opts.TTL)}, nil
+}
+
+// Acquire acquires key, retrying with jittered exponential backoff until ctx is done.
+func (l *Locker) Acquire(ctx context.Context, key string) (*Lock, error) {
+	backoff := l.opts.RetryMin
+	for {
+		lock, err := l.TryAcquire(ctx, key)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lock, err
+		}
+		// Full jitter: contenders that failed together do not retry together.
+		t := time.NewTimer(time.Duration(rand.Int64N(int64(backoff)) + 1))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		backoff = min(backoff*2, l.opts.RetryMax)
+	}
+}
+
+// Key returns the locked key.
+func (lk *Lock) Key() string { return lk.key }
+
+// Token returns the random owner token stored in the key.
+func (lk *Lock) Token() string { return lk.token }
+
+// Fence returns the fencing token: a counter incremented on every acquisition of the key, so a later holder
+// always has a larger one. Store it with writes guarded by the lock and reject writes carrying a smaller
+// token than the stored one (e.g. UPDATE ... WHERE fence < ?), which stops a holder whose lease expired
+// during a pause from overwriting its successor's work.
+func (lk *Lock) Fence() int64 { return lk.fence }
+
+// Expiry returns when the lease ends unless extended, as measured before the last acquire or extend call.
+func (lk *Lock) Expiry() time.Time {
+	lk.mu.Lock()
+	defer lk.mu.Unlock()
+	return lk.expiry
+}
+
+// Extend renews the lease to ttl (0: the Locker's TTL) if the lock is still held, else returns
+// ErrLockNotHeld.
+func (lk *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = lk.ttl
+	}
+	start := time.Now()
+	ok, err := extendScript.Run(ctx, lk.client, []string{lk.key}, lk.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLockNotHeld
+	}
+	lk.mu.Lock()
+	lk.expiry = start.Add(ttl)
+	lk.mu.Unlock()
+	return nil
+}
+
+// Release deletes the lock if it is still held, else returns ErrLockNotHeld.
+func (lk *Lock) Release(ctx context.Context) error {
+	ok, err := releaseScript.Run(ctx, lk.client, []string{lk.key}, lk.token).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// KeepAlive renews the lease every TTL/3 until the returned context is cancelled. The context is also
+// cancelled when the lock is lost (ErrLockNotHeld) or its lease runs out because renewals keep failing;
+// context.Cause then reports why. Do the guarded work with the returned context and call cancel when done,
+// before Release.
+func (lk *Lock) KeepAlive(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		interval := lk.ttl / 3
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			err := lk.Extend(ctx, 0)
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrLockNotHeld):
+				cancel(err)
+				return
+			case ctx.Err() != nil:
+				return
+			case time.Until(lk.Expiry()) < interval:
+				// The next attempt would come too late: give the work up before the lease ends.
+				cancel(errors.Join(ErrLockNotHeld, err))
+				return
+			}
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// fenceKey returns the fencing counter key of key, in the same cluster hash slot so both can be used by
+// one script: key's own hash tag when it has one, else key wrapped in a new hash tag.
+func fenceKey(key string) string {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		if j := strings.IndexByte(key[i+1:], '}'); j > 0 {
+			return key + ":fence"
+		}
+	}
+	return "{" + key + "}:fence"
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := cryptorand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
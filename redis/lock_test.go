@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFenceKey(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"jobs:payout", "{jobs:payout}:fence"},
+		{"lock:{user:1}:profile", "lock:{user:1}:profile:fence"},
+		{"odd{", "{odd{}:fence"},
+	}
+	for _, tt := range tests {
+		if got := fenceKey(tt.key); got != tt.want {
+			t.Errorf("fenceKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLockOptions_Defaults(t *testing.T) {
+	var o LockOptions
+	o.applyDefaults()
+	if o.TTL != defaultLockTTL || o.RetryMin != defaultLockRetryMin || o.RetryMax != defaultLockRetryMax {
+		t.Errorf("defaults = %+v", o)
+	}
+	o = LockOptions{RetryMin: 2 * time.Second}
+	o.applyDefaults()
+	if o.RetryMax != 2*time.Second {
+		t.Errorf("RetryMax = %v, want RetryMin when the default is smaller", o.RetryMax)
+	}
+}
+
+func newIntegrationLocker(t *testing.T, opts LockOptions) (*Locker, string) {
+	t.Helper()
+	setupIntegrationBackend(t)
+	t.Cleanup(func() { rdb = nil; rdbCluster = nil })
+	key := keyPrefix + "lock:" + t.Name()
+	t.Cleanup(func() { getClient().Del(context.Background(), key, fenceKey(key)) })
+	return NewLocker(GetUniversalClient(), opts), key
+}
+
+func TestIntegration_LockOwnership(t *testing.T) {
+	l, key := newIntegrationLocker(t, LockOptions{TTL: 200 * time.Millisecond})
+	ctx := context.Background()
+
+	first, err := l.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	if _, err := l.TryAcquire(ctx, key); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("second TryAcquire: err = %v, want ErrNotAcquired", err)
+	}
+
+	// The first lease expires and someone else acquires the key.
+	time.Sleep(300 * time.Millisecond)
+	second, err := l.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("TryAcquire after expiry: %v", err)
+	}
+	if second.Fence() <= first.Fence() {
+		t.Errorf("fence %d not greater than previous %d", second.Fence(), first.Fence())
+	}
+	if err := first.Extend(ctx, 0); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("stale Extend: err = %v, want ErrLockNotHeld", err)
+	}
+	if err := first.Release(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("stale Release: err = %v, want ErrLockNotHeld", err)
+	}
+	if v, _ := Get(ctx, key); v != second.Token() {
+		t.Errorf("stale holder changed the lock: value %q, want %q", v, second.Token())
+	}
+
+	if err := second.Extend(ctx, time.Minute); err != nil {
+		t.Errorf("Extend: %v", err)
+	}
+	if ttl := getClient().PTTL(ctx, key).Val(); ttl < 50*time.Second {
+		t.Errorf("PTTL after Extend = %v, want about 1m", ttl)
+	}
+	if err := second.Release(ctx); err != nil {
+		t.Errorf("Release: %v", err)
+	}
+	if _, err := l.TryAcquire(ctx, key); err != nil {
+		t.Errorf("TryAcquire after Release: %v", err)
+	}
+}
+
+func TestIntegration_LockAcquireBlocks(t *testing.T) {
+	l, key := newIntegrationLocker(t, LockOptions{TTL: 5 * time.Second, RetryMin: 5 * time.Millisecond, RetryMax: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	held, err := l.Acquire(ctx, key)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(short, key); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire while held: err = %v, want DeadlineExceeded", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		held.Release(ctx)
+	}()
+	next, err := l.Acquire(ctx, key)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	next.Release(ctx)
+}
+
+func TestIntegration_LockMutualExclusion(t *testing.T) {
+	l, key := newIntegrationLocker(t, LockOptions{TTL: 5 * time.Second, RetryMin: time.Millisecond, RetryMax: 5 * time.Millisecond})
+	ctx := context.Background()
+
+	var inside, violations atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := l.Acquire(ctx, key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if inside.Add(1) > 1 {
+				violations.Add(1)
+			}
+			time.Sleep(2 * time.Millisecond)
+			inside.Add(-1)
+			if err := lock.Release(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if violations.Load() > 0 {
+		t.Errorf("%d holders overlapped", violations.Load())
+	}
+}
+
+func TestIntegration_LockKeepAlive(t *testing.T) {
+	l, key := newIntegrationLocker(t, LockOptions{TTL: 150 * time.Millisecond})
+	ctx := context.Background()
+
+	lock, err := l.TryAcquire(ctx, key)
+	if err != nil {
+		t.Fatalf("TryAcquire: %v", err)
+	}
+	work, stop := lock.KeepAlive(ctx)
+	time.Sleep(400 * time.Millisecond) // well past the TTL
+	if work.Err() != nil {
+		t.Fatalf("KeepAlive context done while the lock is held: %v", context.Cause(work))
+	}
+	if _, err := l.TryAcquire(ctx, key); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("lease was not renewed: TryAcquire err = %v", err)
+	}
+
+	// Losing the lock cancels the work context.
+	getClient().Del(ctx, key)
+	select {
+	case <-work.Done():
+		if !errors.Is(context.Cause(work), ErrLockNotHeld) {
+			t.Errorf("cause = %v, want ErrLockNotHeld", context.Cause(work))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("KeepAlive context not cancelled after the lock was lost")
+	}
+	stop()
+}
@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// Command metrics are shared by every instrumented client and distinguished by the client label.
+var (
+	commandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "redis_command_duration_seconds",
+			Help:    "Duration of Redis commands in seconds (pipelines as command \"pipeline\").",
+			Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		},
+		[]string{"client", "command", "status"},
+	)
+)
+
+// poolStatser is implemented by *redis.Client, *redis.ClusterClient, and *redis.Ring.
+type poolStatser interface {
+	PoolStats() *redis.PoolStats
+}
+
+// hookable is implemented by *redis.Client, *redis.ClusterClient, and *redis.Ring.
+type hookable interface {
+	AddHook(redis.Hook)
+}
+
+// poolStatsCollector exports go-redis connection pool stats for one client.
+type poolStatsCollector struct {
+	client poolStatser
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// NewPoolStatsCollector returns a collector for client's pool stats with the constant label client=name:
+// redis_pool_hits_total, redis_pool_misses_total, redis_pool_timeouts_total, redis_pool_stale_connections_total,
+// redis_pool_connections, redis_pool_idle_connections.
+func NewPoolStatsCollector(name string, client poolStatser) prometheus.Collector {
+	labels := prometheus.Labels{"client": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("redis_pool_"+metric, help, nil, labels)
+	}
+	return &poolStatsCollector{
+		client:     client,
+		hits:       desc("hits_total", "Number of times a free connection was found in the pool."),
+		misses:     desc("misses_total", "Number of times a free connection was NOT found in the pool."),
+		timeouts:   desc("timeouts_total", "Number of times a wait for a connection timed out."),
+		totalConns: desc("connections", "Number of connections in the pool."),
+		idleConns:  desc("idle_connections", "Number of idle connections in the pool."),
+		staleConns: desc("stale_connections_total", "Number of stale connections removed from the pool."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+// Collect implements prometheus.Collector.
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(s.StaleConns))
+}
+
+// metricsHook records redis_command_duration_seconds for every command and pipeline.
+type metricsHook struct {
+	name string
+}
+
+// NewMetricsHook returns a redis.Hook that observes command latency with labels client=name, command, and
+// status ("ok" or "error"; redis.Nil counts as ok).
+func NewMetricsHook(name string) redis.Hook {
+	return &metricsHook{name: name}
+}
+
+func (h *metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (h *metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", start, err)
+		return err
+	}
+}
+
+func (h *metricsHook) observe(command string, start time.Time, err error) {
+	status := "ok"
+	if err != nil && !errors.Is(err, redis.Nil) {
+		status = "error"
+	}
+	commandDuration.WithLabelValues(h.name, command, status).Observe(time.Since(start).Seconds())
+}
+
+// InstrumentMetrics registers pool stats and command latency for client under client=name on reg
+// (prometheus.DefaultRegisterer when nil). Call once per client: hooks cannot be removed.
+func InstrumentMetrics(name string, client interface {
+	poolStatser
+	hookable
+}, reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	if err := reg.Register(commandDuration); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+	}
+	if err := reg.Register(NewPoolStatsCollector(name, client)); err != nil {
+		return err
+	}
+	client.AddHook(NewMetricsHook(name))
+	return nil
+}
+
+// RegisterMetrics calls InstrumentMetrics for the client created by Setup (client name "default", or
+// "cluster" in cluster mode). No-op when Redis is not set up.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	if isCluster && rdbCluster != nil {
+		return InstrumentMetrics("cluster", rdbCluster, reg)
+	}
+	if rdb != nil {
+		return InstrumentMetrics("default", rdb, reg)
+	}
+	return nil
+}
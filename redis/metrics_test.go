@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestInstrumentMetrics(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:63999",
+		DialTimeout: 10 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+
+	reg := prometheus.NewRegistry()
+	if err := InstrumentMetrics("metrics_test", client, reg); err != nil {
+		t.Fatalf("InstrumentMetrics: %v", err)
+	}
+	if err := InstrumentMetrics("metrics_test", client, reg); err == nil {
+		t.Error("registering the same client name twice must fail")
+	}
+
+	if err := client.Get(context.Background(), "key").Err(); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	if n := testutil.CollectAndCount(commandDuration, "redis_command_duration_seconds"); n == 0 {
+		t.Error("redis_command_duration_seconds has no series")
+	}
+	if n, err := testutil.GatherAndCount(reg, "redis_pool_connections", "redis_pool_misses_total"); err != nil || n != 2 {
+		t.Errorf("pool stats series = %d, err = %v; want 2", n, err)
+	}
+}
+
+func TestMetricsHook_NilIsOK(t *testing.T) {
+	hook := NewMetricsHook("nil_test")
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error { return redis.Nil })
+	_ = process(context.Background(), redis.NewStringCmd(context.Background(), "get", "k"))
+
+	var m dto.Metric
+	if err := commandDuration.WithLabelValues("nil_test", "get", "ok").(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 1 {
+		t.Errorf("status=ok samples = %d, want 1", got)
+	}
+}
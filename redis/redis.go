@@ -151,6 +151,18 @@ func IsAlive() bool {
 	return rdb.Ping(context.Background()).Err() == nil
 }
 
+// HealthCheck pings the active client (standalone or cluster) with ctx. Returns an error if Setup was not called
+// or the ping fails.
+func HealthCheck(ctx context.Context) error {
+	if isCluster && rdbCluster != nil {
+		return rdbCluster.Ping(ctx).Err()
+	}
+	if !isCluster && rdb != nil {
+		return rdb.Ping(ctx).Err()
+	}
+	return fmt.Errorf("redis client is not initialized")
+}
+
 // GetRedis returns the standard Redis client. Panics if cluster mode is enabled or Setup was not called. Panics if cluster mode is enabled or Setup was not called.
 func GetRedis() *redis.Client {
 	if isCluster {
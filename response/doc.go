@@ -8,6 +8,7 @@ Responsibilities:
   - Build and parse composite response codes (codes.go).
   - Write success, error, validation, pagination, and custom responses to Gin context (response.go, validation.go, pagination.go).
   - Format go-playground/validator errors into Laravel-style field maps (validation.go).
+  - Record the composite code written under CodeKey in the Gin context for access logging.
 
 Constraints:
   - No business logic; only response shape and code building.
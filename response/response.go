@@ -6,6 +6,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// CodeKey is the gin context key under which every helper in this package stores the composite response
+// code it wrote (int), so middleware such as the access log can report it without parsing the body.
+const CodeKey = "response.code"
+
+// writeJSON records code under CodeKey and writes body as JSON.
+func writeJSON(ctx *gin.Context, httpStatus, code int, body interface{}) {
+	ctx.Set(CodeKey, code)
+	ctx.JSON(httpStatus, body)
+}
+
 // CommonResponse is the standard JSON body: code (7-digit composite), message, data.
 type CommonResponse struct {
 	Code    int         `json:"code"` // Custom response code: HTTP_STATUS + SERVICE_CODE + CASE_CODE (e.g., 2000401)
@@ -16,7 +26,7 @@ type CommonResponse struct {
 // Result writes a JSON response with the given HTTP status and composite code (BuildResponseCode(httpStatus, serviceCode, caseCode)).
 func Result(ctx *gin.Context, httpStatus int, serviceCode, caseCode string, data interface{}, message string) {
 	responseCode := BuildResponseCode(httpStatus, serviceCode, caseCode)
-	ctx.JSON(httpStatus, CommonResponse{
+	writeJSON(ctx, httpStatus, responseCode, CommonResponse{
 		Code:    responseCode,
 		Message: message,
 		Data:    data,
@@ -25,7 +35,7 @@ func Result(ctx *gin.Context, httpStatus int, serviceCode, caseCode string, data
 
 // ResultWithCode writes a JSON response with explicit responseCode (no BuildResponseCode).
 func ResultWithCode(ctx *gin.Context, httpStatus int, responseCode int, data interface{}, message string) {
-	ctx.JSON(httpStatus, CommonResponse{
+	writeJSON(ctx, httpStatus, responseCode, CommonResponse{
 		Code:    responseCode,
 		Message: message,
 		Data:    data,
@@ -59,7 +69,7 @@ type CursorPaginatedResponse struct {
 // CursorPaginated writes a cursor-based paginated JSON response using the given pagination payload.
 func CursorPaginated(ctx *gin.Context, httpStatus int, serviceCode, caseCode string, pagination CursorPaginationResponse, message string) {
 	responseCode := BuildResponseCode(httpStatus, serviceCode, caseCode)
-	ctx.JSON(httpStatus, CursorPaginatedResponse{
+	writeJSON(ctx, httpStatus, responseCode, CursorPaginatedResponse{
 		Code:       responseCode,
 		Message:    message,
 		Data:       pagination.Data,
@@ -82,7 +92,7 @@ type SimplePaginatedResponse struct {
 // SimplePaginated returns a simple paginated response with fields at the top level
 func SimplePaginated(ctx *gin.Context, httpStatus int, serviceCode, caseCode string, pagination SimplePaginationResponse, message string) {
 	responseCode := BuildResponseCode(httpStatus, serviceCode, caseCode)
-	ctx.JSON(httpStatus, SimplePaginatedResponse{
+	writeJSON(ctx, httpStatus, responseCode, SimplePaginatedResponse{
 		Code:       responseCode,
 		Message:    message,
 		Data:       pagination.Data,
@@ -144,7 +154,7 @@ func ValidationError(ctx *gin.Context, serviceCode string, err error) {
 
 	responseCode := BuildResponseCode(http.StatusUnprocessableEntity, serviceCode, CaseCodeValidationError)
 
-	ctx.JSON(http.StatusUnprocessableEntity, ValidationErrorResponse{
+	writeJSON(ctx, http.StatusUnprocessableEntity, responseCode, ValidationErrorResponse{
 		Code:    responseCode,
 		Message: message,
 		Errors:  errors,
@@ -162,7 +172,7 @@ func ValidationErrorWithMessage(ctx *gin.Context, serviceCode string, message st
 
 	responseCode := BuildResponseCode(http.StatusUnprocessableEntity, serviceCode, CaseCodeValidationError)
 
-	ctx.JSON(http.StatusUnprocessableEntity, ValidationErrorResponse{
+	writeJSON(ctx, http.StatusUnprocessableEntity, responseCode, ValidationErrorResponse{
 		Code:    responseCode,
 		Message: message,
 		Errors:  errors,
@@ -289,3 +289,18 @@ func TestSimplePaginated(t *testing.T) {
 		t.Errorf("body = %+v", body)
 	}
 }
+
+func TestCodeKey(t *testing.T) {
+	ctx, _ := testContext()
+	Ok(ctx)
+	if got := ctx.GetInt(CodeKey); got != 2000001 {
+		t.Errorf("Ok: CodeKey = %d, want 2000001", got)
+	}
+
+	ctx, _ = testContext()
+	ValidationErrorSimple(ctx, ServiceCodeCommon, "name", "required")
+	want := BuildResponseCode(http.StatusUnprocessableEntity, ServiceCodeCommon, CaseCodeValidationError)
+	if got := ctx.GetInt(CodeKey); got != want {
+		t.Errorf("ValidationErrorSimple: CodeKey = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,358 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/turahe/pkg/config"
+)
+
+// ErrNotFound is returned (wrapped) when an object does not exist.
+var ErrNotFound = errors.New("s3: object not found")
+
+// Client is an S3-compatible (AWS S3, MinIO) client bound to one bucket. Every call takes the caller's
+// context, so transfers are cancelled or time-bounded with the request. Safe for concurrent use.
+type Client struct {
+	client *minio.Client
+	core   *minio.Core
+	bucket string
+	opts   *Options
+}
+
+// New creates a Client for cfg.BucketName on cfg.Endpoint. With cfg.AccessKeyID empty, credentials come from
+// the AWS_* environment variables, the shared credentials file, or the instance role, in that order. No
+// request is made; use HealthCheck to verify access.
+func New(cfg *config.S3Configuration, opts Options, override ...Option) (*Client, error) {
+	o := resolveOptions(opts, override)
+	creds := credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
+	if cfg.AccessKeyID == "" {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+	lookup := minio.BucketLookupAuto
+	if cfg.ForcePathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	mc, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+		Transport:    o.Transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+	return &Client{client: mc, core: &minio.Core{Client: mc}, bucket: cfg.BucketName, opts: o}, nil
+}
+
+// NewWithClient returns a Client for bucket using an existing minio client.
+func NewWithClient(mc *minio.Client, bucket string, opts Options, override ...Option) *Client {
+	return &Client{client: mc, core: &minio.Core{Client: mc}, bucket: bucket, opts: resolveOptions(opts, override)}
+}
+
+func resolveOptions(opts Options, override []Option) *Options {
+	o := &Options{}
+	*o = opts
+	for _, fn := range override {
+		fn(o)
+	}
+	o.applyDefaults()
+	return o
+}
+
+// Minio returns the underlying minio client.
+func (c *Client) Minio() *minio.Client {
+	return c.client
+}
+
+// BucketName returns the client's bucket name.
+func (c *Client) BucketName() string {
+	return c.bucket
+}
+
+// HealthCheck checks with ctx that the bucket exists and is accessible.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	if c.bucket == "" {
+		return fmt.Errorf("S3 bucket name is not configured")
+	}
+	ok, err := c.client.BucketExists(ctx, c.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to access bucket %s: %w", c.bucket, err)
+	}
+	if !ok {
+		return fmt.Errorf("bucket %s does not exist", c.bucket)
+	}
+	return nil
+}
+
+// Close is a no-op: a minio client holds only pooled connections. It mirrors gcs.Client.Close so callers can
+// release either client the same way.
+func (c *Client) Close() error {
+	return nil
+}
+
+// UploadOptions are the object attributes and upload settings for Upload and CreateMultipartUpload.
+type UploadOptions struct {
+	// ContentType of the object; default "application/octet-stream".
+	ContentType string
+	// ContentEncoding of the data as given, e.g. "gzip" for pre-compressed content.
+	ContentEncoding    string
+	CacheControl       string
+	ContentDisposition string
+	// Metadata is stored as user metadata (x-amz-meta-*).
+	Metadata map[string]string
+	// Size is the length of r when known. Objects smaller than the part size are sent in one request; larger
+	// or unknown-size (0) objects use a multipart upload.
+	Size int64
+	// PartSize is the multipart part size in bytes; 0 uses Options.PartSize. Unknown-size uploads buffer one
+	// part in memory.
+	PartSize uint64
+	// Progress, when set, is called with the number of bytes sent so far. Parts of a multipart upload with a
+	// known Size are sent in parallel, so Progress may be called concurrently and totals may arrive out of
+	// order; it must be safe for concurrent use.
+	Progress func(bytesSent int64)
+}
+
+func (o UploadOptions) putOptions(partSize uint64) minio.PutObjectOptions {
+	if o.PartSize > 0 {
+		partSize = max(o.PartSize, minPartSize)
+	}
+	po := minio.PutObjectOptions{
+		ContentType:        o.ContentType,
+		ContentEncoding:    o.ContentEncoding,
+		CacheControl:       o.CacheControl,
+		ContentDisposition: o.ContentDisposition,
+		UserMetadata:       o.Metadata,
+		PartSize:           partSize,
+	}
+	if o.Progress != nil {
+		po.Progress = &progressReader{fn: o.Progress}
+	}
+	return po
+}
+
+// Upload streams r to object key, in a single request or as a multipart upload (see UploadOptions.Size).
+// Cancelling ctx aborts the upload and leaves any existing object unchanged.
+func (c *Client) Upload(ctx context.Context, key string, r io.Reader, opts UploadOptions) (minio.UploadInfo, error) {
+	size := opts.Size
+	if size <= 0 {
+		size = -1
+	}
+	info, err := c.client.PutObject(ctx, c.bucket, key, r, size, opts.putOptions(c.opts.PartSize))
+	if err != nil {
+		return minio.UploadInfo{}, wrapError("upload", key, err)
+	}
+	return info, nil
+}
+
+// DownloadOptions select the byte range Download and NewReader read.
+type DownloadOptions struct {
+	// Offset is the first byte to read. A negative Offset reads the last -Offset bytes (Length is ignored).
+	Offset int64
+	// Length is the number of bytes to read from Offset; 0 reads to the end.
+	Length int64
+	// VersionID reads a specific version of the object in a versioned bucket.
+	VersionID string
+}
+
+func (o DownloadOptions) getOptions() (minio.GetObjectOptions, error) {
+	var g minio.GetObjectOptions
+	g.VersionID = o.VersionID
+	var err error
+	switch {
+	case o.Offset < 0:
+		err = g.SetRange(0, o.Offset)
+	case o.Length > 0:
+		err = g.SetRange(o.Offset, o.Offset+o.Length-1)
+	case o.Offset > 0:
+		err = g.SetRange(o.Offset, 0)
+	}
+	return g, err
+}
+
+// Download writes object key, or the byte range in opts, to w and returns the object's attributes.
+func (c *Client) Download(ctx context.Context, key string, w io.Writer, opts DownloadOptions) (minio.ObjectInfo, error) {
+	obj, info, err := c.open(ctx, key, opts)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	defer obj.Close()
+	if _, err := io.Copy(w, obj); err != nil {
+		return minio.ObjectInfo{}, wrapError("download", key, err)
+	}
+	return info, nil
+}
+
+// NewReader opens object key, or the byte range in opts, for streaming. Missing objects fail here rather
+// than on the first Read. The caller must close the reader.
+func (c *Client) NewReader(ctx context.Context, key string, opts DownloadOptions) (io.ReadCloser, error) {
+	obj, _, err := c.open(ctx, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (c *Client) open(ctx context.Context, key string, opts DownloadOptions) (io.ReadCloser, minio.ObjectInfo, error) {
+	g, err := opts.getOptions()
+	if err != nil {
+		return nil, minio.ObjectInfo{}, wrapError("read", key, err)
+	}
+	body, info, _, err := c.core.GetObject(ctx, c.bucket, key, g)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, wrapError("read", key, err)
+	}
+	return body, info, nil
+}
+
+// Attrs returns the attributes of object key.
+func (c *Client) Attrs(ctx context.Context, key string) (minio.ObjectInfo, error) {
+	info, err := c.client.StatObject(ctx, c.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return minio.ObjectInfo{}, wrapError("stat", key, err)
+	}
+	return info, nil
+}
+
+// Exists reports whether object key exists.
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.Attrs(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check object existence %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Delete deletes object key. Deleting a missing object succeeds, as in S3.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	if err := c.client.RemoveObject(ctx, c.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return wrapError("delete", key, err)
+	}
+	return nil
+}
+
+// List returns the objects whose keys start with prefix, in key order, including those under nested
+// prefixes.
+func (c *Client) List(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	var out []minio.ObjectInfo
+	for obj := range c.client.ListObjects(ctx, c.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects with prefix %s: %w", prefix, obj.Err)
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// PresignGet returns a URL that downloads object key without credentials until expires (at most 7 days).
+// params may override response headers, e.g. "response-content-disposition".
+func (c *Client) PresignGet(ctx context.Context, key string, expires time.Duration, params url.Values) (string, error) {
+	u, err := c.client.PresignedGetObject(ctx, c.bucket, key, expires, params)
+	if err != nil {
+		return "", wrapError("presign", key, err)
+	}
+	return u.String(), nil
+}
+
+// PresignPut returns a URL that uploads object key without credentials until expires (at most 7 days). When
+// contentType is set, the uploader must send it as the Content-Type header.
+func (c *Client) PresignPut(ctx context.Context, key string, expires time.Duration, contentType string) (string, error) {
+	var h http.Header
+	if contentType != "" {
+		h = http.Header{"Content-Type": {contentType}}
+	}
+	u, err := c.client.PresignHeader(ctx, http.MethodPut, c.bucket, key, expires, nil, h)
+	if err != nil {
+		return "", wrapError("presign", key, err)
+	}
+	return u.String(), nil
+}
+
+// CreateMultipartUpload starts a multipart upload of object key with the attributes in opts (Size, PartSize,
+// and Progress are ignored) and returns its upload ID. Parts are sent with UploadPart or PresignUploadPart
+// and the object is created by CompleteMultipartUpload; AbortMultipartUpload discards the parts.
+func (c *Client) CreateMultipartUpload(ctx context.Context, key string, opts UploadOptions) (string, error) {
+	opts.Progress = nil
+	id, err := c.core.NewMultipartUpload(ctx, c.bucket, key, opts.putOptions(0))
+	if err != nil {
+		return "", wrapError("start multipart upload of", key, err)
+	}
+	return id, nil
+}
+
+// UploadPart uploads part partNumber (1 to 10000) of size bytes from r. Every part but the last must be at
+// least 5 MiB. The returned part is passed to CompleteMultipartUpload.
+func (c *Client) UploadPart(ctx context.Context, key, uploadID string, partNumber int, r io.Reader, size int64) (minio.CompletePart, error) {
+	p, err := c.core.PutObjectPart(ctx, c.bucket, key, uploadID, partNumber, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return minio.CompletePart{}, wrapError("upload part "+strconv.Itoa(partNumber)+" of", key, err)
+	}
+	return minio.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag}, nil
+}
+
+// PresignUploadPart returns a URL that uploads part partNumber of a multipart upload without credentials,
+// for clients that send large files directly. The ETag response header of each part upload must be passed
+// back to CompleteMultipartUpload.
+func (c *Client) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	params := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	u, err := c.client.Presign(ctx, http.MethodPut, c.bucket, key, expires, params)
+	if err != nil {
+		return "", wrapError("presign", key, err)
+	}
+	return u.String(), nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts, in part number order, into object key.
+func (c *Client) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []minio.CompletePart) (minio.UploadInfo, error) {
+	info, err := c.core.CompleteMultipartUpload(ctx, c.bucket, key, uploadID, parts, minio.PutObjectOptions{})
+	if err != nil {
+		return minio.UploadInfo{}, wrapError("complete multipart upload of", key, err)
+	}
+	return info, nil
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts.
+func (c *Client) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if err := c.core.AbortMultipartUpload(ctx, c.bucket, key, uploadID); err != nil {
+		return wrapError("abort multipart upload of", key, err)
+	}
+	return nil
+}
+
+// progressReader receives the bytes minio-go has sent and reports the running total. minio-go reads it from
+// one goroutine per part being uploaded.
+type progressReader struct {
+	fn   func(int64)
+	sent atomic.Int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	p.fn(p.sent.Add(int64(len(b))))
+	return len(b), nil
+}
+
+// wrapError adds the operation and object key to err and marks missing objects with ErrNotFound.
+func wrapError(op, key string, err error) error {
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) && (resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound && resp.Code != "NoSuchBucket") {
+		return fmt.Errorf("failed to %s object %s: %w: %w", op, key, ErrNotFound, err)
+	}
+	return fmt.Errorf("failed to %s object %s: %w", op, key, err)
+}
@@ -0,0 +1,267 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestClient_UploadSingleRequest(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client()
+
+	info, err := c.Upload(context.Background(), "kyc/doc.txt", strings.NewReader("hello"), UploadOptions{
+		ContentType:  "text/plain",
+		CacheControl: "private, max-age=0",
+		Metadata:     map[string]string{"owner": "u-1"},
+		Size:         5,
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if info.Key != "kyc/doc.txt" || info.ETag == "" {
+		t.Errorf("info = %+v", info)
+	}
+	obj := f.objects["kyc/doc.txt"]
+	if string(obj.data) != "hello" {
+		t.Errorf("stored %q, want hello", obj.data)
+	}
+	if obj.header.Get("Content-Type") != "text/plain" || obj.header.Get("Cache-Control") != "private, max-age=0" || obj.header.Get("X-Amz-Meta-Owner") != "u-1" {
+		t.Errorf("header = %v", obj.header)
+	}
+	if f.parts != 0 {
+		t.Errorf("part uploads = %d, want 0", f.parts)
+	}
+}
+
+func TestClient_UploadMultipart(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client(WithPartSize(5 << 20))
+	data := bytes.Repeat([]byte("0123456789abcdef"), 12<<16) // 12 MiB: three parts
+
+	var sent int64
+	_, err := c.Upload(context.Background(), "big.bin", bytes.NewReader(data), UploadOptions{
+		Progress: func(n int64) { sent = n },
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !bytes.Equal(f.objects["big.bin"].data, data) {
+		t.Fatalf("stored %d bytes, want %d", len(f.objects["big.bin"].data), len(data))
+	}
+	if f.parts != 3 {
+		t.Errorf("part uploads = %d, want 3", f.parts)
+	}
+	if sent != int64(len(data)) {
+		t.Errorf("progress = %d, want %d", sent, len(data))
+	}
+	if len(f.uploads) != 0 {
+		t.Errorf("%d multipart uploads left open", len(f.uploads))
+	}
+}
+
+func TestClient_UploadParallelProgress(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client(WithPartSize(minPartSize))
+	data := bytes.Repeat([]byte("0123456789abcdef"), 12<<16) // 12 MiB: three parts sent in parallel
+
+	var sent atomic.Int64
+	_, err := c.Upload(context.Background(), "parallel.bin", bytes.NewReader(data), UploadOptions{
+		Size: int64(len(data)),
+		Progress: func(n int64) {
+			for cur := sent.Load(); n > cur && !sent.CompareAndSwap(cur, n); cur = sent.Load() {
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !bytes.Equal(f.objects["parallel.bin"].data, data) {
+		t.Fatalf("stored %d bytes, want %d", len(f.objects["parallel.bin"].data), len(data))
+	}
+	if f.parts != 3 {
+		t.Errorf("part uploads = %d, want 3", f.parts)
+	}
+	if got := sent.Load(); got != int64(len(data)) {
+		t.Errorf("progress = %d, want %d", got, len(data))
+	}
+}
+
+func TestClient_UploadCancelled(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.Upload(ctx, "cancelled.txt", strings.NewReader("data"), UploadOptions{Size: 4}); err == nil {
+		t.Fatal("Upload with cancelled context succeeded")
+	}
+	if _, ok := f.objects["cancelled.txt"]; ok {
+		t.Error("object written despite cancelled context")
+	}
+}
+
+func TestClient_Download(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client()
+	ctx := context.Background()
+	f.put("report.csv", []byte("0123456789"), "text/csv")
+
+	tests := []struct {
+		name string
+		opts DownloadOptions
+		want string
+	}{
+		{"whole object", DownloadOptions{}, "0123456789"},
+		{"range", DownloadOptions{Offset: 2, Length: 3}, "234"},
+		{"from offset", DownloadOptions{Offset: 7}, "789"},
+		{"suffix", DownloadOptions{Offset: -4}, "6789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			info, err := c.Download(ctx, "report.csv", &buf, tt.opts)
+			if err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+			if info.ContentType != "text/csv" {
+				t.Errorf("info = %+v", info)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.Download(ctx, "missing.csv", &buf, DownloadOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Download missing: err = %v, want ErrNotFound", err)
+	}
+	if _, err := c.NewReader(ctx, "missing.csv", DownloadOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("NewReader missing: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_AttrsExistsDeleteList(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, k := range []string{"img/2.png", "img/1.png", "img/thumbs/1.png", "doc.pdf"} {
+		f.put(k, []byte(k), "image/png")
+	}
+
+	if ok, err := c.Exists(ctx, "img/1.png"); err != nil || !ok {
+		t.Fatalf("Exists = %v, %v; want true", ok, err)
+	}
+	attrs, err := c.Attrs(ctx, "img/1.png")
+	if err != nil || attrs.Size != int64(len("img/1.png")) || attrs.ContentType != "image/png" {
+		t.Fatalf("Attrs = %+v, %v", attrs, err)
+	}
+	objs, err := c.List(ctx, "img/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var keys []string
+	for _, o := range objs {
+		keys = append(keys, o.Key)
+	}
+	if strings.Join(keys, ",") != "img/1.png,img/2.png,img/thumbs/1.png" {
+		t.Errorf("List = %v", keys)
+	}
+
+	if err := c.Delete(ctx, "img/1.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ok, err := c.Exists(ctx, "img/1.png"); err != nil || ok {
+		t.Errorf("Exists after delete = %v, %v; want false", ok, err)
+	}
+	if _, err := c.Attrs(ctx, "img/1.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Attrs after delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_ExplicitMultipart(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client()
+	ctx := context.Background()
+
+	id, err := c.CreateMultipartUpload(ctx, "video.mp4", UploadOptions{ContentType: "video/mp4"})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+	first := bytes.Repeat([]byte("a"), 5<<20)
+	var parts []minio.CompletePart
+	for i, chunk := range [][]byte{first, []byte("tail")} {
+		p, err := c.UploadPart(ctx, "video.mp4", id, i+1, bytes.NewReader(chunk), int64(len(chunk)))
+		if err != nil {
+			t.Fatalf("UploadPart %d: %v", i+1, err)
+		}
+		parts = append(parts, p)
+	}
+	if _, err := c.CompleteMultipartUpload(ctx, "video.mp4", id, parts); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	obj := f.objects["video.mp4"]
+	if len(obj.data) != len(first)+4 || obj.header.Get("Content-Type") != "video/mp4" {
+		t.Errorf("stored %d bytes, header %v", len(obj.data), obj.header)
+	}
+
+	id, err = c.CreateMultipartUpload(ctx, "aborted.bin", UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AbortMultipartUpload(ctx, "aborted.bin", id); err != nil {
+		t.Fatalf("AbortMultipartUpload: %v", err)
+	}
+	if len(f.uploads) != 0 {
+		t.Errorf("%d multipart uploads left open", len(f.uploads))
+	}
+}
+
+func TestClient_Presign(t *testing.T) {
+	f := newFakeS3(t)
+	c := f.client()
+	ctx := context.Background()
+	f.put("a.txt", []byte("presigned"), "text/plain")
+
+	get, err := c.PresignGet(ctx, "a.txt", 10*time.Minute, nil)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	q := presignedQuery(t, get)
+	if q.Get("X-Amz-Expires") != "600" || q.Get("X-Amz-Signature") == "" {
+		t.Errorf("GET URL query = %v", q)
+	}
+	resp, err := http.Get(get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET presigned = %d", resp.StatusCode)
+	}
+
+	put, err := c.PresignPut(ctx, "b.png", time.Minute, "image/png")
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	if h := presignedQuery(t, put).Get("X-Amz-SignedHeaders"); !strings.Contains(h, "content-type") {
+		t.Errorf("PUT URL signs %q, want content-type", h)
+	}
+
+	part, err := c.PresignUploadPart(ctx, "c.bin", "upload-1", 2, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignUploadPart: %v", err)
+	}
+	if q := presignedQuery(t, part); q.Get("partNumber") != "2" || q.Get("uploadId") != "upload-1" {
+		t.Errorf("part URL query = %v", q)
+	}
+}
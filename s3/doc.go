@@ -0,0 +1,28 @@
+/*
+Package s3 provides an S3-compatible object storage client (AWS S3, MinIO) built on minio-go and configured
+from the config package.
+
+Role in architecture:
+  - Infrastructure adapter: connects to an S3 endpoint and exposes object operations on one bucket; the
+    counterpart of gcs for workloads outside GCP.
+
+Responsibilities:
+  - Client (New, NewWithClient): context-first operations on one bucket. Upload streams from an io.Reader in
+    one request or as a multipart upload (part size from Options or UploadOptions) with content
+    type/encoding, cache-control, and user metadata; Download and NewReader read whole objects or byte
+    ranges; Attrs, Exists, Delete, List.
+  - Presigned URLs: PresignGet (optional response header overrides), PresignPut (optionally bound to a
+    Content-Type), PresignUploadPart for direct multipart uploads from clients.
+  - Multipart uploads: CreateMultipartUpload, UploadPart, CompleteMultipartUpload, AbortMultipartUpload.
+  - Setup, GetDefault, HealthCheck, Close: the default Client from config.S3.
+
+Constraints:
+  - SDK: github.com/minio/minio-go/v7. One bucket per Client.
+  - Credentials: S3_ACCESS_KEY_ID/S3_SECRET_ACCESS_KEY, or the AWS environment, shared credentials file, or
+    instance role when unset.
+  - Missing objects return ErrNotFound; deleting a missing object succeeds, as in S3.
+
+This package must NOT:
+  - Contain use-case logic; only S3 operations.
+*/
+package s3
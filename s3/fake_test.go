@@ -0,0 +1,314 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// fakeS3 is a minimal in-process implementation of the S3 REST API for bucket "test-bucket" (path-style):
+// object PUT/GET/HEAD/DELETE with byte ranges, ListObjectsV2, and multipart uploads. Signatures are not
+// checked.
+type fakeS3 struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+	uploads map[string]*fakeUpload
+	nextID  int
+	parts   int // part uploads received
+}
+
+type fakeObject struct {
+	header http.Header
+	data   []byte
+	mod    time.Time
+}
+
+type fakeUpload struct {
+	key    string
+	header http.Header
+	parts  map[int][]byte
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	f := &fakeS3{t: t, objects: map[string]*fakeObject{}, uploads: map[string]*fakeUpload{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// client returns a Client for bucket "test-bucket" on the fake server.
+func (f *fakeS3) client(override ...Option) *Client {
+	f.t.Helper()
+	mc, err := minio.New(strings.TrimPrefix(f.srv.URL, "http://"), &minio.Options{
+		Creds:        credentials.NewStaticV4("AKID", "SECRET", ""),
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+		MaxRetries:   1,
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return NewWithClient(mc, "test-bucket", Options{}, override...)
+}
+
+func (f *fakeS3) put(key string, data []byte, contentType string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(key, data, http.Header{"Content-Type": {contentType}})
+}
+
+// store saves an object; f.mu must be held.
+func (f *fakeS3) store(key string, data []byte, h http.Header) string {
+	sum := md5.Sum(data)
+	etag := hex.EncodeToString(sum[:])
+	header := http.Header{"Etag": {`"` + etag + `"`}}
+	for k, v := range h {
+		if k == "Content-Type" || k == "Cache-Control" || k == "Content-Disposition" || k == "Content-Encoding" || strings.HasPrefix(k, "X-Amz-Meta-") {
+			header[k] = v
+		}
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "binary/octet-stream")
+	}
+	f.objects[key] = &fakeObject{header: header, data: data, mod: time.Now().UTC().Truncate(time.Second)}
+	return header.Get("Etag")
+}
+
+func (f *fakeS3) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != "test-bucket" {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	q := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet && q.Get("list-type") == "2":
+		f.serveList(w, q.Get("prefix"))
+	case key == "":
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		f.nextID++
+		id := "upload-" + strconv.Itoa(f.nextID)
+		f.uploads[id] = &fakeUpload{key: key, header: r.Header.Clone(), parts: map[int][]byte{}}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: id})
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		f.serveComplete(w, r, key, q.Get("uploadId"))
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		up, ok := f.uploads[q.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		data := readBody(r)
+		up.parts[n] = data
+		f.parts++
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(f.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		w.Header().Set("ETag", f.store(key, readBody(r), r.Header))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		f.serveObject(w, r, key)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) serveObject(w http.ResponseWriter, r *http.Request, key string) {
+	obj, ok := f.objects[key]
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	for k, v := range obj.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Last-Modified", obj.mod.Format(http.TimeFormat))
+	w.Header().Set("Accept-Ranges", "bytes")
+	data, status := obj.data, http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end := parseRange(rng, int64(len(obj.data)))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(obj.data)))
+		data, status = obj.data[start:end+1], http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func (f *fakeS3) serveComplete(w http.ResponseWriter, r *http.Request, key, id string) {
+	up, ok := f.uploads[id]
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	var req struct {
+		Parts []struct {
+			PartNumber int
+			ETag       string
+		} `xml:"Part"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	var data []byte
+	for i, p := range req.Parts {
+		part, ok := up.parts[p.PartNumber]
+		if !ok || (i > 0 && p.PartNumber <= req.Parts[i-1].PartNumber) {
+			writeError(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		data = append(data, part...)
+	}
+	delete(f.uploads, id)
+	etag := f.store(key, data, up.header)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: "test-bucket", Key: key, ETag: etag})
+}
+
+func (f *fakeS3) serveList(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	res := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: "test-bucket", Prefix: prefix, KeyCount: len(keys), MaxKeys: 1000}
+	for _, k := range keys {
+		o := f.objects[k]
+		res.Contents = append(res.Contents, content{
+			Key: k, LastModified: o.mod.Format("2006-01-02T15:04:05.000Z"), ETag: o.header.Get("Etag"),
+			Size: len(o.data), StorageClass: "STANDARD",
+		})
+	}
+	writeXML(w, res)
+}
+
+// readBody returns the request payload, decoding aws-chunked bodies (streaming signatures and trailers).
+func readBody(r *http.Request) []byte {
+	if r.Header.Get("X-Amz-Decoded-Content-Length") == "" && !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		data, _ := io.ReadAll(r.Body)
+		return data
+	}
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return out.Bytes()
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil || n == 0 {
+			return out.Bytes()
+		}
+		_, _ = io.CopyN(&out, br, n)
+		_, _ = br.ReadString('\n')
+	}
+}
+
+// parseRange parses "bytes=a-b", "bytes=a-" and "bytes=-n" into inclusive bounds.
+func parseRange(h string, size int64) (start, end int64) {
+	spec := strings.TrimPrefix(h, "bytes=")
+	a, b, _ := strings.Cut(spec, "-")
+	end = size - 1
+	if a == "" {
+		n, _ := strconv.ParseInt(b, 10, 64)
+		return max(size-n, 0), end
+	}
+	start, _ = strconv.ParseInt(a, 10, 64)
+	if b != "" {
+		e, _ := strconv.ParseInt(b, 10, 64)
+		end = min(e, end)
+	}
+	return start, end
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: code})
+}
+
+// presignedQuery returns the query of a presigned URL.
+func presignedQuery(t *testing.T, raw string) url.Values {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
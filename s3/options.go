@@ -0,0 +1,44 @@
+package s3
+
+import (
+	"net/http"
+)
+
+const (
+	// defaultPartSize is the multipart upload part size (16 MiB); each in-flight upload buffers one part when
+	// the object size is unknown.
+	defaultPartSize = 16 << 20
+	// minPartSize is the S3 minimum size of every part but the last.
+	minPartSize = 5 << 20
+)
+
+// Options holds Client settings. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Transport is the HTTP transport used by New (e.g. one trusting a MinIO test certificate); default is the
+	// minio-go transport.
+	Transport http.RoundTripper
+	// PartSize is the default multipart upload part size in bytes; default 16 MiB, minimum 5 MiB.
+	PartSize uint64
+}
+
+func (o *Options) applyDefaults() {
+	if o.PartSize == 0 {
+		o.PartSize = defaultPartSize
+	}
+	if o.PartSize < minPartSize {
+		o.PartSize = minPartSize
+	}
+}
+
+// Option is a functional option applied to Options.
+type Option func(*Options)
+
+// WithTransport sets the HTTP transport used by New.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *Options) { o.Transport = rt }
+}
+
+// WithPartSize sets the default multipart upload part size in bytes.
+func WithPartSize(n uint64) Option {
+	return func(o *Options) { o.PartSize = n }
+}
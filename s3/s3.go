@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/logger"
+)
+
+var defaultClient *Client
+
+// Setup initializes the default Client from config and verifies bucket access. No-op if S3.Enabled is false.
+func Setup() error {
+	configuration := config.GetConfig()
+
+	if !configuration.S3.Enabled {
+		logger.Infof("S3 is disabled, skipping setup")
+		return nil
+	}
+	if configuration.S3.BucketName == "" {
+		return fmt.Errorf("S3 bucket name is not configured")
+	}
+
+	c, err := New(&configuration.S3, Options{})
+	if err != nil {
+		return err
+	}
+	if err := c.HealthCheck(context.Background()); err != nil {
+		return err
+	}
+	defaultClient = c
+	logger.Infof("S3 client initialized successfully with bucket: %s", c.bucket)
+	return nil
+}
+
+// GetDefault returns the Client created by Setup. Panics if Setup was not called.
+func GetDefault() *Client {
+	if defaultClient == nil {
+		panic("S3 client is not initialized. Call Setup() first.")
+	}
+	return defaultClient
+}
+
+// HealthCheck checks the configured bucket with ctx. Returns an error if Setup was not called or the bucket is
+// not accessible.
+func HealthCheck(ctx context.Context) error {
+	if defaultClient == nil {
+		return fmt.Errorf("S3 client is not initialized")
+	}
+	return defaultClient.HealthCheck(ctx)
+}
+
+// Close closes the default Client.
+func Close() error {
+	if defaultClient != nil {
+		return defaultClient.Close()
+	}
+	return nil
+}
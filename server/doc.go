@@ -0,0 +1,26 @@
+/*
+Package server wires a service's process lifecycle: configuration, infrastructure startup, the standard Gin
+middleware stack, the HTTP server, and graceful shutdown.
+
+Role in architecture:
+  - Composition root helper: replaces the per-service main boilerplate (config.Setup, database.Setup,
+    redis.Setup, gcs.Setup, s3.Setup, router, ListenAndServe, cleanup on signal). No business logic.
+
+Responsibilities:
+  - New: load config, start tracing/database/Redis/GCS/S3 as enabled, register their health checks, build the
+    engine (trace, instrumentation, logger, recovery, metrics, timeout, rate limit) with /livez, /readyz,
+    and /metrics; metrics go to Options.Registerer (default registry when nil).
+  - AddComponent: start application components (consumers, schedulers) that are stopped before the
+    infrastructure they use.
+  - Run/Serve: serve until the context is cancelled or SIGINT/SIGTERM arrives.
+  - Shutdown: readiness to shutting_down, optional delay for load balancer deregistration, drain in-flight
+    requests, stop components in reverse start order, flush queued log entries, all within ShutdownTimeout.
+
+Constraints:
+  - Uses the global singletons of config, database, redis, gcs, and s3; one Server per process.
+  - Shutdown runs once; later calls return the first result.
+
+This package must NOT:
+  - Register application routes or contain use-case logic; use Engine() from main.
+*/
+package server
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/middlewares"
+	"github.com/turahe/pkg/tracing"
+)
+
+const (
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultReadTimeout       = 10 * time.Second
+	defaultWriteTimeout      = 10 * time.Second
+	defaultIdleTimeout       = 60 * time.Second
+	defaultRequestTimeout    = 10 * time.Second
+	defaultShutdownTimeout   = 25 * time.Second
+)
+
+// Options configures a Server. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Config is used as-is when set (and stored in config.Config); otherwise config.Setup(ConfigPath) runs.
+	Config *config.Configuration
+	// ConfigPath is the .env file passed to config.Setup; "" loads .env from the working directory.
+	ConfigPath string
+
+	// Addr is the listen address; default ":" + Config.Server.Port.
+	Addr string
+	// HTTP server timeouts; defaults 5s, 10s, 10s, 60s.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// RequestTimeout is the per-request context deadline (middlewares.RequestTimeout); default 10s,
+	// negative disables it.
+	RequestTimeout time.Duration
+
+	// ShutdownTimeout bounds the whole shutdown: drain plus component Stop calls. Default 25s; keep it below
+	// the orchestrator's grace period (Kubernetes terminationGracePeriodSeconds: 30).
+	ShutdownTimeout time.Duration
+	// ShutdownDelay is how long readiness reports shutting_down before the listener closes, so load balancers
+	// stop routing first. Default 0; 5s is typical behind a Kubernetes Service.
+	ShutdownDelay time.Duration
+	// Signals that trigger shutdown; default SIGINT and SIGTERM.
+	Signals []os.Signal
+
+	// AccessLog configures the request log (middlewares.LoggerWithOptions). SkipPaths defaults to
+	// /livez, /readyz, and /metrics; set it to an empty non-nil slice to log probes and scrapes.
+	AccessLog middlewares.AccessLogOptions
+	// Registerer receives the HTTP metrics (middlewares.NewMetrics) and log_dropped_entries_total
+	// (logger.RegisterMetrics), and /metrics serves it when it is also a prometheus.Gatherer. Default nil
+	// uses the default Prometheus registry.
+	Registerer prometheus.Registerer
+
+	// Tracing, when set, runs tracing.Setup first, adds tracing.Middleware, and calls tracing.Shutdown last.
+	Tracing *tracing.Config
+	// WithoutDatabase skips database.Setup (services without a database).
+	WithoutDatabase bool
+	// Middlewares are appended after the standard stack (e.g. CORS, auth).
+	Middlewares []gin.HandlerFunc
+}
+
+func (o *Options) applyDefaults(cfg *config.Configuration) {
+	if o.Addr == "" {
+		o.Addr = ":" + cfg.Server.Port
+	}
+	if o.ReadHeaderTimeout <= 0 {
+		o.ReadHeaderTimeout = defaultReadHeaderTimeout
+	}
+	if o.ReadTimeout <= 0 {
+		o.ReadTimeout = defaultReadTimeout
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = defaultWriteTimeout
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = defaultIdleTimeout
+	}
+	if o.RequestTimeout == 0 {
+		o.RequestTimeout = defaultRequestTimeout
+	}
+	if o.ShutdownTimeout <= 0 {
+		o.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if o.ShutdownDelay < 0 {
+		o.ShutdownDelay = 0
+	}
+	if o.AccessLog.SkipPaths == nil {
+		o.AccessLog.SkipPaths = []string{"/livez", "/readyz", "/metrics"}
+	}
+	if len(o.Signals) == 0 {
+		o.Signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+}
+
+// Option is a functional option applied to Options (e.g. WithAddr, WithShutdownDelay).
+type Option func(*Options)
+
+// WithConfig uses cfg instead of loading configuration with config.Setup.
+func WithConfig(cfg *config.Configuration) Option {
+	return func(o *Options) { o.Config = cfg }
+}
+
+// WithAddr sets the listen address (e.g. ":8080").
+func WithAddr(addr string) Option {
+	return func(o *Options) { o.Addr = addr }
+}
+
+// WithShutdownTimeout sets the overall shutdown deadline.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *Options) { o.ShutdownTimeout = d }
+}
+
+// WithShutdownDelay sets how long readiness fails before the listener closes.
+func WithShutdownDelay(d time.Duration) Option {
+	return func(o *Options) { o.ShutdownDelay = d }
+}
+
+// WithAccessLog sets the request log skip paths, sampling, and slow threshold.
+func WithAccessLog(opts middlewares.AccessLogOptions) Option {
+	return func(o *Options) { o.AccessLog = opts }
+}
+
+// WithRegisterer registers the server's metrics on reg instead of the default Prometheus registry.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(o *Options) { o.Registerer = reg }
+}
+
+// WithMiddleware appends middleware after the standard stack.
+func WithMiddleware(mw ...gin.HandlerFunc) Option {
+	return func(o *Options) { o.Middlewares = append(o.Middlewares, mw...) }
+}
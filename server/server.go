@@ -0,0 +1,307 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/database"
+	"github.com/turahe/pkg/gcs"
+	"github.com/turahe/pkg/health"
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/middlewares"
+	"github.com/turahe/pkg/redis"
+	"github.com/turahe/pkg/s3"
+	"github.com/turahe/pkg/tracing"
+)
+
+// Component is a dependency with a lifecycle. Start runs when the component is added; Stop runs during
+// shutdown in reverse order of Start, so a component is stopped before the components it depends on.
+// Either func may be nil.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Server owns the Gin engine, the HTTP server, the health registry, and the started components.
+type Server struct {
+	opts       Options
+	cfg        *config.Configuration
+	engine     *gin.Engine
+	health     *health.Registry
+	metrics    *middlewares.HTTPMetrics // nil: middlewares.Metrics on the default registry
+	httpServer *http.Server
+
+	mu       sync.Mutex
+	started  []Component
+	shutdown sync.Once
+	stopErr  error
+}
+
+// New loads configuration, starts the infrastructure enabled in it (tracing, database, Redis, GCS, S3; in
+// that order), registers their health checks, and builds the Gin engine with the standard middleware stack:
+// tracing (optional), CloudTraceMiddleware, HTTPInstrumentation, LoggerWithOptions, RecoveryHandler, Metrics,
+// RequestTimeout, RateLimiter, then Options.Middlewares. GET /livez, /readyz, and /metrics are mounted.
+// If a component fails to start, the ones already started are stopped in reverse order.
+func New(opts Options, override ...Option) (*Server, error) {
+	for _, o := range override {
+		o(&opts)
+	}
+	cfg := opts.Config
+	if cfg == nil {
+		if err := config.Setup(opts.ConfigPath); err != nil {
+			return nil, fmt.Errorf("server: %w", err)
+		}
+		cfg = config.GetConfig()
+	} else {
+		config.Config = cfg
+	}
+	opts.applyDefaults(cfg)
+	if cfg.Server.Mode != "" {
+		gin.SetMode(cfg.Server.Mode)
+	}
+
+	s := &Server{opts: opts, cfg: cfg, health: health.New(health.Options{})}
+	if err := s.registerMetrics(); err != nil {
+		return nil, fmt.Errorf("server: %w", err)
+	}
+	if err := s.startInfrastructure(); err != nil {
+		return nil, errors.Join(err, s.stopComponents(context.Background()))
+	}
+	s.engine = s.buildEngine()
+	s.httpServer = &http.Server{
+		Addr:              opts.Addr,
+		Handler:           s.engine,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+	}
+	return s, nil
+}
+
+// registerMetrics registers the HTTP and log metrics on Options.Registerer, or on the default registry when
+// it is nil. Metrics already registered there by another Server are reused.
+func (s *Server) registerMetrics() error {
+	reg := s.opts.Registerer
+	if reg == nil {
+		return logger.RegisterMetrics(prometheus.DefaultRegisterer)
+	}
+	m, err := middlewares.NewMetrics(middlewares.MetricsOptions{Registerer: reg})
+	if err != nil {
+		return err
+	}
+	s.metrics = m
+	return logger.RegisterMetrics(reg)
+}
+
+func (s *Server) startInfrastructure() error {
+	ignoreCtx := func(fn func() error) func(context.Context) error {
+		return func(context.Context) error { return fn() }
+	}
+	var components []Component
+	if s.opts.Tracing != nil {
+		tc := *s.opts.Tracing
+		components = append(components, Component{
+			Name:  "tracing",
+			Start: func(context.Context) error { return tracing.Setup(tc) },
+			Stop:  tracing.Shutdown,
+		})
+	}
+	if !s.opts.WithoutDatabase {
+		components = append(components, Component{Name: "database", Start: ignoreCtx(database.Setup), Stop: ignoreCtx(database.Cleanup)})
+	}
+	if s.cfg.Redis.Enabled {
+		components = append(components, Component{Name: "redis", Start: ignoreCtx(redis.Setup), Stop: ignoreCtx(redis.Close)})
+	}
+	if s.cfg.GCS.Enabled {
+		components = append(components, Component{Name: "gcs", Start: ignoreCtx(gcs.Setup), Stop: ignoreCtx(gcs.Close)})
+	}
+	if s.cfg.S3.Enabled {
+		components = append(components, Component{Name: "s3", Start: ignoreCtx(s3.Setup), Stop: ignoreCtx(s3.Close)})
+	}
+	for _, c := range components {
+		if err := s.AddComponent(c); err != nil {
+			return err
+		}
+	}
+
+	checks := []health.Check{}
+	if !s.opts.WithoutDatabase {
+		checks = append(checks, health.Check{Name: "database", Func: health.PrimaryDatabase(), Critical: true})
+		if s.cfg.DatabaseSite.Dbname != "" {
+			checks = append(checks, health.Check{Name: "database_site", Func: health.SiteDatabase(), Critical: true})
+		}
+	}
+	if s.cfg.Redis.Enabled {
+		checks = append(checks, health.Check{Name: "redis", Func: health.Redis(), Critical: true})
+	}
+	if s.cfg.GCS.Enabled {
+		checks = append(checks, health.Check{Name: "gcs", Func: health.GCS()})
+	}
+	if s.cfg.S3.Enabled {
+		checks = append(checks, health.Check{Name: "s3", Func: health.S3()})
+	}
+	for _, c := range checks {
+		if err := s.health.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) buildEngine() *gin.Engine {
+	metricsMiddleware, metricsHandler := middlewares.Metrics(), middlewares.MetricsHandler()
+	if s.metrics != nil {
+		metricsMiddleware, metricsHandler = s.metrics.Middleware(), s.metrics.Handler()
+	}
+	engine := gin.New()
+	if s.opts.Tracing != nil {
+		engine.Use(tracing.Middleware())
+	}
+	engine.Use(
+		middlewares.CloudTraceMiddleware(),
+		middlewares.HTTPInstrumentation(),
+		middlewares.LoggerWithOptions(s.opts.AccessLog),
+		middlewares.RecoveryHandler,
+		metricsMiddleware,
+		middlewares.RequestTimeout(s.opts.RequestTimeout),
+		middlewares.RateLimiter(),
+	)
+	engine.Use(s.opts.Middlewares...)
+	engine.NoMethod(middlewares.NoMethodHandler())
+	engine.NoRoute(middlewares.NoRouteHandler())
+	s.health.Mount(engine)
+	engine.GET("/metrics", metricsHandler)
+	return engine
+}
+
+// Engine returns the Gin engine for registering application routes.
+func (s *Server) Engine() *gin.Engine {
+	return s.engine
+}
+
+// Health returns the health registry behind /readyz, for registering application checks.
+func (s *Server) Health() *health.Registry {
+	return s.health
+}
+
+// Config returns the configuration the server was built with.
+func (s *Server) Config() *config.Configuration {
+	return s.cfg
+}
+
+// AddComponent starts c (if Start is set) and records it for shutdown. Components added after New are
+// stopped before the built-in infrastructure, so they may use the database and Redis in Stop.
+func (s *Server) AddComponent(c Component) error {
+	if c.Start != nil {
+		if err := c.Start(context.Background()); err != nil {
+			return fmt.Errorf("server: start %s: %w", c.Name, err)
+		}
+	}
+	s.mu.Lock()
+	s.started = append(s.started, c)
+	s.mu.Unlock()
+	return nil
+}
+
+// Run listens on Options.Addr and serves until ctx is cancelled or a shutdown signal arrives, then runs
+// Shutdown with Options.ShutdownTimeout.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return errors.Join(fmt.Errorf("server: %w", err), s.Shutdown(context.Background()))
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run with a caller-provided listener.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, s.opts.Signals...)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.httpServer.Serve(ln) }()
+	logger.Infof("server: listening on %s", ln.Addr())
+
+	var err error
+	select {
+	case <-ctx.Done():
+		logger.Infof("server: shutdown requested")
+	case err = <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		} else {
+			err = fmt.Errorf("server: %w", err)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+	return errors.Join(err, s.Shutdown(shutdownCtx))
+}
+
+// Shutdown runs the shutdown sequence once: readiness reports shutting_down, wait ShutdownDelay, stop
+// accepting connections and drain in-flight requests, stop components in reverse start order, then flush
+// queued log entries (logger.Flush). All steps share ctx's deadline; a component failing to stop does not
+// prevent the others from stopping.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdown.Do(func() {
+		s.health.SetShuttingDown(true)
+		if s.opts.ShutdownDelay > 0 {
+			t := time.NewTimer(s.opts.ShutdownDelay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+			}
+		}
+
+		var errs []error
+		if s.httpServer != nil {
+			if err := s.httpServer.Shutdown(ctx); err != nil {
+				logger.Errorf("server: drain: %v", err)
+				errs = append(errs, fmt.Errorf("server: drain: %w", err))
+				_ = s.httpServer.Close()
+			}
+		}
+		if err := s.stopComponents(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		s.stopErr = errors.Join(errs...)
+		logger.Infof("server: shutdown complete")
+		_ = logger.Flush(ctx)
+	})
+	return s.stopErr
+}
+
+// stopComponents stops started components in reverse order and forgets them.
+func (s *Server) stopComponents(ctx context.Context) error {
+	s.mu.Lock()
+	started := s.started
+	s.started = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			continue
+		}
+		if err := c.Stop(ctx); err != nil {
+			logger.Errorf("server: stop %s: %v", c.Name, err)
+			errs = append(errs, fmt.Errorf("server: stop %s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turahe/pkg/config"
+)
+
+func testConfig() *config.Configuration {
+	return &config.Configuration{Server: config.ServerConfiguration{Port: "0", Mode: gin.TestMode}}
+}
+
+// recorder collects component stop order.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) component(name string, stopErr error) Component {
+	return Component{
+		Name: name,
+		Stop: func(context.Context) error {
+			r.mu.Lock()
+			r.calls = append(r.calls, name)
+			r.mu.Unlock()
+			return stopErr
+		},
+	}
+}
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	s, err := New(Options{WithoutDatabase: true}, WithConfig(testConfig()), WithShutdownDelay(100*time.Millisecond))
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	require.NoError(t, s.AddComponent(rec.component("consumer", nil)))
+	require.NoError(t, s.AddComponent(rec.component("scheduler", errors.New("flush failed"))))
+
+	inHandler := make(chan struct{})
+	s.Engine().GET("/slow", func(c *gin.Context) {
+		close(inHandler)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	base := "http://" + ln.Addr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- s.Serve(ctx, ln) }()
+
+	resp, err := http.Get(base + "/readyz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	slowBody := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slowBody <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		slowBody <- string(b)
+	}()
+	<-inHandler
+	cancel()
+
+	// During ShutdownDelay the listener is still open and readiness fails.
+	time.Sleep(20 * time.Millisecond)
+	resp, err = http.Get(base + "/readyz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	assert.Equal(t, "done", <-slowBody, "in-flight request is drained")
+	err = <-runErr
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stop scheduler: flush failed")
+	assert.Equal(t, []string{"scheduler", "consumer"}, rec.calls, "components stop in reverse order")
+
+	assert.EqualError(t, s.Shutdown(context.Background()), err.Error(), "Shutdown runs once")
+}
+
+func TestServer_StandardRoutes(t *testing.T) {
+	s, err := New(Options{WithoutDatabase: true}, WithConfig(testConfig()))
+	require.NoError(t, err)
+	defer s.Shutdown(context.Background())
+
+	for _, path := range []string{"/livez", "/readyz", "/metrics"} {
+		routes := s.Engine().Routes()
+		found := false
+		for _, r := range routes {
+			if r.Path == path && r.Method == http.MethodGet {
+				found = true
+			}
+		}
+		assert.True(t, found, path)
+	}
+}
+
+func TestServer_Registerer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	newServer := func() *Server {
+		s, err := New(Options{WithoutDatabase: true}, WithConfig(testConfig()), WithRegisterer(reg))
+		require.NoError(t, err)
+		t.Cleanup(func() { s.Shutdown(context.Background()) })
+		return s
+	}
+	newServer()
+	s := newServer() // reuses the metrics registered by the first server
+
+	w := httptest.NewRecorder()
+	s.Engine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	w = httptest.NewRecorder()
+	s.Engine().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",path="/livez",status="200"} 1`)
+
+	// log_dropped_entries_total is registered on reg: an identical collector cannot be registered again.
+	err := reg.Register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "log_dropped_entries_total",
+		Help: "Total number of log entries dropped by the async writer, by severity and reason.",
+	}, []string{"severity", "reason"}))
+	assert.ErrorAs(t, err, &prometheus.AlreadyRegisteredError{})
+}
+
+func TestServer_AddComponentStartError(t *testing.T) {
+	s, err := New(Options{WithoutDatabase: true}, WithConfig(testConfig()))
+	require.NoError(t, err)
+	defer s.Shutdown(context.Background())
+
+	err = s.AddComponent(Component{Name: "broker", Start: func(context.Context) error { return errors.New("unreachable") }})
+	assert.EqualError(t, err, "server: start broker: unreachable")
+}
+
+func TestNew_InfrastructureFailure(t *testing.T) {
+	cfg := testConfig()
+	cfg.Database = config.DatabaseConfiguration{Driver: "invalid-driver", Dbname: "db"}
+	_, err := New(Options{}, WithConfig(cfg))
+	assert.Error(t, err)
+}
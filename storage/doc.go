@@ -0,0 +1,29 @@
+/*
+Package storage provides a backend-neutral object storage interface with GCS, local filesystem, and in-memory
+implementations.
+
+Role in architecture:
+  - Infrastructure port and adapters: application code depends on Storage; the driver in config.Storage picks
+    the implementation, so file logic runs locally and in unit tests without GCP credentials.
+
+Responsibilities:
+  - Storage: Put, Get, Delete, Exists, List, Stat, SignedURL on slash-separated keys; ErrNotFound for missing
+    objects and ErrInvalidKey for keys that are empty, absolute, or contain "." or ".." elements.
+  - GCS (NewGCS): adapter over gcs.Client; V4 signed URLs.
+  - S3 (NewS3): adapter over s3.Client; presigned URLs.
+  - Local (NewLocal): files under a root directory opened with os.Root; atomic writes via temp file and
+    rename; attributes in JSON sidecars under .meta; HMAC-signed URLs verified and served by Handler.
+  - Memory (NewMemory): map-backed store for tests; SignedURL returns non-fetchable memory:// URLs.
+  - New, Setup, GetStorage, Close: build the Storage for config.Storage.Driver ("gcs", "s3", "local", "memory").
+
+Constraints:
+  - The gcs and s3 drivers require GCS.Enabled/S3.Enabled and a bucket name; Setup is a no-op when the driver
+    is gcs and GCS is disabled.
+  - List returns every matching object in one slice; no pagination.
+  - Local signed URLs need STORAGE_BASE_URL and STORAGE_SIGNING_SECRET; Local is for development, not
+    multi-instance deployments.
+
+This package must NOT:
+  - Contain use-case logic (naming schemes, validation of uploaded content); only storage operations.
+*/
+package storage
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gstorage "cloud.google.com/go/storage"
+
+	"github.com/turahe/pkg/gcs"
+)
+
+// GCS is a Storage backed by a gcs.Client and its bucket. Signed URLs use the V4 scheme and need credentials
+// that can sign (a service account key, or the IAM signBlob permission under ADC).
+type GCS struct {
+	client *gcs.Client
+}
+
+// NewGCS returns a GCS Storage using c. Close closes c.
+func NewGCS(c *gcs.Client) *GCS {
+	return &GCS{client: c}
+}
+
+// Close closes the underlying gcs.Client.
+func (g *GCS) Close() error {
+	return g.client.Close()
+}
+
+// Client returns the underlying gcs.Client, for operations outside Storage such as preconditions or ranged
+// reads.
+func (g *GCS) Client() *gcs.Client {
+	return g.client
+}
+
+// Put implements Storage.
+func (g *GCS) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	attrs, err := g.client.Upload(ctx, key, r, gcs.UploadOptions{
+		ContentType:  opts.ContentType,
+		CacheControl: opts.CacheControl,
+		Metadata:     opts.Metadata,
+	})
+	if err != nil {
+		return nil, mapGCSError(err)
+	}
+	return objectInfoFromAttrs(attrs), nil
+}
+
+// Get implements Storage.
+func (g *GCS) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	r, err := g.client.NewReader(ctx, key, gcs.DownloadOptions{})
+	if err != nil {
+		return nil, mapGCSError(err)
+	}
+	return r, nil
+}
+
+// Delete implements Storage.
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return mapGCSError(g.client.Delete(ctx, key, gcs.Preconditions{}))
+}
+
+// Exists implements Storage.
+func (g *GCS) Exists(ctx context.Context, key string) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	return g.client.Exists(ctx, key)
+}
+
+// List implements Storage.
+func (g *GCS) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	for attrs, err := range g.client.List(ctx, gcs.ListOptions{Prefix: prefix}) {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *objectInfoFromAttrs(attrs))
+	}
+	return out, nil
+}
+
+// Stat implements Storage.
+func (g *GCS) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	attrs, err := g.client.Attrs(ctx, key)
+	if err != nil {
+		return nil, mapGCSError(err)
+	}
+	return objectInfoFromAttrs(attrs), nil
+}
+
+// SignedURL implements Storage.
+func (g *GCS) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	u, err := g.client.SignedURL(key, gcs.SignedURLOptions{
+		Method:      opts.Method,
+		Expires:     opts.Expires,
+		ContentType: opts.ContentType,
+	})
+	if err != nil {
+		return "", err
+	}
+	return u.URL, nil
+}
+
+func objectInfoFromAttrs(attrs *gstorage.ObjectAttrs) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          attrs.Name,
+		Size:         attrs.Size,
+		ContentType:  attrs.ContentType,
+		CacheControl: attrs.CacheControl,
+		Metadata:     attrs.Metadata,
+		ETag:         attrs.Etag,
+		Updated:      attrs.Updated,
+	}
+}
+
+// mapGCSError marks gcs.ErrNotFound with ErrNotFound; both stay matchable with errors.Is.
+func mapGCSError(err error) error {
+	if errors.Is(err, gcs.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localMetaDir is the directory under the root that holds attribute sidecars and in-progress writes. Keys
+// inside it are rejected.
+const localMetaDir = ".meta"
+
+// LocalOptions configure a Local backend.
+type LocalOptions struct {
+	// BaseURL is the URL Handler is served at, e.g. "http://localhost:8080/files". Required for SignedURL.
+	BaseURL string
+	// SigningSecret is the HMAC key for signed URLs. Required for SignedURL and Handler.
+	SigningSecret string
+}
+
+// Local is a Storage on the local filesystem for development. Objects are files under the root directory
+// (key "a/b.txt" is <root>/a/b.txt); their attributes are JSON sidecars under <root>/.meta. Writes go to a
+// temporary file that is renamed into place, so readers never see partial objects. All access goes through
+// os.Root, so keys cannot escape the root directory.
+type Local struct {
+	root *os.Root
+	opts LocalOptions
+	now  func() time.Time
+}
+
+// localMeta is the sidecar stored for each object.
+type localMeta struct {
+	ContentType  string            `json:"content_type,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+}
+
+// NewLocal returns a Local rooted at dir, creating the directory if needed. Close releases the root.
+func NewLocal(dir string, opts LocalOptions) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", dir, err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage directory %s: %w", dir, err)
+	}
+	if err := root.MkdirAll(path.Join(localMetaDir, "tmp"), 0o755); err != nil {
+		root.Close()
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", dir, err)
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	return &Local{root: root, opts: opts, now: time.Now}, nil
+}
+
+// Close releases the root directory.
+func (l *Local) Close() error {
+	return l.root.Close()
+}
+
+// Put implements Storage.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := l.validateKey(key); err != nil {
+		return nil, err
+	}
+	contentType, r, err := detectContentType(opts.ContentType, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	if err := l.root.MkdirAll(path.Dir(key), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+
+	tmp := path.Join(localMetaDir, "tmp", rand.Text())
+	f, err := l.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	defer l.root.Remove(tmp) // no-op after a successful rename
+
+	h := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, h), &contextReader{ctx: ctx, r: r})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+
+	meta := localMeta{
+		ContentType:  contentType,
+		CacheControl: opts.CacheControl,
+		Metadata:     copyMetadata(opts.Metadata),
+		ETag:         hex.EncodeToString(h.Sum(nil)),
+	}
+	metaTmp, err := l.stageMeta(key, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	defer l.root.Remove(metaTmp) // no-op after a successful rename
+	// Data first: a failed Put never leaves attributes describing content that was not stored.
+	if err := l.root.Rename(tmp, key); err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	if err := l.root.Rename(metaTmp, metaPath(key)); err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	return l.Stat(ctx, key)
+}
+
+// Get implements Storage.
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := l.validateKey(key); err != nil {
+		return nil, err
+	}
+	f, err := l.root.Open(key)
+	if err != nil {
+		return nil, wrapFSError("read", key, err)
+	}
+	if fi, err := f.Stat(); err != nil || fi.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("failed to read object %s: %w", key, ErrNotFound)
+	}
+	return f, nil
+}
+
+// Delete implements Storage.
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := l.validateKey(key); err != nil {
+		return err
+	}
+	fi, err := l.root.Lstat(key)
+	if err != nil {
+		return wrapFSError("delete", key, err)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("failed to delete object %s: %w", key, ErrNotFound)
+	}
+	if err := l.root.Remove(key); err != nil {
+		return wrapFSError("delete", key, err)
+	}
+	if err := l.root.Remove(metaPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return wrapFSError("delete", key, err)
+	}
+	return nil
+}
+
+// Exists implements Storage.
+func (l *Local) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := l.Stat(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// List implements Storage.
+func (l *Local) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var out []ObjectInfo
+	err := fs.WalkDir(l.root.FS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip the sidecar directory and subtrees that cannot contain the prefix.
+			if p == localMetaDir || (p != "." && !strings.HasPrefix(p+"/", prefix) && !strings.HasPrefix(prefix, p+"/")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(p, prefix) || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := l.Stat(ctx, p)
+		if err != nil {
+			return err
+		}
+		out = append(out, *info)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects with prefix %s: %w", prefix, err)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// Stat implements Storage. Files placed under the root by other means are reported with a content type
+// derived from their extension.
+func (l *Local) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := l.validateKey(key); err != nil {
+		return nil, err
+	}
+	fi, err := l.root.Stat(key)
+	if err != nil {
+		return nil, wrapFSError("stat", key, err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("failed to stat object %s: %w", key, ErrNotFound)
+	}
+	meta, err := l.readMeta(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", key, err)
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = "application/octet-stream"
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  meta.ContentType,
+		CacheControl: meta.CacheControl,
+		Metadata:     meta.Metadata,
+		ETag:         meta.ETag,
+		Updated:      fi.ModTime(),
+	}, nil
+}
+
+// SignedURL implements Storage. It returns BaseURL/<key> with an expiry and an HMAC-SHA256 signature over
+// the method, key, expiry, and content type, which Handler verifies. Requires BaseURL and SigningSecret.
+func (l *Local) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := l.validateKey(key); err != nil {
+		return "", err
+	}
+	if l.opts.BaseURL == "" || l.opts.SigningSecret == "" {
+		return "", fmt.Errorf("%w: local signed URLs need a base URL and signing secret", ErrNotSupported)
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(l.now().Add(opts.Expires).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", l.sign(opts.Method, key, expires, opts.ContentType))
+	return l.opts.BaseURL + "/" + escapeKey(key) + "?" + q.Encode(), nil
+}
+
+// Handler serves signed URLs: GET downloads the object and PUT uploads the request body with the request's
+// Content-Type. Mount it at BaseURL's path with the prefix stripped, e.g.
+// router.Any("/files/*key", gin.WrapH(http.StripPrefix("/files", local.Handler()))). Requests with a
+// missing, invalid, or expired signature get 403.
+func (l *Local) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !l.verify(r, key) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if r.Method == http.MethodPut {
+			_, err := l.Put(r.Context(), key, r.Body, PutOptions{ContentType: r.Header.Get("Content-Type")})
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		info, err := l.Stat(r.Context(), key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		f, err := l.root.Open(key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", info.ContentType)
+		if info.CacheControl != "" {
+			w.Header().Set("Cache-Control", info.CacheControl)
+		}
+		if info.ETag != "" {
+			w.Header().Set("ETag", `"`+info.ETag+`"`)
+		}
+		http.ServeContent(w, r, path.Base(key), info.Updated, f)
+	})
+}
+
+// verify checks the signature and expiry of a signed URL request. HEAD is allowed with a GET signature.
+func (l *Local) verify(r *http.Request, key string) bool {
+	if l.opts.SigningSecret == "" || l.validateKey(key) != nil {
+		return false
+	}
+	q := r.URL.Query()
+	expires := q.Get("expires")
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().Unix() > exp {
+		return false
+	}
+	method, contentType := r.Method, ""
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if method == http.MethodPut {
+		contentType = r.Header.Get("Content-Type")
+	}
+	want := l.sign(method, key, expires, contentType)
+	return hmac.Equal([]byte(q.Get("signature")), []byte(want))
+}
+
+func (l *Local) sign(method, key, expires, contentType string) string {
+	mac := hmac.New(sha256.New, []byte(l.opts.SigningSecret))
+	mac.Write([]byte(method + "\n" + key + "\n" + expires + "\n" + contentType))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) validateKey(key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if key == localMetaDir || strings.HasPrefix(key, localMetaDir+"/") {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+func (l *Local) readMeta(key string) (localMeta, error) {
+	var meta localMeta
+	data, err := l.root.ReadFile(metaPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, err
+	}
+	return meta, json.Unmarshal(data, &meta)
+}
+
+// stageMeta writes the attributes of key to a temporary file, to be renamed to metaPath(key) once the data is
+// in place, and returns its path.
+func (l *Local) stageMeta(key string, meta localMeta) (string, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	if err := l.root.MkdirAll(path.Dir(metaPath(key)), 0o755); err != nil {
+		return "", err
+	}
+	tmp := path.Join(localMetaDir, "tmp", rand.Text())
+	return tmp, l.root.WriteFile(tmp, data, 0o644)
+}
+
+func metaPath(key string) string {
+	return path.Join(localMetaDir, "objects", key+".json")
+}
+
+// escapeKey path-escapes each element of key, keeping the slashes.
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// wrapFSError maps fs.ErrNotExist to ErrNotFound.
+func wrapFSError(op, key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to %s object %s: %w", op, key, ErrNotFound)
+	}
+	return fmt.Errorf("failed to %s object %s: %w", op, key, err)
+}
+
+// contextReader fails reads once ctx is done, so a cancelled Put stops copying.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) (*Local, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(nil)
+	l, err := NewLocal(t.TempDir(), LocalOptions{BaseURL: "http://" + srv.Listener.Addr().String() + "/files/", SigningSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	srv.Config.Handler = http.StripPrefix("/files", l.Handler())
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return l, srv
+}
+
+func TestLocal_SignedURLGet(t *testing.T) {
+	l, _ := newTestLocal(t)
+	ctx := context.Background()
+	if _, err := l.Put(ctx, "reports/q1 final.csv", strings.NewReader("a,b\n"), PutOptions{ContentType: "text/csv", CacheControl: "private"}); err != nil {
+		t.Fatal(err)
+	}
+	u, err := l.SignedURL(ctx, "reports/q1 final.csv", SignedURLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(u, "/files/reports/q1%20final.csv?") {
+		t.Errorf("URL = %s", u)
+	}
+
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "a,b\n" {
+		t.Fatalf("GET = %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Content-Type") != "text/csv" || resp.Header.Get("Cache-Control") != "private" || resp.Header.Get("ETag") == "" {
+		t.Errorf("headers = %v", resp.Header)
+	}
+
+	// Tampering with the key or signature is rejected.
+	for _, bad := range []string{
+		strings.Replace(u, "q1%20final", "q2%20final", 1),
+		strings.Replace(u, "signature=", "signature=0", 1),
+	} {
+		resp, err := http.Get(bad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("GET %s = %d, want 403", bad, resp.StatusCode)
+		}
+	}
+}
+
+func TestLocal_SignedURLExpired(t *testing.T) {
+	l, _ := newTestLocal(t)
+	if _, err := l.Put(context.Background(), "a.txt", strings.NewReader("a"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	u, err := l.SignedURL(context.Background(), "a.txt", SignedURLOptions{Expires: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expired GET = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestLocal_SignedURLPut(t *testing.T) {
+	l, _ := newTestLocal(t)
+	ctx := context.Background()
+	u, err := l.SignedURL(ctx, "uploads/avatar.png", SignedURLOptions{Method: http.MethodPut, ContentType: "image/png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(contentType string) int {
+		req, _ := http.NewRequest(http.MethodPut, u, strings.NewReader("png-bytes"))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := put("text/plain"); code != http.StatusForbidden {
+		t.Errorf("PUT with wrong content type = %d, want 403", code)
+	}
+	if code := put("image/png"); code != http.StatusOK {
+		t.Fatalf("PUT = %d, want 200", code)
+	}
+	info, err := l.Stat(ctx, "uploads/avatar.png")
+	if err != nil || info.ContentType != "image/png" || info.Size != 9 {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+
+	// A PUT signature does not allow GET.
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("GET with PUT signature = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestLocal_SignedURLRequiresSecret(t *testing.T) {
+	l, err := NewLocal(t.TempDir(), LocalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := l.SignedURL(context.Background(), "a.txt", SignedURLOptions{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("err = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestLocal_FailedPutKeepsMetadata(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLocal(dir, LocalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ctx := context.Background()
+
+	if _, err := l.Put(ctx, "a/b.txt", strings.NewReader("hello"), PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// "a" is a directory, so the data cannot be renamed into place.
+	if _, err := l.Put(ctx, "a", strings.NewReader("x"), PutOptions{ContentType: "text/plain"}); err == nil {
+		t.Fatal("Put over a directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".meta", "objects", "a.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("metadata written for failed Put: %v", err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, ".meta", "tmp")); len(tmp) != 0 {
+		t.Errorf("%d temporary files left", len(tmp))
+	}
+}
+
+func TestLocal_ReservedKeysAndForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLocal(dir, LocalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ctx := context.Background()
+
+	if _, err := l.Put(ctx, ".meta/objects/x.json", strings.NewReader("{}"), PutOptions{}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put into .meta: err = %v, want ErrInvalidKey", err)
+	}
+
+	// Files copied into the root without Put are listed, with a content type from their extension.
+	if err := os.WriteFile(filepath.Join(dir, "seed.json"), []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	objs, err := l.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 || objs[0].Key != "seed.json" || objs[0].ContentType != "application/json" {
+		t.Errorf("List = %+v", objs)
+	}
+}
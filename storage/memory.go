@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Storage for tests. Put reads the data into a new buffer that is never modified, so
+// callers cannot mutate stored objects. SignedURL returns "memory://" URLs that encode the key, method, and
+// expiry but cannot be fetched.
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]*memoryObject
+	now     func() time.Time
+}
+
+type memoryObject struct {
+	info ObjectInfo
+	data []byte
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string]*memoryObject), now: time.Now}
+}
+
+// Close is a no-op; it lets Memory be closed like the other backends.
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Put implements Storage.
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	contentType, r, err := detectContentType(opts.ContentType, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	sum := md5.Sum(data)
+	obj := &memoryObject{
+		info: ObjectInfo{
+			Key:          key,
+			Size:         int64(len(data)),
+			ContentType:  contentType,
+			CacheControl: opts.CacheControl,
+			Metadata:     copyMetadata(opts.Metadata),
+			ETag:         hex.EncodeToString(sum[:]),
+			Updated:      m.now(),
+		},
+		data: data,
+	}
+	m.mu.Lock()
+	m.objects[key] = obj
+	m.mu.Unlock()
+	return obj.info.clone(), nil
+}
+
+// Get implements Storage.
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+// Delete implements Storage.
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return fmt.Errorf("failed to delete object %s: %w", key, ErrNotFound)
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+// Exists implements Storage.
+func (m *Memory) Exists(ctx context.Context, key string) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+// List implements Storage.
+func (m *Memory) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	m.mu.RLock()
+	var out []ObjectInfo
+	for key, obj := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, *obj.info.clone())
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// Stat implements Storage.
+func (m *Memory) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	obj, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return obj.info.clone(), nil
+}
+
+// SignedURL implements Storage. The URL is not fetchable; it lets tests assert what was signed.
+func (m *Memory) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("method", opts.Method)
+	q.Set("expires", strconv.FormatInt(m.now().Add(opts.Expires).Unix(), 10))
+	if opts.ContentType != "" {
+		q.Set("content_type", opts.ContentType)
+	}
+	return (&url.URL{Scheme: "memory", Path: "/" + key, RawQuery: q.Encode()}).String(), nil
+}
+
+func (m *Memory) lookup(key string) (*memoryObject, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("failed to read object %s: %w", key, ErrNotFound)
+	}
+	return obj, nil
+}
+
+func (i *ObjectInfo) clone() *ObjectInfo {
+	c := *i
+	c.Metadata = copyMetadata(i.Metadata)
+	return &c
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/turahe/pkg/s3"
+)
+
+// S3 is a Storage backed by an s3.Client and its bucket. Signed URLs are S3 presigned URLs (at most 7 days).
+type S3 struct {
+	client *s3.Client
+}
+
+// NewS3 returns an S3 Storage using c. Close closes c.
+func NewS3(c *s3.Client) *S3 {
+	return &S3{client: c}
+}
+
+// Close closes the underlying s3.Client.
+func (s *S3) Close() error {
+	return s.client.Close()
+}
+
+// Client returns the underlying s3.Client, for operations outside Storage such as multipart uploads or
+// ranged reads.
+func (s *S3) Client() *s3.Client {
+	return s.client
+}
+
+// Put implements Storage.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	contentType, r, err := detectContentType(opts.ContentType, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+	if _, err := s.client.Upload(ctx, key, r, s3.UploadOptions{
+		ContentType:  contentType,
+		CacheControl: opts.CacheControl,
+		Metadata:     opts.Metadata,
+	}); err != nil {
+		return nil, err
+	}
+	return s.Stat(ctx, key)
+}
+
+// Get implements Storage.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	r, err := s.client.NewReader(ctx, key, s3.DownloadOptions{})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	return r, nil
+}
+
+// Delete implements Storage. S3 deletes of missing objects succeed, so the object is checked first.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+	return s.client.Delete(ctx, key)
+}
+
+// Exists implements Storage.
+func (s *S3) Exists(ctx context.Context, key string) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	return s.client.Exists(ctx, key)
+}
+
+// List implements Storage.
+func (s *S3) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objs, err := s.client.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ObjectInfo, 0, len(objs))
+	for _, o := range objs {
+		out = append(out, *objectInfoFromS3(o))
+	}
+	return out, nil
+}
+
+// Stat implements Storage.
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	info, err := s.client.Attrs(ctx, key)
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	return objectInfoFromS3(info), nil
+}
+
+// SignedURL implements Storage.
+func (s *S3) SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	if opts.Method == http.MethodPut {
+		return s.client.PresignPut(ctx, key, opts.Expires, opts.ContentType)
+	}
+	return s.client.PresignGet(ctx, key, opts.Expires, nil)
+}
+
+// objectInfoFromS3 converts minio object info; list results carry no content type or metadata.
+func objectInfoFromS3(o minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          o.Key,
+		Size:         o.Size,
+		ContentType:  o.ContentType,
+		CacheControl: o.Metadata.Get("Cache-Control"),
+		Metadata:     lowerKeys(o.UserMetadata),
+		ETag:         o.ETag,
+		Updated:      o.LastModified,
+	}
+}
+
+// lowerKeys copies S3 user metadata, which minio-go returns with canonical header casing ("Owner"), using the
+// lower-case keys S3 stores.
+func lowerKeys(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+// mapS3Error marks s3.ErrNotFound with ErrNotFound; both stay matchable with errors.Is.
+func mapS3Error(err error) error {
+	if errors.Is(err, s3.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
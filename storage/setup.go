@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/gcs"
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/s3"
+)
+
+// Drivers accepted in config.StorageConfiguration.Driver.
+const (
+	DriverGCS    = "gcs"
+	DriverS3     = "s3"
+	DriverLocal  = "local"
+	DriverMemory = "memory"
+)
+
+var defaultStorage Storage
+
+// New returns the Storage selected by cfg.Storage.Driver: "gcs" (the default) uses cfg.GCS and requires
+// GCS.Enabled and a bucket name, "s3" likewise uses cfg.S3, "local" stores files under
+// cfg.Storage.LocalPath, and "memory" keeps objects in memory. ctx is used only for client creation. Every
+// returned Storage implements io.Closer; close it to release the GCS client or the local root directory.
+func New(ctx context.Context, cfg *config.Configuration) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case DriverGCS, "":
+		if !cfg.GCS.Enabled {
+			return nil, fmt.Errorf("storage driver %q requires GCS_ENABLED", DriverGCS)
+		}
+		if cfg.GCS.BucketName == "" {
+			return nil, fmt.Errorf("storage driver %q requires GCS_BUCKET_NAME", DriverGCS)
+		}
+		c, err := gcs.New(ctx, &cfg.GCS, gcs.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return NewGCS(c), nil
+	case DriverS3:
+		if !cfg.S3.Enabled {
+			return nil, fmt.Errorf("storage driver %q requires S3_ENABLED", DriverS3)
+		}
+		if cfg.S3.BucketName == "" {
+			return nil, fmt.Errorf("storage driver %q requires S3_BUCKET_NAME", DriverS3)
+		}
+		c, err := s3.New(&cfg.S3, s3.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return NewS3(c), nil
+	case DriverLocal:
+		return NewLocal(cfg.Storage.LocalPath, LocalOptions{
+			BaseURL:       cfg.Storage.BaseURL,
+			SigningSecret: cfg.Storage.SigningSecret,
+		})
+	case DriverMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// Setup creates the default Storage from config. No-op when the driver is "gcs" and GCS is disabled, so
+// existing deployments without object storage are unaffected. The "s3" driver fails when S3 is disabled.
+func Setup() error {
+	configuration := config.GetConfig()
+
+	driver := configuration.Storage.Driver
+	if (driver == DriverGCS || driver == "") && !configuration.GCS.Enabled {
+		logger.Infof("Storage driver is gcs and GCS is disabled, skipping setup")
+		return nil
+	}
+
+	s, err := New(context.Background(), configuration)
+	if err != nil {
+		return err
+	}
+	defaultStorage = s
+	logger.Infof("Storage initialized with driver: %s", driver)
+	return nil
+}
+
+// GetStorage returns the Storage created by Setup. Panics if Setup was not called or was a no-op.
+func GetStorage() Storage {
+	if defaultStorage == nil {
+		panic("Storage is not initialized. Call Setup() first.")
+	}
+	return defaultStorage
+}
+
+// Close closes the default Storage, if any.
+func Close() error {
+	if c, ok := defaultStorage.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
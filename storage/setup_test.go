@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/turahe/pkg/config"
+	"github.com/turahe/pkg/gcs"
+	"github.com/turahe/pkg/s3"
+)
+
+func TestNew_Drivers(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := New(ctx, &config.Configuration{Storage: config.StorageConfiguration{Driver: DriverMemory}})
+	if _, ok := s.(*Memory); err != nil || !ok {
+		t.Errorf("memory driver = %T, %v", s, err)
+	}
+
+	s, err = New(ctx, &config.Configuration{Storage: config.StorageConfiguration{Driver: DriverLocal, LocalPath: t.TempDir()}})
+	if _, ok := s.(*Local); err != nil || !ok {
+		t.Errorf("local driver = %T, %v", s, err)
+	} else {
+		s.(*Local).Close()
+	}
+
+	if _, err := New(ctx, &config.Configuration{Storage: config.StorageConfiguration{Driver: DriverGCS}}); err == nil {
+		t.Error("gcs driver with GCS disabled: want error")
+	}
+	s3cfg := config.S3Configuration{Enabled: true, Endpoint: "localhost:9000", Region: "us-east-1", BucketName: "b", AccessKeyID: "k", SecretAccessKey: "s"}
+	s, err = New(ctx, &config.Configuration{S3: s3cfg, Storage: config.StorageConfiguration{Driver: DriverS3}})
+	if _, ok := s.(*S3); err != nil || !ok {
+		t.Errorf("s3 driver = %T, %v", s, err)
+	}
+	if _, err := New(ctx, &config.Configuration{Storage: config.StorageConfiguration{Driver: DriverS3}}); err == nil {
+		t.Error("s3 driver with S3 disabled: want error")
+	}
+	if _, err := New(ctx, &config.Configuration{Storage: config.StorageConfiguration{Driver: "ftp"}}); err == nil {
+		t.Error("unknown driver: want error")
+	}
+}
+
+func TestMapGCSError(t *testing.T) {
+	err := mapGCSError(fmt.Errorf("failed to read object a: %w", gcs.ErrNotFound))
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, gcs.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound and gcs.ErrNotFound", err)
+	}
+	other := errors.New("boom")
+	if err := mapGCSError(other); err != other {
+		t.Errorf("err = %v, want unchanged", err)
+	}
+	if mapGCSError(nil) != nil {
+		t.Error("mapGCSError(nil) != nil")
+	}
+}
+
+func TestMapS3Error(t *testing.T) {
+	err := mapS3Error(fmt.Errorf("failed to stat object a: %w", s3.ErrNotFound))
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, s3.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound and s3.ErrNotFound", err)
+	}
+	if got := lowerKeys(map[string]string{"Owner": "u-1"}); got["owner"] != "u-1" {
+		t.Errorf("lowerKeys = %v", got)
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned (wrapped) when an object does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned when a key is empty, absolute, or contains "." or ".." elements.
+	ErrInvalidKey = errors.New("storage: invalid key")
+	// ErrNotSupported is returned when a backend cannot perform an operation as configured, e.g. signing URLs
+	// without a signing secret.
+	ErrNotSupported = errors.New("storage: operation not supported")
+)
+
+// defaultSignedURLExpiry is used when SignedURLOptions.Expires is zero.
+const defaultSignedURLExpiry = 15 * time.Minute
+
+// Storage is an object store addressed by slash-separated keys such as "avatars/u-1.png". Implementations
+// are safe for concurrent use.
+type Storage interface {
+	// Put stores the contents of r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, opts PutOptions) (*ObjectInfo, error)
+	// Get opens the object for reading. The caller must close it. Missing objects return ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Missing objects return ErrNotFound.
+	Delete(ctx context.Context, key string) error
+	// Exists reports whether an object is stored under key.
+	Exists(ctx context.Context, key string) (bool, error)
+	// List returns the objects whose keys start with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Stat returns the object's attributes. Missing objects return ErrNotFound.
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// SignedURL returns a time-limited URL that allows opts.Method on key without credentials.
+	SignedURL(ctx context.Context, key string, opts SignedURLOptions) (string, error)
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+	// ETag changes whenever the content changes; its format is backend-specific.
+	ETag    string
+	Updated time.Time
+}
+
+// PutOptions are the object attributes stored by Put.
+type PutOptions struct {
+	// ContentType of the object; detected from the first 512 bytes when empty.
+	ContentType  string
+	CacheControl string
+	// Metadata is stored as custom object metadata.
+	Metadata map[string]string
+}
+
+// SignedURLOptions configure SignedURL.
+type SignedURLOptions struct {
+	// Method is the HTTP method the URL allows: http.MethodGet (default) or http.MethodPut.
+	Method string
+	// Expires is how long the URL stays valid; default 15 minutes.
+	Expires time.Duration
+	// ContentType, for PUT, is the Content-Type header the uploader must send (empty: none).
+	ContentType string
+}
+
+func (o SignedURLOptions) withDefaults() (SignedURLOptions, error) {
+	if o.Method == "" {
+		o.Method = http.MethodGet
+	}
+	if o.Method != http.MethodGet && o.Method != http.MethodPut {
+		return o, fmt.Errorf("%w: signed URL method %s", ErrNotSupported, o.Method)
+	}
+	if o.Expires <= 0 {
+		o.Expires = defaultSignedURLExpiry
+	}
+	return o, nil
+}
+
+// validateKey rejects keys that are not clean, relative, slash-separated paths.
+func validateKey(key string) error {
+	if key == "" || key == "." || !fs.ValidPath(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// detectContentType returns contentType, or the type sniffed from the start of r when it is empty, and a
+// reader that still yields all of r.
+func detectContentType(contentType string, r io.Reader) (string, io.Reader, error) {
+	if contentType != "" {
+		return contentType, r, nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	return http.DetectContentType(head), io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// copyMetadata returns a copy of m, or nil when m is empty.
+func copyMetadata(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
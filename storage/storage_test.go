@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// backends returns every Storage that can run without external services.
+func backends(t *testing.T) map[string]Storage {
+	t.Helper()
+	local, err := NewLocal(t.TempDir(), LocalOptions{BaseURL: "http://localhost/files", SigningSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { local.Close() })
+	return map[string]Storage{"memory": NewMemory(), "local": local}
+}
+
+func readAll(t *testing.T, s Storage, key string) string {
+	t.Helper()
+	r, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStorage_PutGetStat(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			info, err := s.Put(ctx, "docs/a.txt", strings.NewReader("hello"), PutOptions{
+				ContentType:  "text/plain",
+				CacheControl: "no-cache",
+				Metadata:     map[string]string{"owner": "u-1"},
+			})
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if info.Key != "docs/a.txt" || info.Size != 5 || info.ETag == "" || info.Updated.IsZero() {
+				t.Errorf("Put info = %+v", info)
+			}
+			if got := readAll(t, s, "docs/a.txt"); got != "hello" {
+				t.Errorf("Get = %q, want hello", got)
+			}
+			stat, err := s.Stat(ctx, "docs/a.txt")
+			if err != nil {
+				t.Fatalf("Stat: %v", err)
+			}
+			if stat.ContentType != "text/plain" || stat.CacheControl != "no-cache" || stat.Metadata["owner"] != "u-1" || stat.ETag != info.ETag {
+				t.Errorf("Stat = %+v", stat)
+			}
+
+			// Overwrite replaces content and attributes.
+			info2, err := s.Put(ctx, "docs/a.txt", strings.NewReader("<html><body>hi</body></html>"), PutOptions{})
+			if err != nil {
+				t.Fatalf("Put overwrite: %v", err)
+			}
+			if info2.ETag == info.ETag || !strings.HasPrefix(info2.ContentType, "text/html") || info2.Metadata != nil {
+				t.Errorf("overwrite info = %+v", info2)
+			}
+		})
+	}
+}
+
+func TestStorage_NotFound(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get: err = %v, want ErrNotFound", err)
+			}
+			if _, err := s.Stat(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Stat: err = %v, want ErrNotFound", err)
+			}
+			if err := s.Delete(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete: err = %v, want ErrNotFound", err)
+			}
+			if ok, err := s.Exists(ctx, "missing"); err != nil || ok {
+				t.Errorf("Exists = %v, %v; want false", ok, err)
+			}
+		})
+	}
+}
+
+func TestStorage_DeleteAndExists(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := s.Put(ctx, "a/b/c.bin", strings.NewReader("x"), PutOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := s.Exists(ctx, "a/b/c.bin"); err != nil || !ok {
+				t.Fatalf("Exists = %v, %v; want true", ok, err)
+			}
+			// A key prefix is not an object.
+			if ok, _ := s.Exists(ctx, "a/b"); ok {
+				t.Error("Exists(a/b) = true for a prefix")
+			}
+			if err := s.Delete(ctx, "a/b/c.bin"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if ok, _ := s.Exists(ctx, "a/b/c.bin"); ok {
+				t.Error("Exists after Delete = true")
+			}
+		})
+	}
+}
+
+func TestStorage_List(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, key := range []string{"img/2.png", "img/1.png", "img/thumbs/1.png", "imgx.png", "doc.pdf"} {
+				if _, err := s.Put(ctx, key, strings.NewReader(key), PutOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tests := []struct {
+				prefix string
+				want   []string
+			}{
+				{"img/", []string{"img/1.png", "img/2.png", "img/thumbs/1.png"}},
+				{"img", []string{"img/1.png", "img/2.png", "img/thumbs/1.png", "imgx.png"}},
+				{"", []string{"doc.pdf", "img/1.png", "img/2.png", "img/thumbs/1.png", "imgx.png"}},
+				{"none/", nil},
+			}
+			for _, tt := range tests {
+				got, err := s.List(ctx, tt.prefix)
+				if err != nil {
+					t.Fatalf("List(%q): %v", tt.prefix, err)
+				}
+				var keys []string
+				for _, o := range got {
+					keys = append(keys, o.Key)
+				}
+				if strings.Join(keys, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("List(%q) = %v, want %v", tt.prefix, keys, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStorage_InvalidKeys(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"", ".", "/abs", "../escape", "a/../b", "a//b", "a/"} {
+				if _, err := s.Put(context.Background(), key, strings.NewReader("x"), PutOptions{}); !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("Put(%q): err = %v, want ErrInvalidKey", key, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStorage_SignedURLMethods(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := s.SignedURL(ctx, "a.txt", SignedURLOptions{}); err != nil {
+				t.Errorf("GET: %v", err)
+			}
+			if _, err := s.SignedURL(ctx, "a.txt", SignedURLOptions{Method: http.MethodPut, ContentType: "text/plain"}); err != nil {
+				t.Errorf("PUT: %v", err)
+			}
+			if _, err := s.SignedURL(ctx, "a.txt", SignedURLOptions{Method: http.MethodDelete}); !errors.Is(err, ErrNotSupported) {
+				t.Errorf("DELETE: err = %v, want ErrNotSupported", err)
+			}
+		})
+	}
+}
+
+func TestStorage_PutCancelled(t *testing.T) {
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := s.Put(ctx, "cancelled.txt", strings.NewReader("data"), PutOptions{ContentType: "text/plain"}); !errors.Is(err, context.Canceled) {
+				t.Fatalf("Put: err = %v, want context.Canceled", err)
+			}
+			if ok, _ := s.Exists(context.Background(), "cancelled.txt"); ok {
+				t.Error("object written despite cancelled context")
+			}
+		})
+	}
+}
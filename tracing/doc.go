@@ -0,0 +1,30 @@
+/*
+Package tracing provides OpenTelemetry tracing for services built on this module: tracer provider setup,
+a Gin server span per request, child spans for GORM queries and Redis commands, and W3C trace context
+propagation on outbound HTTP calls.
+
+Role in architecture:
+  - Infrastructure: instruments HTTP, database, and Redis adapters; no business logic.
+
+Responsibilities:
+  - Setup/NewProvider: build an SDK TracerProvider (service resource, parent-based ratio sampler) around a
+    caller-supplied SpanExporter; Setup also installs it and the W3C TraceContext + Baggage propagator globally.
+  - Middleware: extract traceparent/tracestate, start a server span named "METHOD /route", record status.
+  - NewGORMPlugin / InstrumentGORM: one client span per create/query/update/delete/row/raw statement.
+  - NewRedisHook / InstrumentRedis: one client span per command and per pipeline (standard and cluster clients).
+  - NewTransport: client span per outbound request and traceparent/tracestate injection.
+
+Log correlation: package logger reads trace and span IDs from the active span context, so every log line
+written inside a span (including GORM slow-query warnings) links to that span in Cloud Trace.
+
+Constraints:
+  - Exporter choice is the caller's (OTLP, Cloud Trace, stdout, or tracetest.InMemoryExporter in tests);
+    this package does not depend on any exporter.
+  - Without Setup, instrumentation uses the global no-op provider: no spans are recorded, but an incoming
+    traceparent is still propagated to outbound requests.
+  - SQL is recorded with placeholders only (bind values are never attached); Redis arguments are not recorded.
+
+This package must NOT:
+  - Contain use-case or domain logic; only span lifecycle and context propagation.
+*/
+package tracing
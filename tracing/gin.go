@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware returns Gin middleware that starts a server span per request, continuing the trace from
+// traceparent/tracestate when present. Span name is "METHOD /route" (route template, not raw path, to
+// keep cardinality bounded); 5xx responses mark the span as error.
+// Register first (before RecoveryHandler and TraceMiddleware) so all later middleware and logs see the span.
+func Middleware(opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts)
+	tr := o.tracer()
+	return func(c *gin.Context) {
+		req := c.Request
+		ctx := o.propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+		route := c.FullPath()
+		name := req.Method
+		if route != "" {
+			name += " " + route
+		}
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.path", req.URL.Path),
+			attribute.String("url.scheme", scheme),
+			attribute.String("client.address", c.ClientIP()),
+			attribute.String("user_agent.original", req.UserAgent()),
+		}
+		if route != "" {
+			attrs = append(attrs, attribute.String("http.route", route))
+		}
+		ctx, span := tr.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		c.Request = req.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, e := range c.Errors {
+			span.RecordError(e.Err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	gormPluginName   = "turahe:tracing"
+	gormParentCtxKey = "turahe:tracing:parent_ctx"
+)
+
+// gormPlugin registers before/after callbacks around each GORM operation.
+type gormPlugin struct {
+	tracer trace.Tracer
+}
+
+// NewGORMPlugin returns a gorm.Plugin that records one client span per statement. Use with db.Use(plugin)
+// or InstrumentGORM. Spans are children of the span in the statement context (pass ctx via db.WithContext).
+func NewGORMPlugin(opts ...Option) gorm.Plugin {
+	return &gormPlugin{tracer: newOptions(opts).tracer()}
+}
+
+// InstrumentGORM registers NewGORMPlugin on db, e.g. tracing.InstrumentGORM(database.GetDB()).
+func InstrumentGORM(db *gorm.DB, opts ...Option) error {
+	return db.Use(NewGORMPlugin(opts...))
+}
+
+// Name implements gorm.Plugin.
+func (p *gormPlugin) Name() string {
+	return gormPluginName
+}
+
+// Initialize implements gorm.Plugin by registering callbacks for create, query, update, delete, row, and raw.
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+func (p *gormPlugin) before(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		parent := tx.Statement.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, _ := p.tracer.Start(parent, "gorm."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", tx.Dialector.Name()),
+				attribute.String("db.operation.name", op),
+			))
+		tx.Statement.Settings.Store(gormParentCtxKey, parent)
+		tx.Statement.Context = ctx
+	}
+}
+
+func (p *gormPlugin) after(tx *gorm.DB) {
+	parent, ok := tx.Statement.Settings.LoadAndDelete(gormParentCtxKey)
+	if !ok {
+		return
+	}
+	span := trace.SpanFromContext(tx.Statement.Context)
+	if tx.Statement.Table != "" {
+		span.SetAttributes(attribute.String("db.collection.name", tx.Statement.Table))
+	}
+	if sql := tx.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(attribute.String("db.query.text", sql))
+	}
+	span.SetAttributes(attribute.Int64("db.response.rows_affected", tx.Statement.RowsAffected))
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(tx.Error)
+		span.SetStatus(codes.Error, tx.Error.Error())
+	}
+	span.End()
+	// Restore the caller's context so later statements on this session are not parented to an ended span.
+	tx.Statement.Context = parent.(context.Context)
+}
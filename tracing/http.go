@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transport wraps an http.RoundTripper with a client span and trace context injection.
+type transport struct {
+	base http.RoundTripper
+	opts *options
+	tr   trace.Tracer
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) so every outbound request gets a client span
+// and traceparent/tracestate headers for the span in the request context. Use with req.WithContext(ctx)
+// or http.NewRequestWithContext so the call is parented to the incoming request.
+func NewTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	o := newOptions(opts)
+	return &transport{base: base, opts: o, tr: o.tracer()}
+}
+
+// RoundTrip implements http.RoundTripper. The caller's request is cloned, never mutated.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tr.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			// Query strings are omitted: they often carry tokens or signatures.
+			attribute.String("url.full", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		))
+	defer span.End()
+
+	out := req.Clone(ctx)
+	t.opts.propagator.Inject(ctx, propagation.HeaderCarrier(out.Header))
+
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisHook implements redis.Hook with one client span per command or pipeline.
+type redisHook struct {
+	tracer trace.Tracer
+}
+
+// hookable is implemented by *redis.Client and *redis.ClusterClient.
+type hookable interface {
+	AddHook(redis.Hook)
+}
+
+// NewRedisHook returns a redis.Hook that records a client span per command (named by the command, e.g.
+// "GET") and per pipeline ("pipeline"). Command arguments are not recorded.
+func NewRedisHook(opts ...Option) redis.Hook {
+	return &redisHook{tracer: newOptions(opts).tracer()}
+}
+
+// InstrumentRedis adds NewRedisHook to client (*redis.Client or *redis.ClusterClient),
+// e.g. tracing.InstrumentRedis(pkgredis.GetRedis()).
+func InstrumentRedis(client hookable, opts ...Option) {
+	client.AddHook(NewRedisHook(opts...))
+}
+
+// DialHook implements redis.Hook; dials are not traced.
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook implements redis.Hook.
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", "redis"),
+				attribute.String("db.operation.name", cmd.Name()),
+			))
+		defer span.End()
+		err := next(ctx, cmd)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook.
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", "redis"),
+				attribute.String("db.operation.name", "pipeline"),
+				attribute.Int("db.operation.batch.size", len(cmds)),
+			))
+		defer span.End()
+		err := next(ctx, cmds)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+// recordRedisError marks span as failed; redis.Nil (key not found) is a normal result, not an error.
+func recordRedisError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the instrumentation scope reported on every span created by this package.
+const instrumentationName = "github.com/turahe/pkg/tracing"
+
+// Config configures the tracer provider. Exporter is required.
+type Config struct {
+	// ServiceName is reported as service.name (e.g. "payments-api").
+	ServiceName string
+	// ServiceVersion is reported as service.version (optional).
+	ServiceVersion string
+	// Environment is reported as deployment.environment.name (optional).
+	Environment string
+	// SampleRatio is the fraction of new root traces to record (0..1]. 0 means 1 (record all).
+	// Sampling decisions from an upstream traceparent are always honoured.
+	SampleRatio float64
+	// Exporter receives finished spans (OTLP, Cloud Trace, or tracetest.NewInMemoryExporter in tests).
+	Exporter sdktrace.SpanExporter
+	// Synchronous exports each span when it ends instead of batching. Use in tests only.
+	Synchronous bool
+}
+
+var (
+	providerMu sync.Mutex
+	provider   *sdktrace.TracerProvider
+)
+
+// NewProvider builds an SDK TracerProvider from cfg without installing it globally.
+func NewProvider(cfg Config) (*sdktrace.TracerProvider, error) {
+	if cfg.Exporter == nil {
+		return nil, errors.New("tracing: exporter is required")
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	attrs := []attribute.KeyValue{attribute.String("service.name", cfg.ServiceName)}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, attribute.String("service.version", cfg.ServiceVersion))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment.name", cfg.Environment))
+	}
+	processor := sdktrace.WithBatcher(cfg.Exporter)
+	if cfg.Synchronous {
+		processor = sdktrace.WithSyncer(cfg.Exporter)
+	}
+	return sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	), nil
+}
+
+// Setup builds a provider with NewProvider and installs it as the global OpenTelemetry tracer provider,
+// together with the W3C TraceContext + Baggage propagator. Call Shutdown on exit to flush pending spans.
+func Setup(cfg Config) error {
+	tp, err := NewProvider(cfg)
+	if err != nil {
+		return err
+	}
+	providerMu.Lock()
+	provider = tp
+	providerMu.Unlock()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(defaultPropagator())
+	return nil
+}
+
+// Shutdown flushes and stops the provider installed by Setup. Safe to call when Setup was not called.
+func Shutdown(ctx context.Context) error {
+	providerMu.Lock()
+	tp := provider
+	provider = nil
+	providerMu.Unlock()
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
+
+func defaultPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// options holds the provider and propagator used by instrumentation; zero values fall back to the
+// global tracer provider and the W3C TraceContext + Baggage propagator.
+type options struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// Option configures Middleware, NewTransport, NewGORMPlugin, and NewRedisHook.
+type Option func(*options)
+
+// WithTracerProvider uses tp instead of the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) { o.provider = tp }
+}
+
+// WithPropagator uses p instead of the W3C TraceContext + Baggage propagator.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) { o.propagator = p }
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, fn := range opts {
+		fn(o)
+	}
+	if o.provider == nil {
+		o.provider = otel.GetTracerProvider()
+	}
+	if o.propagator == nil {
+		o.propagator = defaultPropagator()
+	}
+	return o
+}
+
+func (o *options) tracer() trace.Tracer {
+	return o.provider.Tracer(instrumentationName)
+}
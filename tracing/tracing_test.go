@@ -0,0 +1,226 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/turahe/pkg/logger"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentID    = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testParentID + "-01"
+)
+
+func newTestProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp, err := NewProvider(Config{ServiceName: "test", Exporter: exp, Synchronous: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return tp, exp
+}
+
+func attrValue(attrs []attribute.KeyValue, key string) (attribute.Value, bool) {
+	for _, a := range attrs {
+		if string(a.Key) == key {
+			return a.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestNewProvider_RequiresExporter(t *testing.T) {
+	_, err := NewProvider(Config{ServiceName: "test"})
+	assert.Error(t, err)
+}
+
+func TestShutdown_WithoutSetup(t *testing.T) {
+	assert.NoError(t, Shutdown(context.Background()))
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("continues incoming traceparent and records route", func(t *testing.T) {
+		tp, exp := newTestProvider(t)
+		var logTraceID, logSpanID string
+		router := gin.New()
+		router.Use(Middleware(WithTracerProvider(tp)))
+		router.GET("/items/:id", func(c *gin.Context) {
+			logTraceID = logger.GetTraceID(c.Request.Context())
+			logSpanID = logger.GetSpanID(c.Request.Context())
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/items/42", nil)
+		req.Header.Set("traceparent", testTraceparent)
+		router.ServeHTTP(w, req)
+
+		spans := exp.GetSpans()
+		require.Len(t, spans, 1)
+		s := spans[0]
+		assert.Equal(t, "GET /items/:id", s.Name)
+		assert.Equal(t, trace.SpanKindServer, s.SpanKind)
+		assert.Equal(t, testTraceID, s.SpanContext.TraceID().String())
+		assert.Equal(t, testParentID, s.Parent.SpanID().String())
+		route, _ := attrValue(s.Attributes, "http.route")
+		assert.Equal(t, "/items/:id", route.AsString())
+		status, _ := attrValue(s.Attributes, "http.response.status_code")
+		assert.Equal(t, int64(200), status.AsInt64())
+		assert.Equal(t, testTraceID, logTraceID)
+		assert.Equal(t, s.SpanContext.SpanID().String(), logSpanID)
+	})
+
+	t.Run("marks 5xx as error", func(t *testing.T) {
+		tp, exp := newTestProvider(t)
+		router := gin.New()
+		router.Use(Middleware(WithTracerProvider(tp)))
+		router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/fail", nil)
+		router.ServeHTTP(w, req)
+
+		spans := exp.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.False(t, spans[0].Parent.IsValid())
+	})
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Run("injects traceparent of the client span", func(t *testing.T) {
+		tp, exp := newTestProvider(t)
+		var gotTraceparent string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotTraceparent = r.Header.Get("traceparent")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+		client := &http.Client{Transport: NewTransport(nil, WithTracerProvider(tp))}
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/path?token=secret", nil)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		parent.End()
+
+		spans := exp.GetSpans()
+		require.Len(t, spans, 2)
+		clientSpan := spans[0]
+		assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind)
+		assert.Equal(t, parent.SpanContext().SpanID(), clientSpan.Parent.SpanID())
+		assert.Equal(t, "00-"+clientSpan.SpanContext.TraceID().String()+"-"+clientSpan.SpanContext.SpanID().String()+"-01", gotTraceparent)
+		full, _ := attrValue(clientSpan.Attributes, "url.full")
+		assert.NotContains(t, full.AsString(), "secret")
+		assert.Empty(t, req.Header.Get("traceparent"), "caller request must not be mutated")
+	})
+
+	t.Run("propagates remote parent without SDK provider", func(t *testing.T) {
+		var gotTraceparent string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotTraceparent = r.Header.Get("traceparent")
+		}))
+		defer srv.Close()
+
+		tid, _ := trace.TraceIDFromHex(testTraceID)
+		sid, _ := trace.SpanIDFromHex(testParentID)
+		ctx := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: tid, SpanID: sid, TraceFlags: trace.FlagsSampled, Remote: true,
+		}))
+		client := &http.Client{Transport: NewTransport(nil, WithTracerProvider(noop.NewTracerProvider()))}
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, testTraceparent, gotTraceparent)
+	})
+}
+
+type gormTestRow struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func TestGORMPlugin(t *testing.T) {
+	tp, exp := newTestProvider(t)
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Skipf("sqlite: %v", err)
+	}
+	require.NoError(t, InstrumentGORM(db, WithTracerProvider(tp)))
+	require.NoError(t, db.AutoMigrate(&gormTestRow{}))
+	exp.Reset()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	require.NoError(t, db.WithContext(ctx).Create(&gormTestRow{Name: "a"}).Error)
+	var row gormTestRow
+	require.NoError(t, db.WithContext(ctx).First(&row).Error)
+	err = db.WithContext(ctx).Where("id = ?", 999).First(&row).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	parent.End()
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 4)
+	assert.Equal(t, "gorm.create", spans[0].Name)
+	assert.Equal(t, "gorm.query", spans[1].Name)
+	for _, s := range spans[:3] {
+		assert.Equal(t, parent.SpanContext().SpanID(), s.Parent.SpanID())
+		assert.Equal(t, trace.SpanKindClient, s.SpanKind)
+		table, _ := attrValue(s.Attributes, "db.collection.name")
+		assert.Equal(t, "gorm_test_rows", table.AsString())
+	}
+	stmt, _ := attrValue(spans[0].Attributes, "db.query.text")
+	assert.Contains(t, stmt.AsString(), "INSERT INTO")
+	assert.NotContains(t, stmt.AsString(), `"a"`, "bind values must not be recorded")
+	assert.Equal(t, codes.Unset, spans[2].Status.Code, "record not found is not an error")
+}
+
+func TestRedisHook(t *testing.T) {
+	tp, exp := newTestProvider(t)
+	hook := NewRedisHook(WithTracerProvider(tp))
+
+	var seenSpan trace.SpanContext
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		seenSpan = trace.SpanContextFromContext(ctx)
+		return redis.Nil
+	})
+	cmd := redis.NewStringCmd(context.Background(), "get", "key")
+	assert.ErrorIs(t, process(context.Background(), cmd), redis.Nil)
+
+	pipeline := hook.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		return assert.AnError
+	})
+	assert.Error(t, pipeline(context.Background(), []redis.Cmder{cmd, cmd}))
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "get", spans[0].Name)
+	assert.Equal(t, spans[0].SpanContext.SpanID(), seenSpan.SpanID())
+	assert.Equal(t, codes.Unset, spans[0].Status.Code, "redis.Nil is not an error")
+	assert.Equal(t, "pipeline", spans[1].Name)
+	size, _ := attrValue(spans[1].Attributes, "db.operation.batch.size")
+	assert.Equal(t, int64(2), size.AsInt64())
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
@@ -0,0 +1,26 @@
+/*
+Package upload validates user-supplied files before they reach object storage.
+
+Role in architecture:
+  - Infrastructure service between handlers and storage.Storage: every file accepted from users goes through a
+    Pipeline instead of being written with the type and size the client claims.
+
+Responsibilities:
+  - Pipeline (New, Upload): per-category rules (Category: AllowedTypes, MaxSize, StripMetadata). Upload
+    enforces the size limit, sniffs the MIME type from magic bytes (DetectContentType) and checks it against
+    the allowlist (AnyType opts in to any type), strips image metadata (StripMetadata: EXIF/XMP/IPTC/text
+    from JPEG, PNG, WebP), stores the SHA-256 checksum and category as object metadata, and scans the file.
+  - Scanner: pluggable malware scanning; Clamd (NewClamd) speaks the clamd INSTREAM protocol over TCP or a
+    Unix socket. Infected files are stored under Options.QuarantinePrefix and return ErrInfected.
+  - Errors: ErrUnknownCategory, ErrTooLarge, ErrTypeNotAllowed, ErrMalformed, ErrInfected, ErrScanFailed.
+
+Constraints:
+  - A category with an empty AllowedTypes rejects every file (ErrTypeNotAllowed).
+  - Files are buffered in memory; Category.MaxSize (default 10 MiB) bounds memory per upload.
+  - Metadata stripping edits the container without re-encoding; the EXIF orientation is dropped with it.
+  - Scanner failures reject the upload unless Options.FailOpen is set.
+
+This package must NOT:
+  - Decide object keys or naming schemes, or talk to a storage backend other than through storage.Storage.
+*/
+package upload
@@ -0,0 +1,63 @@
+package upload
+
+import "maps"
+
+const (
+	// defaultMaxSize is the Category.MaxSize used when it is zero.
+	defaultMaxSize = 10 << 20
+	// defaultQuarantinePrefix is the key prefix under which infected files are stored.
+	defaultQuarantinePrefix = "quarantine/"
+)
+
+// Options holds Pipeline settings. applyDefaults fills zero values with package defaults.
+type Options struct {
+	// Categories maps a category name ("avatar", "kyc-document") to its rules. Upload rejects unknown
+	// categories.
+	Categories map[string]Category
+	// Scanner scans every file before it is stored; nil disables scanning.
+	Scanner Scanner
+	// QuarantinePrefix is prepended to the key of infected files; default "quarantine/". Keep it outside
+	// every prefix that is served to users.
+	QuarantinePrefix string
+	// FailOpen stores files when the scanner fails, logging a warning; default false rejects them with
+	// ErrScanFailed.
+	FailOpen bool
+}
+
+func (o *Options) applyDefaults() {
+	if o.QuarantinePrefix == "" {
+		o.QuarantinePrefix = defaultQuarantinePrefix
+	}
+	categories := make(map[string]Category, len(o.Categories))
+	for name, c := range o.Categories {
+		if c.MaxSize <= 0 {
+			c.MaxSize = defaultMaxSize
+		}
+		categories[name] = c
+	}
+	o.Categories = categories
+}
+
+// Option is a functional option applied to Options.
+type Option func(*Options)
+
+// WithCategory adds or replaces the rules of category name. The caller's Options.Categories map is not
+// modified.
+func WithCategory(name string, c Category) Option {
+	return func(o *Options) {
+		categories := make(map[string]Category, len(o.Categories)+1)
+		maps.Copy(categories, o.Categories)
+		categories[name] = c
+		o.Categories = categories
+	}
+}
+
+// WithScanner sets the malware scanner.
+func WithScanner(s Scanner) Option {
+	return func(o *Options) { o.Scanner = s }
+}
+
+// WithQuarantinePrefix sets the key prefix for infected files.
+func WithQuarantinePrefix(prefix string) Option {
+	return func(o *Options) { o.QuarantinePrefix = prefix }
+}
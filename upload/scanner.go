@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Scanner scans file content for malware. Implementations are safe for concurrent use.
+type Scanner interface {
+	// Scan reads r to the end and reports whether it contains a threat. An error means the content could not
+	// be scanned, not that it is infected.
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+// Verdict is the result of a scan.
+type Verdict struct {
+	Infected bool
+	// Threat is the name of the signature that matched, e.g. "Eicar-Test-Signature".
+	Threat string
+}
+
+const (
+	defaultClamdTimeout = 30 * time.Second
+	// clamdChunkSize is the size of INSTREAM chunks; it must stay below clamd's StreamMaxLength.
+	clamdChunkSize = 64 << 10
+)
+
+// Clamd is a Scanner that streams content to a ClamAV daemon with the INSTREAM command.
+type Clamd struct {
+	network, address string
+	timeout          time.Duration
+}
+
+// NewClamd returns a Clamd for address: "host:port" for TCP or "unix:/path/to/clamd.ctl" for a Unix socket.
+// timeout bounds each scan, on top of the context deadline; default 30s.
+func NewClamd(address string, timeout time.Duration) *Clamd {
+	if timeout <= 0 {
+		timeout = defaultClamdTimeout
+	}
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	}
+	return &Clamd{network: network, address: address, timeout: timeout}
+}
+
+// Scan implements Scanner. Files larger than clamd's StreamMaxLength fail with an error.
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	reply, err := c.command(ctx, "zINSTREAM\x00", func(conn net.Conn) error {
+		buf := make([]byte, 4+clamdChunkSize)
+		for {
+			n, err := io.ReadFull(r, buf[4:])
+			if n > 0 {
+				binary.BigEndian.PutUint32(buf, uint32(n))
+				if _, werr := conn.Write(buf[:4+n]); werr != nil {
+					return werr
+				}
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err := conn.Write([]byte{0, 0, 0, 0})
+		return err
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+	// Replies are "stream: OK", "stream: <threat> FOUND", or "<message> ERROR".
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return Verdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return Verdict{Infected: true, Threat: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return Verdict{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// Ping checks that the daemon is reachable and answering.
+func (c *Clamd) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: unexpected PING reply %q", reply)
+	}
+	return nil
+}
+
+// command sends cmd, then lets send write any payload, and returns the reply without its terminator.
+func (c *Clamd) command(ctx context.Context, cmd string, send func(net.Conn) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := io.WriteString(conn, cmd); err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	if send != nil {
+		if err := send(conn); err != nil {
+			return "", fmt.Errorf("clamd: %w", err)
+		}
+	}
+	// z-prefixed commands get a NUL-terminated reply.
+	reply, err := bufio.NewReader(io.LimitReader(conn, 4096)).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
@@ -0,0 +1,100 @@
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd serves the clamd PING and INSTREAM commands, reporting content that contains "EICAR" as infected
+// and content larger than maxStream as an error.
+func fakeClamd(t *testing.T, maxStream int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxStream)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxStream int) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	cmd, err := br.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch cmd {
+	case "zPING\x00":
+		io.WriteString(conn, "PONG\x00")
+	case "zINSTREAM\x00":
+		var data bytes.Buffer
+		var size [4]byte
+		for {
+			if _, err := io.ReadFull(br, size[:]); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(size[:])
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&data, br, int64(n)); err != nil {
+				return
+			}
+		}
+		switch {
+		case data.Len() > maxStream:
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+		case bytes.Contains(data.Bytes(), []byte("EICAR")):
+			io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+		default:
+			io.WriteString(conn, "stream: OK\x00")
+		}
+	default:
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+	}
+}
+
+func TestClamd(t *testing.T) {
+	c := NewClamd(fakeClamd(t, 1<<20), time.Second)
+	ctx := context.Background()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	v, err := c.Scan(ctx, bytes.NewReader(bytes.Repeat([]byte("clean "), 30000))) // several chunks
+	if err != nil || v.Infected {
+		t.Errorf("clean scan = %+v, %v", v, err)
+	}
+	v, err = c.Scan(ctx, strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	if err != nil || !v.Infected || v.Threat != "Eicar-Test-Signature" {
+		t.Errorf("infected scan = %+v, %v", v, err)
+	}
+	if _, err := c.Scan(ctx, bytes.NewReader(make([]byte, 2<<20))); err == nil || !strings.Contains(err.Error(), "size limit") {
+		t.Errorf("oversized scan: err = %v, want clamd error", err)
+	}
+
+	down := NewClamd("127.0.0.1:1", time.Second)
+	if _, err := down.Scan(ctx, strings.NewReader("x")); err == nil {
+		t.Error("unreachable clamd: want error")
+	}
+	if c := NewClamd("unix:/run/clamav/clamd.ctl", 0); c.network != "unix" || c.address != "/run/clamav/clamd.ctl" || c.timeout != defaultClamdTimeout {
+		t.Errorf("unix clamd = %+v", c)
+	}
+}
@@ -0,0 +1,43 @@
+package upload
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// isoBrands maps ISO base media file brands (the "ftyp" box) that http.DetectContentType does not know.
+var isoBrands = map[string]string{
+	"heic": "image/heic",
+	"heix": "image/heic",
+	"mif1": "image/heif",
+	"avif": "image/avif",
+}
+
+// DetectContentType returns the media type of data from its magic bytes, without parameters (e.g.
+// "text/plain", not "text/plain; charset=utf-8"). It extends http.DetectContentType with HEIC/HEIF and AVIF
+// images and returns "application/octet-stream" for unknown content.
+func DetectContentType(data []byte) string {
+	if len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) {
+		if t, ok := isoBrands[string(data[8:12])]; ok {
+			return t
+		}
+	}
+	mt, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mt
+}
+
+// typeAllowed reports whether contentType matches an entry of allowed; an empty list allows nothing.
+func typeAllowed(contentType string, allowed []string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if a == AnyType || a == contentType || (strings.HasSuffix(a, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
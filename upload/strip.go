@@ -0,0 +1,136 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// StripMetadata returns data without its EXIF, XMP, IPTC, and comment/text metadata when contentType is
+// image/jpeg, image/png, or image/webp, and data unchanged for other types. Image data is copied as is, not
+// re-encoded. Structurally invalid images return ErrMalformed.
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// JPEG markers dropped by stripJPEG: APP1 (EXIF, XMP), APP13 (IPTC), and COM. APP0 (JFIF), APP2 (ICC
+// profile), and APP14 (Adobe colour transform) affect rendering and are kept.
+var jpegDropMarkers = map[byte]bool{0xE1: true, 0xED: true, 0xFE: true}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("%w: missing JPEG SOI marker", ErrMalformed)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("%w: JPEG marker expected at offset %d", ErrMalformed, i)
+		}
+		for i < len(data) && data[i] == 0xFF { // fill bytes
+			i++
+		}
+		if i >= len(data) {
+			break
+		}
+		marker := data[i]
+		i++
+		if marker == 0xD9 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 { // EOI, RSTn, TEM: no length
+			out = append(out, 0xFF, marker)
+			continue
+		}
+		if i+2 > len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG segment", ErrMalformed)
+		}
+		end := i + int(binary.BigEndian.Uint16(data[i:]))
+		if end > len(data) || end < i+2 {
+			return nil, fmt.Errorf("%w: JPEG segment overruns data", ErrMalformed)
+		}
+		if marker == 0xDA { // start of scan: the rest is entropy-coded data and later scans
+			out = append(out, 0xFF, marker)
+			return append(out, data[i:]...), nil
+		}
+		if !jpegDropMarkers[marker] {
+			out = append(out, 0xFF, marker)
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG chunks dropped by stripPNG.
+var pngDropChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("%w: missing PNG signature", ErrMalformed)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated PNG chunk", ErrMalformed)
+		}
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + n // length, type, data, CRC
+		if n < 0 || end > len(data) {
+			return nil, fmt.Errorf("%w: PNG chunk overruns data", ErrMalformed)
+		}
+		if !pngDropChunks[string(data[i+4:i+8])] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// VP8X feature flags for metadata chunks.
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("%w: missing WebP RIFF header", ErrMalformed)
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("%w: truncated WebP chunk", ErrMalformed)
+		}
+		fourCC := string(data[i : i+4])
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + n + n&1 // chunks are padded to an even size
+		if n < 0 || end > len(data) {
+			return nil, fmt.Errorf("%w: WebP chunk overruns data", ErrMalformed)
+		}
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if n > 0 {
+				out[start+8] &^= webpFlagEXIF | webpFlagXMP
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := range 8 {
+		img.Set(x, x, color.RGBA{R: 255, A: 255})
+	}
+	return img
+}
+
+func TestStripMetadata_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	clean := buf.Bytes()
+	exif := append([]byte("Exif\x00\x00"), []byte("GPS 52.37N 4.89E")...)
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+	comment := []byte{0xFF, 0xFE, 0, 7, 'h', 'e', 'l', 'l', 'o'}
+	tagged := append(append(append([]byte{0xFF, 0xD8}, segment...), comment...), clean[2:]...)
+
+	got, err := StripMetadata("image/jpeg", tagged)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if !bytes.Equal(got, clean) {
+		t.Errorf("stripped JPEG (%d bytes) differs from the original (%d bytes)", len(got), len(clean))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("decode stripped JPEG: %v", err)
+	}
+	if _, err := StripMetadata("image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated JPEG: err = %v, want ErrMalformed", err)
+	}
+}
+
+func TestStripMetadata_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	clean := buf.Bytes()
+	text := pngChunk("tEXt", []byte("Author\x00Jane"))
+	ihdrEnd := len(pngSignature) + 12 + 13
+	tagged := append(append(append([]byte{}, clean[:ihdrEnd]...), text...), clean[ihdrEnd:]...)
+
+	got, err := StripMetadata("image/png", tagged)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if !bytes.Equal(got, clean) {
+		t.Errorf("stripped PNG (%d bytes) differs from the original (%d bytes)", len(got), len(clean))
+	}
+	if _, err := StripMetadata("image/png", append(clean[:ihdrEnd:ihdrEnd], 0, 0, 1, 0, 'I', 'D')); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated PNG: err = %v, want ErrMalformed", err)
+	}
+}
+
+func TestStripMetadata_WebP(t *testing.T) {
+	vp8x := webpChunk("VP8X", []byte{webpFlagEXIF | webpFlagXMP | 0x10, 0, 0, 0, 7, 0, 0, 7, 0, 0})
+	bitstream := webpChunk("VP8L", []byte{0x2F, 1, 2}) // odd size: padded
+	tagged := riff(vp8x, bitstream, webpChunk("EXIF", []byte("Exif\x00\x00GPS")), webpChunk("XMP ", []byte("<x/>")))
+
+	got, err := StripMetadata("image/webp", tagged)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	vp8x[8] = 0x10 // alpha flag kept, metadata flags cleared
+	if want := riff(vp8x, bitstream); !bytes.Equal(got, want) {
+		t.Errorf("stripped WebP = %x, want %x", got, want)
+	}
+}
+
+func TestStripMetadata_OtherTypes(t *testing.T) {
+	data := []byte("%PDF-1.7")
+	if got, err := StripMetadata("application/pdf", data); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("PDF = %q, %v; want unchanged", got, err)
+	}
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(append(out, typ...), data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[4:]))
+}
+
+func webpChunk(fourCC string, data []byte) []byte {
+	out := binary.LittleEndian.AppendUint32([]byte(fourCC), uint32(len(data)))
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
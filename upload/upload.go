@@ -0,0 +1,162 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+
+	"github.com/turahe/pkg/logger"
+	"github.com/turahe/pkg/storage"
+)
+
+var (
+	// ErrUnknownCategory is returned when Upload is called with a category that is not configured.
+	ErrUnknownCategory = errors.New("upload: unknown category")
+	// ErrTooLarge is returned (wrapped) when a file exceeds its category's MaxSize.
+	ErrTooLarge = errors.New("upload: file too large")
+	// ErrTypeNotAllowed is returned (wrapped) when the sniffed type of a file is not in its category's
+	// AllowedTypes.
+	ErrTypeNotAllowed = errors.New("upload: file type not allowed")
+	// ErrMalformed is returned (wrapped) when an image's metadata cannot be stripped because its structure is
+	// invalid.
+	ErrMalformed = errors.New("upload: malformed file")
+	// ErrInfected is returned (wrapped) when the scanner finds a threat; the file is stored under the
+	// quarantine prefix.
+	ErrInfected = errors.New("upload: file infected")
+	// ErrScanFailed is returned (wrapped) when the scanner fails and Options.FailOpen is false.
+	ErrScanFailed = errors.New("upload: scan failed")
+)
+
+// AnyType in Category.AllowedTypes accepts files of any type.
+const AnyType = "*/*"
+
+// Metadata keys set on stored objects.
+const (
+	MetadataSHA256   = "sha256"
+	MetadataCategory = "upload-category"
+	MetadataThreat   = "threat"
+)
+
+// Category holds the rules for one kind of upload.
+type Category struct {
+	// AllowedTypes lists accepted media types, exact ("application/pdf") or by top-level type ("image/*").
+	// They are matched against the type sniffed from the content, never the one claimed by the client. A
+	// category without AllowedTypes rejects every file; list AnyType to accept any type.
+	AllowedTypes []string
+	// MaxSize is the largest accepted file in bytes; default 10 MiB. Files are buffered in memory, so it also
+	// bounds memory per upload.
+	MaxSize int64
+	// StripMetadata removes EXIF, XMP, IPTC, and text metadata from JPEG, PNG, and WebP images (GPS position,
+	// camera serial, etc.) without re-encoding. The EXIF orientation is removed too.
+	StripMetadata bool
+}
+
+// PutOptions are the attributes Upload stores besides the sniffed content type and checksum.
+type PutOptions struct {
+	CacheControl string
+	// Metadata is stored as custom object metadata; the MetadataSHA256 and MetadataCategory keys are
+	// overwritten.
+	Metadata map[string]string
+}
+
+// Result describes a stored upload.
+type Result struct {
+	Info        *storage.ObjectInfo
+	ContentType string
+	Size        int64
+	// SHA256 is the hex checksum of the stored bytes (after metadata stripping).
+	SHA256 string
+}
+
+// Pipeline validates files and stores them in a storage.Storage.
+type Pipeline struct {
+	store storage.Storage
+	opts  Options
+}
+
+// New returns a Pipeline storing into store. Options are applied first, then override.
+func New(store storage.Storage, opts Options, override ...Option) *Pipeline {
+	for _, o := range override {
+		o(&opts)
+	}
+	opts.applyDefaults()
+	return &Pipeline{store: store, opts: opts}
+}
+
+// Upload validates r against the rules of category and stores it under key: the size is checked against
+// MaxSize, the MIME type is sniffed from the content and checked against AllowedTypes, image metadata is
+// stripped if configured, the SHA-256 checksum is computed, and the Scanner (if any) scans the result.
+// Infected files are stored under QuarantinePrefix+key with the threat in their metadata and Upload returns
+// ErrInfected. The content type claimed by the client is ignored.
+func (p *Pipeline) Upload(ctx context.Context, category, key string, r io.Reader, opts PutOptions) (*Result, error) {
+	cat, ok := p.opts.Categories[category]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCategory, category)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, cat.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload %s: %w", key, err)
+	}
+	if int64(len(data)) > cat.MaxSize {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrTooLarge, key, cat.MaxSize)
+	}
+
+	contentType := DetectContentType(data)
+	if !typeAllowed(contentType, cat.AllowedTypes) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrTypeNotAllowed, key, contentType)
+	}
+	if cat.StripMetadata {
+		if data, err = StripMetadata(contentType, data); err != nil {
+			return nil, fmt.Errorf("failed to strip metadata from %s: %w", key, err)
+		}
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	meta := maps.Clone(opts.Metadata)
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	meta[MetadataSHA256] = checksum
+	meta[MetadataCategory] = category
+
+	if p.opts.Scanner != nil {
+		verdict, err := p.opts.Scanner.Scan(ctx, bytes.NewReader(data))
+		switch {
+		case err != nil && !p.opts.FailOpen:
+			return nil, fmt.Errorf("%w: %s: %w", ErrScanFailed, key, err)
+		case err != nil:
+			logger.WarnfContext(ctx, "upload scan failed for %s, storing unscanned: %v", key, err)
+		case verdict.Infected:
+			return nil, p.quarantine(ctx, key, data, contentType, meta, verdict.Threat)
+		}
+	}
+
+	info, err := p.store.Put(ctx, key, bytes.NewReader(data), storage.PutOptions{
+		ContentType:  contentType,
+		CacheControl: opts.CacheControl,
+		Metadata:     meta,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Info: info, ContentType: contentType, Size: int64(len(data)), SHA256: checksum}, nil
+}
+
+// quarantine stores an infected file under the quarantine prefix and returns the ErrInfected error for it.
+func (p *Pipeline) quarantine(ctx context.Context, key string, data []byte, contentType string, meta map[string]string, threat string) error {
+	qkey := p.opts.QuarantinePrefix + key
+	meta[MetadataThreat] = threat
+	if _, err := p.store.Put(ctx, qkey, bytes.NewReader(data), storage.PutOptions{
+		ContentType: contentType,
+		Metadata:    meta,
+	}); err != nil {
+		return fmt.Errorf("%w: %s (%s); failed to quarantine: %w", ErrInfected, key, threat, err)
+	}
+	return fmt.Errorf("%w: %s (%s), quarantined as %s", ErrInfected, key, threat, qkey)
+}
@@ -0,0 +1,179 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/turahe/pkg/storage"
+)
+
+type stubScanner struct {
+	verdict Verdict
+	err     error
+	scanned []byte
+}
+
+func (s *stubScanner) Scan(_ context.Context, r io.Reader) (Verdict, error) {
+	s.scanned, _ = io.ReadAll(r)
+	return s.verdict, s.err
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestPipeline(scanner *stubScanner) (*Pipeline, *storage.Memory) {
+	store := storage.NewMemory()
+	p := New(store, Options{}, WithCategory("avatar", Category{
+		AllowedTypes:  []string{"image/png", "image/jpeg"},
+		MaxSize:       1 << 10,
+		StripMetadata: true,
+	}), WithCategory("document", Category{AllowedTypes: []string{"application/pdf"}}), WithScanner(scanner))
+	return p, store
+}
+
+func TestPipeline_Upload(t *testing.T) {
+	scanner := &stubScanner{}
+	p, store := newTestPipeline(scanner)
+	ctx := context.Background()
+	clean := pngBytes(t)
+	ihdrEnd := len(pngSignature) + 12 + 13
+	tagged := append(append(append([]byte{}, clean[:ihdrEnd]...), pngChunk("tEXt", []byte("GPS\x0052N"))...), clean[ihdrEnd:]...)
+
+	res, err := p.Upload(ctx, "avatar", "avatars/u-1.png", bytes.NewReader(tagged), PutOptions{
+		CacheControl: "public, max-age=3600",
+		Metadata:     map[string]string{"owner": "u-1", MetadataSHA256: "forged"},
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	sum := sha256.Sum256(clean)
+	if res.ContentType != "image/png" || res.Size != int64(len(clean)) || res.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("result = %+v", res)
+	}
+	info, err := store.Stat(ctx, "avatars/u-1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ContentType != "image/png" || info.CacheControl != "public, max-age=3600" || info.Metadata["owner"] != "u-1" ||
+		info.Metadata[MetadataSHA256] != res.SHA256 || info.Metadata[MetadataCategory] != "avatar" {
+		t.Errorf("stored info = %+v", info)
+	}
+	if !bytes.Equal(scanner.scanned, clean) {
+		t.Error("scanner did not receive the stripped content")
+	}
+}
+
+func TestPipeline_Rejects(t *testing.T) {
+	p, store := newTestPipeline(&stubScanner{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		category string
+		data     []byte
+		want     error
+	}{
+		{"unknown category", "video", pngBytes(t), ErrUnknownCategory},
+		{"too large", "avatar", append(pngBytes(t), make([]byte, 1<<10)...), ErrTooLarge},
+		{"script named .png", "avatar", []byte("#!/bin/sh\necho pwned\n"), ErrTypeNotAllowed},
+		{"image as document", "document", pngBytes(t), ErrTypeNotAllowed},
+		{"truncated image", "avatar", pngBytes(t)[:20], ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.Upload(ctx, tt.category, "x.png", bytes.NewReader(tt.data), PutOptions{}); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if ok, _ := store.Exists(ctx, "x.png"); ok {
+				t.Error("rejected file stored")
+			}
+		})
+	}
+
+	if _, err := p.Upload(ctx, "document", "doc.pdf", strings.NewReader("%PDF-1.7\n"), PutOptions{}); err != nil {
+		t.Errorf("PDF with default MaxSize: %v", err)
+	}
+}
+
+func TestPipeline_AnyTypeAndOptionsNotShared(t *testing.T) {
+	categories := map[string]Category{"attachment": {AllowedTypes: []string{AnyType}}, "unset": {}}
+	p := New(storage.NewMemory(), Options{Categories: categories}, WithCategory("avatar", Category{AllowedTypes: []string{"image/png"}}))
+	if len(categories) != 2 {
+		t.Errorf("caller's Categories modified: %v", categories)
+	}
+	ctx := context.Background()
+	if _, err := p.Upload(ctx, "attachment", "a.txt", strings.NewReader("hello"), PutOptions{}); err != nil {
+		t.Errorf("AnyType: %v, want any type accepted", err)
+	}
+	if _, err := p.Upload(ctx, "unset", "a.txt", strings.NewReader("hello"), PutOptions{}); !errors.Is(err, ErrTypeNotAllowed) {
+		t.Errorf("empty AllowedTypes: err = %v, want ErrTypeNotAllowed", err)
+	}
+	if _, err := p.Upload(ctx, "avatar", "a.png", strings.NewReader("hello"), PutOptions{}); !errors.Is(err, ErrTypeNotAllowed) {
+		t.Errorf("avatar: err = %v, want ErrTypeNotAllowed", err)
+	}
+}
+
+func TestPipeline_Quarantine(t *testing.T) {
+	p, store := newTestPipeline(&stubScanner{verdict: Verdict{Infected: true, Threat: "Eicar-Test-Signature"}})
+	ctx := context.Background()
+
+	_, err := p.Upload(ctx, "document", "kyc/u-1.pdf", strings.NewReader("%PDF-1.7\nEICAR"), PutOptions{})
+	if !errors.Is(err, ErrInfected) || !strings.Contains(err.Error(), "quarantine/kyc/u-1.pdf") {
+		t.Fatalf("err = %v, want ErrInfected with the quarantine key", err)
+	}
+	if ok, _ := store.Exists(ctx, "kyc/u-1.pdf"); ok {
+		t.Error("infected file stored under its key")
+	}
+	info, err := store.Stat(ctx, "quarantine/kyc/u-1.pdf")
+	if err != nil || info.Metadata[MetadataThreat] != "Eicar-Test-Signature" {
+		t.Errorf("quarantined info = %+v, %v", info, err)
+	}
+}
+
+func TestPipeline_ScanFailure(t *testing.T) {
+	scanner := &stubScanner{err: errors.New("connection refused")}
+	p, store := newTestPipeline(scanner)
+	ctx := context.Background()
+
+	if _, err := p.Upload(ctx, "document", "a.pdf", strings.NewReader("%PDF-1.7"), PutOptions{}); !errors.Is(err, ErrScanFailed) {
+		t.Errorf("fail closed: err = %v, want ErrScanFailed", err)
+	}
+	p.opts.FailOpen = true
+	if _, err := p.Upload(ctx, "document", "a.pdf", strings.NewReader("%PDF-1.7"), PutOptions{}); err != nil {
+		t.Errorf("fail open: %v", err)
+	}
+	if ok, _ := store.Exists(ctx, "a.pdf"); !ok {
+		t.Error("fail open: file not stored")
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := map[string]string{
+		"\x00\x00\x00\x18ftypheic\x00\x00\x00\x00": "image/heic",
+		"\x00\x00\x00\x1cftypavif\x00\x00\x00\x00": "image/avif",
+		"%PDF-1.7":         "application/pdf",
+		"plain text":       "text/plain",
+		"\x00\x01\x02\x03": "application/octet-stream",
+	}
+	for data, want := range tests {
+		if got := DetectContentType([]byte(data)); got != want {
+			t.Errorf("DetectContentType(%q) = %q, want %q", data, got, want)
+		}
+	}
+	if !typeAllowed("image/webp", []string{"IMAGE/*"}) || typeAllowed("imagery/png", []string{"image/*"}) || typeAllowed("text/plain", nil) {
+		t.Error("typeAllowed wildcard mismatch")
+	}
+}